@@ -5,15 +5,28 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go/service/organizations"
 	"github.com/mitchellh/cli"
 	"github.com/pr8kerl/organizer/aws"
 )
 
+type accountRow struct {
+	Id       string            `json:"id" yaml:"id"`
+	Name     string            `json:"name" yaml:"name"`
+	Email    string            `json:"email" yaml:"email"`
+	Status   string            `json:"status" yaml:"status"`
+	Joined   *time.Time        `json:"joined" yaml:"joined"`
+	JoinedBy string            `json:"joined_method" yaml:"joined_method"`
+	Tags     map[string]string `json:"tags" yaml:"tags"`
+}
+
 // List Account
 type ListAccountsCommand struct {
 	All bool
 	Ui  cli.Ui
+	orgOptions
 }
 
 func listAccountsCmdFactory() (cli.Command, error) {
@@ -34,29 +47,62 @@ func (c *ListAccountsCommand) Run(args []string) int {
 
 	cmdFlags := flag.NewFlagSet("list accounts", flag.ContinueOnError)
 	cmdFlags.BoolVar(&c.All, "all", false, "show all accounts including inactive")
+	c.addFlags(cmdFlags)
 	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
 
-	org, err := aws.NewOrganization()
+	org, err := c.newOrganization()
 	if err != nil {
-		fmt.Printf("error: could not initialize organization: %s\n", err)
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
 		return 1
 	}
 
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	filter, err := aws.ParseAccountFilter(c.filter)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: %s", err))
+		return 1
+	}
+
+	var accounts []*organizations.Account
 	if c.All {
-		err = org.PrintAccounts()
-		if err != nil {
-			fmt.Printf("error: could not list accounts: %s\n", err)
-			return 1
-		}
+		accounts, err = org.GetAccounts()
 	} else {
-		err = org.PrintActiveAccounts()
-		if err != nil {
-			fmt.Printf("error: could not list accounts: %s\n", err)
-			return 1
+		accounts, err = org.GetActiveAccounts()
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not list accounts: %s", err))
+		return 1
+	}
+
+	tags, errs := org.GetTagsForAccounts(ctx, accounts)
+	printAccountErrors(c.Ui, "could not get tags", errs)
+
+	rows := make([]accountRow, 0, len(accounts))
+	for _, account := range accounts {
+		if !filter.Match(tags[stringValue(account.Id)]) {
+			continue
 		}
+		// for a newly created account, it can take a while for all the account fields
+		// to be populated, so every field may be nil
+		rows = append(rows, accountRow{
+			Id:       stringValue(account.Id),
+			Name:     stringValue(account.Name),
+			Email:    stringValue(account.Email),
+			Status:   stringValue(account.Status),
+			Joined:   account.JoinedTimestamp,
+			JoinedBy: stringValue(account.JoinedMethod),
+			Tags:     tags[stringValue(account.Id)],
+		})
+	}
+
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print accounts: %s", err))
+		return 1
 	}
 
 	return 0
@@ -70,7 +116,7 @@ List organization accounts
 Options:
 	    -all		show all accounts regardless of state. default is to show only active accounts.
 	`
-	return strings.TrimSpace(helpText)
+	return strings.TrimSpace(helpText + orgOptionsHelp)
 }
 
 func (c *ListAccountsCommand) Synopsis() string {
@@ -81,7 +127,9 @@ func (c *ListAccountsCommand) Synopsis() string {
 type CreateAccountCommand struct {
 	AccountName  string
 	AccountEmail string
+	Tags         tagFlags
 	Ui           cli.Ui
+	orgOptions
 }
 
 func createAccountCmdFactory() (cli.Command, error) {
@@ -93,7 +141,8 @@ func createAccountCmdFactory() (cli.Command, error) {
 	}
 
 	return &CreateAccountCommand{
-		Ui: ui,
+		Tags: tagFlags{},
+		Ui:   ui,
 	}, nil
 }
 
@@ -102,6 +151,8 @@ func (c *CreateAccountCommand) Run(args []string) int {
 	cmdFlags := flag.NewFlagSet("create account", flag.ContinueOnError)
 	cmdFlags.StringVar(&c.AccountName, "name", "", "the account name to use")
 	cmdFlags.StringVar(&c.AccountEmail, "email", "", "the account email address to use")
+	cmdFlags.Var(c.Tags, "tag", "a key=value tag for the account, may be repeated")
+	c.addFlags(cmdFlags)
 	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
@@ -120,13 +171,13 @@ func (c *CreateAccountCommand) Run(args []string) int {
 
 	fmt.Printf("create account: %s, %s\n", c.AccountName, c.AccountEmail)
 
-	org, err := aws.NewOrganization()
+	org, err := c.newOrganization()
 	if err != nil {
 		fmt.Printf("error: could not initialize organization: %s\n", err)
 		return 1
 	}
 
-	status, err := org.CreateAccount(c.AccountName, c.AccountEmail)
+	status, err := org.CreateAccount(c.AccountName, c.AccountEmail, c.Tags)
 	if err != nil {
 		fmt.Printf("error: could not create account: %s\n", err)
 		return 1
@@ -146,7 +197,7 @@ func (c *CreateAccountCommand) Run(args []string) int {
 
 func (c *CreateAccountCommand) Help() string {
 	helpText := `
-usage: organizer create account --name <account alias> --email <account email address>
+usage: organizer create account --name <account alias> --email <account email address> [-tag key=value ...]
 
 create an organization aws account.
 
@@ -154,9 +205,10 @@ options:
 
 	-name=<account name>	the account name or account alias used to identify the account
 	-email=<email addr>	the account email address
+	-tag=<key=value>	tag the account, may be repeated
 
 	`
-	return strings.TrimSpace(helpText)
+	return strings.TrimSpace(helpText + orgOptionsHelp)
 }
 
 func (c *CreateAccountCommand) Synopsis() string {
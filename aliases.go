@@ -10,12 +10,18 @@ import (
 	"github.com/pr8kerl/organizer/aws"
 )
 
+type aliasRow struct {
+	AccountId string `json:"account_id" yaml:"account_id"`
+	Alias     string `json:"alias" yaml:"alias"`
+}
+
 // List Aliases
 type ListAliasesCommand struct {
 	AccountId string
 	Report    bool
 	Region    string
 	Ui        cli.Ui
+	orgOptions
 }
 
 func listAliasesCmdFactory() (cli.Command, error) {
@@ -38,32 +44,42 @@ func (c *ListAliasesCommand) Run(args []string) int {
 
 	cmdFlags := flag.NewFlagSet("list aliases", flag.ContinueOnError)
 	cmdFlags.StringVar(&c.AccountId, "accountid", "", "list account aliases for a specific account")
+	c.addFlags(cmdFlags)
 	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
 
-	org, err := aws.NewOrganization()
+	org, err := c.newOrganization()
 	if err != nil {
-		fmt.Printf("error: could not initialize organization: %s\n", err)
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
 		return 1
 	}
 
+	ctx, cancel := interruptContext()
+	defer cancel()
+
 	if len(c.AccountId) > 0 {
+		c.accounts = c.AccountId
+	}
+	accounts, err := c.selectAccounts(ctx, org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
+	}
+	aliases, errs := org.GetAliases(ctx, accounts)
+	printAccountErrors(c.Ui, "could not list aliases", errs)
 
-		err = org.PrintAliasesForAccount(c.AccountId)
-		if err != nil {
-			fmt.Printf("error: could not list aliases for account: %s\n", err)
-			return 1
+	rows := make([]aliasRow, 0, len(aliases))
+	for _, accountid := range aliases.Accounts() {
+		for _, alias := range aliases[accountid] {
+			rows = append(rows, aliasRow{AccountId: accountid, Alias: stringValue(alias)})
 		}
+	}
 
-	} else {
-
-		err = org.PrintAliases()
-		if err != nil {
-			fmt.Printf("error: could not list all account aliases: %s\n", err)
-			return 1
-		}
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print aliases: %s", err))
+		return 1
 	}
 
 	return 0
@@ -75,11 +91,144 @@ func (c *ListAliasesCommand) Help() string {
 List account aliases for accounts within an organization
 
 Options:
-	    -accountid		list all iam users for a specific account only
+	    -accountid		list account aliases for a specific account only, same as -accounts <id>
 	`
-	return strings.TrimSpace(helpText)
+	return strings.TrimSpace(helpText + orgOptionsHelp)
 }
 
 func (c *ListAliasesCommand) Synopsis() string {
 	return "list account aliases for all accounts within an organization"
 }
+
+type aliasChangeRow struct {
+	AccountId   string `json:"account_id" yaml:"account_id"`
+	AccountName string `json:"account_name" yaml:"account_name"`
+	Alias       string `json:"alias" yaml:"alias"`
+	Action      string `json:"action" yaml:"action"`
+	Reason      string `json:"reason" yaml:"reason"`
+}
+
+// Aliases
+type AliasesCommand struct {
+	AccountId   string
+	Ensure      bool
+	AutoApprove bool
+	Ui          cli.Ui
+	orgOptions
+}
+
+func aliasesCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &AliasesCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *AliasesCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("aliases", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.AccountId, "accountid", "", "work on the alias of a specific account")
+	cmdFlags.BoolVar(&c.Ensure, "ensure", false, "set an alias from the account name where none exists")
+	cmdFlags.BoolVar(&c.AutoApprove, "auto-approve", false, "set aliases without asking for confirmation")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	if len(c.AccountId) > 0 {
+		c.accounts = c.AccountId
+	}
+	accounts, err := c.selectAccounts(ctx, org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
+	}
+	aliases, errs := org.GetAliases(ctx, accounts)
+	printAccountErrors(c.Ui, "could not list aliases", errs)
+
+	changes := aws.PlanAccountAliases(accounts, aliases)
+	count := 0
+	rows := make([]aliasChangeRow, 0, len(changes))
+	for _, change := range changes {
+		row := aliasChangeRow{
+			AccountId:   *change.Account.Id,
+			AccountName: stringValue(change.Account.Name),
+			Alias:       change.Alias,
+			Action:      "create",
+			Reason:      change.Skip,
+		}
+		if len(change.Skip) > 0 {
+			row.Action = "skip"
+		} else {
+			count++
+		}
+		rows = append(rows, row)
+	}
+
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print aliases: %s", err))
+		return 1
+	}
+	if count == 0 {
+		c.Ui.Warn("no account aliases to set.")
+		return 0
+	}
+	if !c.Ensure {
+		c.Ui.Warn(fmt.Sprintf("%d accounts have no alias, run with -ensure to set them.", count))
+		return 0
+	}
+
+	if !c.AutoApprove {
+		answer, err := c.Ui.Ask(fmt.Sprintf("set the alias of %d accounts? only 'yes' will be accepted:", count))
+		if err != nil || answer != "yes" {
+			c.Ui.Error("ensure cancelled.")
+			return 1
+		}
+	}
+
+	errs = org.CreateAccountAliases(ctx, changes)
+	printAccountErrors(c.Ui, "could not set alias", errs)
+	if len(errs) > 0 {
+		return 1
+	}
+
+	c.Ui.Warn(fmt.Sprintf("set the alias of %d accounts.", count))
+
+	return 0
+}
+
+func (c *AliasesCommand) Help() string {
+	helpText := `usage: organizer aliases [<args>]
+
+Show the accounts that have no iam account alias and the alias that would be
+set for them, made from the account name in lower case with hyphens. with
+-ensure the aliases are set after confirmation. aliases are unique across aws,
+so an alias that is already taken fails for that account only.
+
+Options:
+	    -accountid		work on the alias of a specific account only, same as -accounts <id>
+	    -ensure		set an alias from the account name where none exists
+	    -auto-approve	set aliases without asking for confirmation
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *AliasesCommand) Synopsis() string {
+	return "set account aliases from account names where none exist"
+}
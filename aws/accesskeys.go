@@ -0,0 +1,223 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+// AccessKey is an iam user access key and when it was last used.
+type AccessKey struct {
+	Account         *organizations.Account
+	AccountId       string
+	UserName        string
+	AccessKeyId     string
+	Status          string
+	CreateDate      *time.Time
+	LastUsed        *time.Time
+	LastUsedService string
+	LastUsedRegion  string
+}
+
+// AccessKeyCleanupOptions sets which access keys a cleanup changes.
+type AccessKeyCleanupOptions struct {
+	DeactivateOlderThan time.Duration
+	DeleteInactive      bool
+}
+
+// AccessKeyAction is a change a cleanup makes to an access key.
+type AccessKeyAction struct {
+	Key    *AccessKey
+	Action string
+	Reason string
+}
+
+const (
+	AccessKeyDeactivate = "deactivate"
+	AccessKeyDelete     = "delete"
+)
+
+// GetAccessKeysForAccount lists the access keys of every iam user in an
+// account along with when each key was last used.
+func (o *Organization) GetAccessKeysForAccount(ctx context.Context, accountid string) ([]*AccessKey, error) {
+
+	svc, err := o.GetIamSvcForAccount(accountid)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := listUsers(ctx, svc, o.newPageLimit("users in account "+accountid))
+	if err != nil {
+		return nil, fmt.Errorf("could not list users: %s", err)
+	}
+
+	keys := make([]*AccessKey, 0, len(users))
+	for _, user := range users {
+		limit := o.newPageLimit(fmt.Sprintf("access keys of user %s in account %s", aws.StringValue(user.UserName), accountid))
+		err := svc.ListAccessKeysPagesWithContext(ctx, &iam.ListAccessKeysInput{UserName: user.UserName}, func(page *iam.ListAccessKeysOutput, last bool) bool {
+			for _, meta := range page.AccessKeyMetadata {
+				keys = append(keys, &AccessKey{
+					AccountId:   accountid,
+					UserName:    aws.StringValue(meta.UserName),
+					AccessKeyId: aws.StringValue(meta.AccessKeyId),
+					Status:      aws.StringValue(meta.Status),
+					CreateDate:  meta.CreateDate,
+				})
+			}
+			return limit.next(last)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not list access keys of user %s: %s", aws.StringValue(user.UserName), err)
+		}
+	}
+
+	for _, key := range keys {
+		resp, err := svc.GetAccessKeyLastUsedWithContext(ctx, &iam.GetAccessKeyLastUsedInput{AccessKeyId: aws.String(key.AccessKeyId)})
+		if err != nil {
+			return nil, fmt.Errorf("could not get last use of access key %s: %s", key.AccessKeyId, err)
+		}
+		if resp.AccessKeyLastUsed != nil {
+			key.LastUsed = resp.AccessKeyLastUsed.LastUsedDate
+			key.LastUsedService = aws.StringValue(resp.AccessKeyLastUsed.ServiceName)
+			key.LastUsedRegion = aws.StringValue(resp.AccessKeyLastUsed.Region)
+		}
+	}
+	return keys, nil
+
+}
+
+// GetAccessKeys returns the access keys of every account in one list,
+// ordered by account and user.
+func (o *Organization) GetAccessKeys(ctx context.Context, accounts []*organizations.Account) ([]*AccessKey, AccountErrors) {
+
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		return o.GetAccessKeysForAccount(ctx, *account.Id)
+	})
+
+	keys := make([]*AccessKey, 0, 500)
+	for _, result := range results {
+		if result.Err == nil {
+			for _, key := range result.Value.([]*AccessKey) {
+				key.Account = result.Account
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].AccountId != keys[j].AccountId {
+			return keys[i].AccountId < keys[j].AccountId
+		}
+		return keys[i].UserName < keys[j].UserName
+	})
+	return keys, Errors(results)
+
+}
+
+// PlanAccessKeyCleanup works out which access keys a cleanup would change.
+// Active keys older than the limit are deactivated, keys that are already
+// inactive are deleted. A key is never deactivated and deleted in one run.
+func PlanAccessKeyCleanup(keys []*AccessKey, opts *AccessKeyCleanupOptions, now time.Time) []*AccessKeyAction {
+
+	actions := make([]*AccessKeyAction, 0, 50)
+	for _, key := range keys {
+		switch key.Status {
+		case iam.StatusTypeActive:
+			if opts.DeactivateOlderThan > 0 && key.CreateDate != nil && now.Sub(*key.CreateDate) > opts.DeactivateOlderThan {
+				actions = append(actions, &AccessKeyAction{
+					Key:    key,
+					Action: AccessKeyDeactivate,
+					Reason: fmt.Sprintf("created %d days ago", int(now.Sub(*key.CreateDate).Hours()/24)),
+				})
+			}
+		case iam.StatusTypeInactive:
+			if opts.DeleteInactive {
+				actions = append(actions, &AccessKeyAction{Key: key, Action: AccessKeyDelete, Reason: "inactive"})
+			}
+		}
+	}
+	return actions
+
+}
+
+// accessKeyAuditRecord is one line of the access key audit log.
+type accessKeyAuditRecord struct {
+	Time        time.Time `json:"time"`
+	Action      string    `json:"action"`
+	AccountId   string    `json:"account_id"`
+	UserName    string    `json:"user_name"`
+	AccessKeyId string    `json:"access_key_id"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// ApplyAccessKeyActions deactivates or deletes access keys as planned.
+// Every attempt is written to audit as a line of json.
+func (o *Organization) ApplyAccessKeyActions(ctx context.Context, actions []*AccessKeyAction, audit io.Writer) AccountErrors {
+
+	byAccount := make(map[string][]*AccessKeyAction)
+	accounts := make([]*organizations.Account, 0, 20)
+	for _, action := range actions {
+		id := action.Key.AccountId
+		if _, ok := byAccount[id]; !ok {
+			accounts = append(accounts, accountOrId(action.Key.Account, id))
+		}
+		byAccount[id] = append(byAccount[id], action)
+	}
+
+	var mu sync.Mutex
+	logAction := func(action *AccessKeyAction, name string, err error) {
+		record := accessKeyAuditRecord{
+			Time:        time.Now().UTC(),
+			Action:      name,
+			AccountId:   action.Key.AccountId,
+			UserName:    action.Key.UserName,
+			AccessKeyId: action.Key.AccessKeyId,
+		}
+		if err != nil {
+			record.Error = err.Error()
+		}
+		line, _ := json.Marshal(record)
+		mu.Lock()
+		fmt.Fprintf(audit, "%s\n", line)
+		mu.Unlock()
+	}
+
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		svc, err := o.GetIamSvcForAccount(*account.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, action := range byAccount[*account.Id] {
+			switch action.Action {
+			case AccessKeyDeactivate:
+				_, err = svc.UpdateAccessKeyWithContext(ctx, &iam.UpdateAccessKeyInput{
+					UserName:    aws.String(action.Key.UserName),
+					AccessKeyId: aws.String(action.Key.AccessKeyId),
+					Status:      aws.String(iam.StatusTypeInactive),
+				})
+				logAction(action, "UpdateAccessKey", err)
+			case AccessKeyDelete:
+				_, err = svc.DeleteAccessKeyWithContext(ctx, &iam.DeleteAccessKeyInput{
+					UserName:    aws.String(action.Key.UserName),
+					AccessKeyId: aws.String(action.Key.AccessKeyId),
+				})
+				logAction(action, "DeleteAccessKey", err)
+			default:
+				err = fmt.Errorf("unknown action %s", action.Action)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("could not %s access key %s of user %s: %s", action.Action, action.Key.AccessKeyId, action.Key.UserName, err)
+			}
+		}
+		return nil, nil
+	})
+	return Errors(results)
+
+}
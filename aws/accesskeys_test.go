@@ -0,0 +1,70 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPlanAccessKeyCleanup(t *testing.T) {
+
+	now := time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+	created := func(days int) *time.Time {
+		t := now.Add(-time.Duration(days) * 24 * time.Hour)
+		return &t
+	}
+	keys := []*AccessKey{
+		{AccountId: "222222222222", UserName: "alice", AccessKeyId: "AKIAOLD", Status: "Active", CreateDate: created(120)},
+		{AccountId: "222222222222", UserName: "alice", AccessKeyId: "AKIANEW", Status: "Active", CreateDate: created(10)},
+		{AccountId: "222222222222", UserName: "bob", AccessKeyId: "AKIAOFF", Status: "Inactive", CreateDate: created(200)},
+	}
+
+	tests := []struct {
+		opts *AccessKeyCleanupOptions
+		want map[string]string
+	}{
+		{&AccessKeyCleanupOptions{}, map[string]string{}},
+		{&AccessKeyCleanupOptions{DeactivateOlderThan: 90 * 24 * time.Hour}, map[string]string{"AKIAOLD": AccessKeyDeactivate}},
+		{&AccessKeyCleanupOptions{DeleteInactive: true}, map[string]string{"AKIAOFF": AccessKeyDelete}},
+		{&AccessKeyCleanupOptions{DeactivateOlderThan: 5 * 24 * time.Hour, DeleteInactive: true}, map[string]string{
+			"AKIAOLD": AccessKeyDeactivate,
+			"AKIANEW": AccessKeyDeactivate,
+			"AKIAOFF": AccessKeyDelete,
+		}},
+	}
+	for i, test := range tests {
+		actions := PlanAccessKeyCleanup(keys, test.opts, now)
+		if len(actions) != len(test.want) {
+			t.Errorf("test %d: PlanAccessKeyCleanup returned %d actions, expected %d", i, len(actions), len(test.want))
+		}
+		for _, action := range actions {
+			if test.want[action.Key.AccessKeyId] != action.Action {
+				t.Errorf("test %d: key %s action is %q, expected %q", i, action.Key.AccessKeyId, action.Action, test.want[action.Key.AccessKeyId])
+			}
+		}
+	}
+
+}
+
+func TestAccessKeyAccountErrors(t *testing.T) {
+
+	org, _, err := newMockClientOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	// the master account role cannot be assumed
+	keys, errs := org.GetAccessKeys(context.Background(), mockAccounts(t, org, "111111111111", "333333333333"))
+	if len(keys) != 0 || len(errs) != 1 || errs[0].AccountName != "master" {
+		t.Fatalf("GetAccessKeys returned %d keys and errors %v, expected an error for master", len(keys), errs)
+	}
+
+	key := &AccessKey{Account: mockAccounts(t, org, "111111111111")[0], AccountId: "111111111111", UserName: "alice", AccessKeyId: "AKIAOLD"}
+	var audit bytes.Buffer
+	errs = org.ApplyAccessKeyActions(context.Background(), []*AccessKeyAction{{Key: key, Action: AccessKeyDeactivate}}, &audit)
+	if len(errs) != 1 || errs[0].AccountName != "master" {
+		t.Errorf("ApplyAccessKeyActions returned errors %v, expected an error naming the master account", errs)
+	}
+
+}
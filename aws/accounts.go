@@ -8,23 +8,21 @@ import (
 	"github.com/aws/aws-sdk-go/service/organizations"
 )
 
+const (
+	// how often a new account is checked while it is being created
+	accountStatusInterval = 10 * time.Second
+)
+
 func (o *Organization) GetAccounts() ([]*organizations.Account, error) {
 
-	params := &organizations.ListAccountsInput{}
+	limit := o.newPageLimit("accounts")
 	accounts := make([]*organizations.Account, 0, 200)
-	var nextToken *string
-
-	for {
-		resp, err := o.svc.ListAccounts(params)
-		if err != nil {
-			return nil, err
-		}
-		accounts = append(accounts, resp.Accounts...)
-		nextToken = resp.NextToken
-		if isNilOrEmpty(nextToken) {
-			break
-		}
-		params.NextToken = nextToken
+	err := o.svc.ListAccountsPages(&organizations.ListAccountsInput{}, func(page *organizations.ListAccountsOutput, last bool) bool {
+		accounts = append(accounts, page.Accounts...)
+		return limit.next(last)
+	})
+	if err != nil {
+		return nil, err
 	}
 	o.accounts = accounts
 
@@ -43,56 +41,22 @@ func (o *Organization) GetActiveAccounts() ([]*organizations.Account, error) {
 	}
 	for _, account := range accounts {
 		if *account.Status == "ACTIVE" {
-			activeAccounts = append(accounts, account)
+			activeAccounts = append(activeAccounts, account)
 		}
 	}
 	return activeAccounts, nil
 
 }
 
-func (o *Organization) PrintAccounts() error {
-
-	accounts, err := o.GetAccounts()
-	if err != nil {
-		return err
-	}
-
-	for _, account := range accounts {
-		fmt.Printf("%s,%s,%s\n", *account.Id, *account.Name, *account.Status)
-	}
-
-	return nil
-}
-
-func (o *Organization) PrintActiveAccounts() error {
-
-	accounts, err := o.GetActiveAccounts()
-	if err != nil {
-		return err
-	}
-
-	for _, account := range accounts {
-		// for a newly created account, it can take a while for all the account fields
-		// to be populated. So to avoid a panic...
-		var name string = "unknown"
-		var id string = "unknown"
-		if account.Id != nil {
-			id = *account.Id
-		}
-		if account.Name != nil {
-			name = *account.Name
-		}
-		fmt.Printf("%s,%s\n", id, name)
-	}
-	return nil
-}
-
-func (o *Organization) CreateAccount(name string, email string) (*organizations.CreateAccountStatus, error) {
+func (o *Organization) CreateAccount(name string, email string, tags map[string]string) (*organizations.CreateAccountStatus, error) {
 	input := &organizations.CreateAccountInput{
-		AccountName: aws.String(name),
-		Email:       aws.String(email),
+		AccountName:            aws.String(name),
+		Email:                  aws.String(email),
 		IamUserAccessToBilling: aws.String("ALLOW"),
 	}
+	if len(tags) > 0 {
+		input.Tags = organizationsTags(tags)
+	}
 
 	result, err := o.svc.CreateAccount(input)
 	if err != nil {
@@ -124,16 +88,16 @@ func (o *Organization) WaitForAccountStatus(accountStatus *organizations.CreateA
 	// wait until the request has completed
 	for *statusOutput.CreateAccountStatus.State == "IN_PROGRESS" {
 
-		time.Sleep(time.Second * 10)
+		time.Sleep(o.config.AccountStatusInterval)
 		statusOutput, err = o.svc.DescribeCreateAccountStatus(statusInput)
 		if err != nil {
 			err := fmt.Errorf("error: could not check account status: %s", err.Error())
 			return nil, err
 		}
-		fmt.Printf("account status: %s\n", *statusOutput.CreateAccountStatus.State)
+		o.logf("account status: %s\n", *statusOutput.CreateAccountStatus.State)
 	}
 
-	fmt.Printf("account status: %s\n", *statusOutput.CreateAccountStatus.State)
+	o.logf("account status: %s\n", *statusOutput.CreateAccountStatus.State)
 
 	if *statusOutput.CreateAccountStatus.State == "FAILED" {
 		err := fmt.Errorf("error: failed to create account: %s", *statusOutput.CreateAccountStatus.FailureReason)
@@ -0,0 +1,210 @@
+package aws
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+// marks the aws cli config profiles written by organizer, so a merge
+// knows which profiles it may replace
+const profileMarker = "# managed by organizer"
+
+var profileNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// ProfileOptions controls how account profiles are written. If SSOStartURL
+// is set the profiles use aws sso, otherwise they assume the member account
+// role from SourceProfile.
+type ProfileOptions struct {
+	SourceProfile string
+	SSOStartURL   string
+	SSORegion     string
+	SSORoleName   string
+	Region        string
+}
+
+// ProfileSetting is a single key = value line of a profile.
+type ProfileSetting struct {
+	Key   string
+	Value string
+}
+
+// Profile is an aws cli config profile for one account.
+type Profile struct {
+	Name      string
+	AccountId string
+	Settings  []ProfileSetting
+}
+
+// AccountProfiles builds a profile for every account, named after the
+// first account alias or else the account name.
+func (o *Organization) AccountProfiles(accounts []*organizations.Account, aliases AliasesPerAccount, opts *ProfileOptions) []*Profile {
+
+	profiles := make([]*Profile, 0, len(accounts))
+	used := make(map[string]bool)
+	for _, account := range accounts {
+		name := stringValue(account.Name)
+		if alii := aliases[*account.Id]; len(alii) > 0 && alii[0] != nil {
+			name = *alii[0]
+		}
+		name = strings.Trim(profileNameUnsafe.ReplaceAllString(name, "-"), "-")
+		if len(name) == 0 || used[name] {
+			name = *account.Id
+		}
+		used[name] = true
+
+		profile := &Profile{Name: name, AccountId: *account.Id}
+		if len(opts.SSOStartURL) > 0 {
+			profile.Settings = []ProfileSetting{
+				{"sso_start_url", opts.SSOStartURL},
+				{"sso_region", opts.SSORegion},
+				{"sso_account_id", *account.Id},
+				{"sso_role_name", opts.SSORoleName},
+			}
+		} else {
+			profile.Settings = []ProfileSetting{
+				{"role_arn", o.config.RoleArn(*account.Id)},
+				{"source_profile", opts.SourceProfile},
+			}
+			if len(o.config.ExternalId) > 0 {
+				profile.Settings = append(profile.Settings, ProfileSetting{"external_id", o.config.ExternalId})
+			}
+		}
+		if len(opts.Region) > 0 {
+			profile.Settings = append(profile.Settings, ProfileSetting{"region", opts.Region})
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles
+
+}
+
+func (p *Profile) String() string {
+	lines := []string{fmt.Sprintf("[profile %s]", p.Name), fmt.Sprintf("%s %s", profileMarker, p.AccountId)}
+	for _, setting := range p.Settings {
+		lines = append(lines, fmt.Sprintf("%s = %s", setting.Key, setting.Value))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// configSection is a section of an aws cli config file, kept as the
+// original lines so hand written sections are written back untouched.
+type configSection struct {
+	name  string
+	lines []string
+}
+
+// account returns the account a profile written by organizer is for.
+func (s *configSection) account() (string, bool) {
+	for _, line := range s.lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, profileMarker) {
+			return strings.TrimSpace(strings.TrimPrefix(trimmed, profileMarker)), true
+		}
+	}
+	return "", false
+}
+
+func parseConfigSections(config string) []*configSection {
+
+	// the first section holds anything before the first header
+	sections := []*configSection{{}}
+	scanner := bufio.NewScanner(strings.NewReader(config))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			name := strings.TrimSpace(strings.TrimPrefix(strings.Trim(trimmed, "[]"), "profile "))
+			sections = append(sections, &configSection{name: name})
+		}
+		current := sections[len(sections)-1]
+		current.lines = append(current.lines, line)
+	}
+	return sections
+
+}
+
+// MergeOptions controls which profiles written by organizer before a
+// merge may change.
+type MergeOptions struct {
+	// Keep lists accounts whose profiles are left as they are, eg. because
+	// their alias could not be read
+	Keep map[string]bool
+	// Prune removes the profiles of accounts without a generated profile,
+	// only safe when every account of the organization was selected
+	Prune bool
+}
+
+// MergeAwsConfig merges profiles into the text of an existing aws cli
+// config file. Profiles previously written by organizer for the account of
+// a generated profile are replaced, whatever they were named. Those of
+// other accounts are kept unless opts.Prune is set, hand written profiles
+// are always kept. The names of generated profiles skipped because a kept
+// profile has the same name are returned.
+func MergeAwsConfig(config string, profiles []*Profile, opts *MergeOptions) (string, []string) {
+
+	generated := make(map[string]*Profile, len(profiles))
+	for _, profile := range profiles {
+		generated[profile.AccountId] = profile
+	}
+
+	// decide which sections stay as they are
+	sections := parseConfigSections(config)
+	keep := make([]bool, len(sections))
+	taken := make(map[string]bool)
+	written := make(map[string]bool)
+	for i, section := range sections {
+		accountid, managed := section.account()
+		_, replaced := generated[accountid]
+		switch {
+		case i == 0 || !managed:
+			keep[i] = true
+		case opts.Keep[accountid]:
+			keep[i] = true
+			written[accountid] = true
+		case !replaced:
+			keep[i] = !opts.Prune
+		}
+		if i > 0 && keep[i] {
+			taken[section.name] = true
+		}
+	}
+
+	skipped := make([]string, 0)
+	for _, profile := range profiles {
+		if !written[profile.AccountId] && taken[profile.Name] {
+			skipped = append(skipped, profile.Name)
+			written[profile.AccountId] = true
+		}
+	}
+
+	blocks := make([]string, 0, len(profiles)+10)
+	for i, section := range sections {
+		if !keep[i] {
+			// the first profile of a generated account is replaced in place
+			accountid, _ := section.account()
+			if profile, ok := generated[accountid]; ok && !written[accountid] {
+				blocks = append(blocks, profile.String())
+				written[accountid] = true
+			}
+			continue
+		}
+		if len(section.lines) > 0 {
+			text := strings.TrimRight(strings.Join(section.lines, "\n"), "\n ") + "\n"
+			if len(strings.TrimSpace(text)) > 0 {
+				blocks = append(blocks, text)
+			}
+		}
+	}
+
+	for _, profile := range profiles {
+		if !written[profile.AccountId] {
+			blocks = append(blocks, profile.String())
+		}
+	}
+	return strings.Join(blocks, "\n"), skipped
+
+}
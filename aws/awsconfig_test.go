@@ -0,0 +1,148 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestMergeAwsConfig(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+	accounts, err := org.GetActiveAccounts()
+	if err != nil {
+		t.Fatalf("organization GetActiveAccounts error: %s", err)
+	}
+
+	aliases := AliasesPerAccount{"222222222222": []*string{aws.String("payments-prod")}}
+	profiles := org.AccountProfiles(accounts, aliases, &ProfileOptions{SourceProfile: "master", Region: "ap-southeast-2"})
+
+	names := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		names = append(names, profile.Name)
+	}
+	if got := strings.Join(names, ","); got != "master,payments-prod,dev-app,audit" {
+		t.Fatalf("AccountProfiles names are %s", got)
+	}
+
+	existing := `[default]
+region = ap-southeast-2
+
+[profile master]
+# hand written
+region = us-east-1
+
+[profile dev-app]
+# managed by organizer 333333333333
+role_arn = arn:aws:iam::333333333333:role/OldRole
+source_profile = master
+
+[profile closed]
+# managed by organizer 999999999999
+role_arn = arn:aws:iam::999999999999:role/OrganizationAccountAccessRole
+`
+	merged, skipped := MergeAwsConfig(existing, profiles, &MergeOptions{Prune: true})
+
+	if len(skipped) != 1 || skipped[0] != "master" {
+		t.Errorf("MergeAwsConfig skipped %v, expected the hand written master profile", skipped)
+	}
+	for _, want := range []string{
+		"[default]\nregion = ap-southeast-2\n",
+		"[profile master]\n# hand written\nregion = us-east-1\n",
+		"[profile dev-app]\n# managed by organizer 333333333333\nrole_arn = arn:aws:iam::333333333333:role/OrganizationAccountAccessRole\n",
+		"[profile payments-prod]\n",
+		"[profile audit]\n",
+	} {
+		if !strings.Contains(merged, want) {
+			t.Errorf("MergeAwsConfig result is missing %q:\n%s", want, merged)
+		}
+	}
+	for _, unwanted := range []string{"OldRole", "[profile closed]"} {
+		if strings.Contains(merged, unwanted) {
+			t.Errorf("MergeAwsConfig result should not contain %q:\n%s", unwanted, merged)
+		}
+	}
+
+	again, _ := MergeAwsConfig(merged, profiles, &MergeOptions{Prune: true})
+	if again != merged {
+		t.Errorf("MergeAwsConfig is not stable when run twice:\n%s\n----\n%s", merged, again)
+	}
+
+}
+
+func TestMergeAwsConfigSelection(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+	selector, err := ParseSelector("222222222222,333333333333")
+	if err != nil {
+		t.Fatalf("ParseSelector error: %s", err)
+	}
+	accounts, err := org.SelectAccounts(context.Background(), selector)
+	if err != nil {
+		t.Fatalf("organization SelectAccounts error: %s", err)
+	}
+
+	existing := `[profile payments-prod]
+# managed by organizer 222222222222
+role_arn = arn:aws:iam::222222222222:role/OrganizationAccountAccessRole
+
+[profile dev]
+# managed by organizer 333333333333
+role_arn = arn:aws:iam::333333333333:role/OldRole
+
+[profile audit]
+# managed by organizer 444444444444
+role_arn = arn:aws:iam::444444444444:role/OrganizationAccountAccessRole
+`
+
+	tests := []struct {
+		name     string
+		aliases  AliasesPerAccount
+		opts     *MergeOptions
+		want     []string
+		unwanted []string
+	}{
+		// profiles of accounts that are not selected are kept, those of
+		// selected accounts are replaced even when renamed
+		{"partial selection", AliasesPerAccount{"222222222222": []*string{aws.String("payments-prod")}}, &MergeOptions{},
+			[]string{"[profile payments-prod]\n", "[profile dev-app]\n# managed by organizer 333333333333\n", "[profile audit]\n"},
+			[]string{"[profile dev]", "OldRole", "[profile prod-app]"}},
+		{"pruned selection", AliasesPerAccount{"222222222222": []*string{aws.String("payments-prod")}}, &MergeOptions{Prune: true},
+			[]string{"[profile payments-prod]\n", "[profile dev-app]\n"},
+			[]string{"[profile audit]", "[profile dev]"}},
+		// an account whose alias could not be read keeps its profile
+		{"alias failure", AliasesPerAccount{}, &MergeOptions{Keep: map[string]bool{"222222222222": true}},
+			[]string{"[profile payments-prod]\n# managed by organizer 222222222222\n", "[profile dev-app]\n", "[profile audit]\n"},
+			[]string{"[profile prod-app]"}},
+	}
+
+	for _, test := range tests {
+		profiles := org.AccountProfiles(accounts, test.aliases, &ProfileOptions{SourceProfile: "master"})
+		merged, skipped := MergeAwsConfig(existing, profiles, test.opts)
+		if len(skipped) > 0 {
+			t.Errorf("%s: MergeAwsConfig skipped %v", test.name, skipped)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(merged, want) {
+				t.Errorf("%s: MergeAwsConfig result is missing %q:\n%s", test.name, want, merged)
+			}
+		}
+		for _, unwanted := range test.unwanted {
+			if strings.Contains(merged, unwanted) {
+				t.Errorf("%s: MergeAwsConfig result should not contain %q:\n%s", test.name, unwanted, merged)
+			}
+		}
+		if n := strings.Count(merged, "# managed by organizer 222222222222"); n != 1 {
+			t.Errorf("%s: MergeAwsConfig wrote %d profiles for 222222222222, expected 1:\n%s", test.name, n, merged)
+		}
+	}
+
+}
@@ -1,13 +1,11 @@
 package aws
 
 import (
-	"fmt"
+	"context"
+	"sort"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/organizations"
 	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/aws/aws-sdk-go/service/sts"
 )
 
 type BucketsPerAccount map[string][]string
@@ -32,47 +30,29 @@ func (s BucketsPerAccount) Set(key string, value []string) {
 	s[key] = value
 }
 
-func (o *Organization) GetBucketsForAccount(accountid string) ([]string, error) {
-
-	role := fmt.Sprintf("arn:aws:iam::%v:role/OrganizationAccountAccessRole", accountid)
+func (s BucketsPerAccount) Accounts() []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
 
-	sess := session.Must(session.NewSession())
-	stssvc := sts.New(sess)
+func (o *Organization) GetBucketsForAccount(ctx context.Context, accountid string) ([]string, error) {
 
-	params := &sts.AssumeRoleInput{
-		RoleArn:         aws.String(role),                // Required
-		RoleSessionName: aws.String("organizer-buckets"), // Required
-		DurationSeconds: aws.Int64(900),
-	}
-	resp, err := stssvc.AssumeRole(params)
+	svc, err := o.clients.S3(accountid, o.region)
 	if err != nil {
 		return nil, err
 	}
 
-	config := aws.NewConfig().WithCredentials(
-		credentials.NewStaticCredentials(
-			*resp.Credentials.AccessKeyId,
-			*resp.Credentials.SecretAccessKey,
-			*resp.Credentials.SessionToken,
-		),
-	).WithRegion(o.region)
-
-	sess = session.New(config)
-	svc := s3.New(sess)
-
 	input := &s3.ListBucketsInput{}
-	bresp, err := svc.ListBuckets(input)
+	bresp, err := svc.ListBucketsWithContext(ctx, input)
 
 	if err != nil {
-		// Print the error, cast err to awserr.Error to get the Code and
-		// Message from an error.
-		fmt.Println(err.Error())
 		return nil, err
 	}
 
-	// Pretty-print the response data.
-	// fmt.Println(resp)
-
 	buckets := make([]string, 0, 200)
 
 	for _, bucket := range bresp.Buckets {
@@ -83,57 +63,18 @@ func (o *Organization) GetBucketsForAccount(accountid string) ([]string, error)
 
 }
 
-func (o *Organization) GetBuckets() (map[string][]string, error) {
+func (o *Organization) GetBuckets(ctx context.Context, accounts []*organizations.Account) (BucketsPerAccount, AccountErrors) {
 
-	accounts, err := o.GetActiveAccounts()
-	if err != nil {
-		return nil, err
-	}
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		return o.GetBucketsForAccount(ctx, *account.Id)
+	})
 
 	buckets := make(BucketsPerAccount)
-
-	for _, account := range accounts {
-		bucks, err := o.GetBucketsForAccount(*account.Id)
-		if err != nil {
-			fmt.Printf("warning: could not list buckets for account %s\n\twarning: %s\n", *account.Name, err)
-			continue
-		}
-		buckets.Set(*account.Id, bucks)
-	}
-	return buckets, nil
-
-}
-
-func (o *Organization) PrintBucketsForAccount(accountid string) error {
-	buckets, err := o.GetBucketsForAccount(accountid)
-	if err != nil {
-		return err
-	}
-
-	if len(buckets) == 0 {
-		fmt.Printf("warning: no buckets found in account %s\n", accountid)
-		return nil
-	}
-
-	for _, bucket := range buckets {
-		fmt.Printf("%s,%s\n", accountid, bucket)
-	}
-
-	return nil
-
-}
-
-func (o *Organization) PrintBuckets() error {
-	buckets, err := o.GetBuckets()
-	if err != nil {
-		return err
-	}
-
-	for accountid, accountbuckets := range buckets {
-		for _, bucket := range accountbuckets {
-			fmt.Printf("%s,%s\n", accountid, bucket)
+	for _, result := range results {
+		if result.Err == nil {
+			buckets.Set(*result.Account.Id, result.Value.([]string))
 		}
 	}
-	return nil
+	return buckets, Errors(results)
 
 }
@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/cloudtrail/cloudtrailiface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+// ClientFactory builds the service clients used to work in a member
+// account. Global services such as iam and cloudfront are still asked for
+// with a region, the one their calls are signed for.
+type ClientFactory interface {
+	S3(accountid string, region string) (s3iface.S3API, error)
+	IAM(accountid string, region string) (iamiface.IAMAPI, error)
+	CloudFront(accountid string, region string) (cloudfrontiface.CloudFrontAPI, error)
+	CloudTrail(accountid string, region string) (cloudtrailiface.CloudTrailAPI, error)
+	STS(accountid string, region string) (stsiface.STSAPI, error)
+}
+
+// sessionClients builds clients from the assumed role session of each
+// account, the default ClientFactory.
+type sessionClients struct {
+	o *Organization
+}
+
+func (c *sessionClients) S3(accountid string, region string) (s3iface.S3API, error) {
+	sess, err := c.o.GetSessionForAccountInRegion(accountid, region)
+	if err != nil {
+		return nil, err
+	}
+	return s3.New(sess), nil
+}
+
+func (c *sessionClients) IAM(accountid string, region string) (iamiface.IAMAPI, error) {
+	sess, err := c.o.GetSessionForAccountInRegion(accountid, region)
+	if err != nil {
+		return nil, err
+	}
+	return iam.New(sess), nil
+}
+
+func (c *sessionClients) CloudFront(accountid string, region string) (cloudfrontiface.CloudFrontAPI, error) {
+	sess, err := c.o.GetSessionForAccountInRegion(accountid, region)
+	if err != nil {
+		return nil, err
+	}
+	return cloudfront.New(sess), nil
+}
+
+func (c *sessionClients) CloudTrail(accountid string, region string) (cloudtrailiface.CloudTrailAPI, error) {
+	sess, err := c.o.GetSessionForAccountInRegion(accountid, region)
+	if err != nil {
+		return nil, err
+	}
+	return cloudtrail.New(sess), nil
+}
+
+func (c *sessionClients) STS(accountid string, region string) (stsiface.STSAPI, error) {
+	sess, err := c.o.GetSessionForAccountInRegion(accountid, region)
+	if err != nil {
+		return nil, err
+	}
+	return sts.New(sess), nil
+}
+
+// SetClients replaces how member account service clients are built, eg.
+// with fakes in tests.
+func (o *Organization) SetClients(clients ClientFactory) {
+	o.clients = clients
+}
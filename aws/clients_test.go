@@ -0,0 +1,451 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/cloudtrail/cloudtrailiface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+// mockClients hands out in memory service clients per account. Accounts
+// without an entry behave as if the member account role could not be
+// assumed.
+type mockClients struct {
+	s3         map[string]*mockS3Svc
+	iam        map[string]*mockIamSvc
+	cloudfront map[string]*mockCloudFrontSvc
+	cloudtrail map[string]*mockTrails
+	sts        map[string]*mockStsSvc
+}
+
+func newMockClients() *mockClients {
+	return &mockClients{
+		s3:         make(map[string]*mockS3Svc),
+		iam:        make(map[string]*mockIamSvc),
+		cloudfront: make(map[string]*mockCloudFrontSvc),
+		cloudtrail: make(map[string]*mockTrails),
+		sts:        make(map[string]*mockStsSvc),
+	}
+}
+
+func noRole(accountid string) error {
+	return fmt.Errorf("AccessDenied: could not assume role in %s", accountid)
+}
+
+func (m *mockClients) S3(accountid string, region string) (s3iface.S3API, error) {
+	if svc, ok := m.s3[accountid]; ok {
+		return svc, nil
+	}
+	return nil, noRole(accountid)
+}
+
+func (m *mockClients) IAM(accountid string, region string) (iamiface.IAMAPI, error) {
+	if svc, ok := m.iam[accountid]; ok {
+		return svc, nil
+	}
+	return nil, noRole(accountid)
+}
+
+func (m *mockClients) CloudFront(accountid string, region string) (cloudfrontiface.CloudFrontAPI, error) {
+	if svc, ok := m.cloudfront[accountid]; ok {
+		return svc, nil
+	}
+	return nil, noRole(accountid)
+}
+
+func (m *mockClients) CloudTrail(accountid string, region string) (cloudtrailiface.CloudTrailAPI, error) {
+	if trails, ok := m.cloudtrail[accountid]; ok {
+		return &mockCloudTrailSvc{trails: trails, accountid: accountid, region: region}, nil
+	}
+	return nil, noRole(accountid)
+}
+
+func (m *mockClients) STS(accountid string, region string) (stsiface.STSAPI, error) {
+	if svc, ok := m.sts[accountid]; ok {
+		return svc, nil
+	}
+	return nil, noRole(accountid)
+}
+
+type mockS3Svc struct {
+	s3iface.S3API
+	buckets []string
+}
+
+func (m *mockS3Svc) ListBucketsWithContext(ctx aws.Context, input *s3.ListBucketsInput, opts ...request.Option) (*s3.ListBucketsOutput, error) {
+	resp := &s3.ListBucketsOutput{}
+	for _, name := range m.buckets {
+		resp.Buckets = append(resp.Buckets, &s3.Bucket{Name: aws.String(name)})
+	}
+	return resp, nil
+}
+
+// the iam credential report is always complete straight away
+func (m *mockIamSvc) GenerateCredentialReportWithContext(ctx aws.Context, input *iam.GenerateCredentialReportInput, opts ...request.Option) (*iam.GenerateCredentialReportOutput, error) {
+	return &iam.GenerateCredentialReportOutput{State: aws.String(iam.ReportStateTypeComplete)}, nil
+}
+
+func (m *mockIamSvc) GetCredentialReportWithContext(ctx aws.Context, input *iam.GetCredentialReportInput, opts ...request.Option) (*iam.GetCredentialReportOutput, error) {
+	if len(m.report) == 0 {
+		return nil, fmt.Errorf("ReportNotPresent: no credential report")
+	}
+	return &iam.GetCredentialReportOutput{Content: []byte(m.report), ReportFormat: aws.String("text/csv")}, nil
+}
+
+// mockTrails are the trails of one account and the arns deleted, created
+// and updated in it. Every trail is logging.
+type mockTrails struct {
+	trails  []*cloudtrail.Trail
+	deleted []string
+	created []string
+	updated []string
+}
+
+type mockCloudTrailSvc struct {
+	cloudtrailiface.CloudTrailAPI
+	trails    *mockTrails
+	accountid string
+	region    string
+}
+
+// DescribeTrailsWithContext returns the trails of the region and the multi
+// region trails of every other region, like cloudtrail with shadow trails.
+func (m *mockCloudTrailSvc) DescribeTrailsWithContext(ctx aws.Context, input *cloudtrail.DescribeTrailsInput, opts ...request.Option) (*cloudtrail.DescribeTrailsOutput, error) {
+	resp := &cloudtrail.DescribeTrailsOutput{}
+	for _, trail := range m.trails.trails {
+		if *trail.HomeRegion == m.region || aws.BoolValue(trail.IsMultiRegionTrail) {
+			resp.TrailList = append(resp.TrailList, trail)
+		}
+	}
+	return resp, nil
+}
+
+func (m *mockCloudTrailSvc) DeleteTrailWithContext(ctx aws.Context, input *cloudtrail.DeleteTrailInput, opts ...request.Option) (*cloudtrail.DeleteTrailOutput, error) {
+	for i, trail := range m.trails.trails {
+		if *trail.TrailARN == *input.Name {
+			if *trail.HomeRegion != m.region {
+				return nil, fmt.Errorf("InvalidHomeRegionException: %s", *input.Name)
+			}
+			m.trails.trails = append(m.trails.trails[:i], m.trails.trails[i+1:]...)
+			m.trails.deleted = append(m.trails.deleted, *input.Name)
+			return &cloudtrail.DeleteTrailOutput{}, nil
+		}
+	}
+	return nil, fmt.Errorf("TrailNotFoundException: %s", *input.Name)
+}
+
+func (m *mockCloudTrailSvc) GetTrailStatusWithContext(ctx aws.Context, input *cloudtrail.GetTrailStatusInput, opts ...request.Option) (*cloudtrail.GetTrailStatusOutput, error) {
+	return &cloudtrail.GetTrailStatusOutput{IsLogging: aws.Bool(true)}, nil
+}
+
+func (m *mockCloudTrailSvc) CreateTrailWithContext(ctx aws.Context, input *cloudtrail.CreateTrailInput, opts ...request.Option) (*cloudtrail.CreateTrailOutput, error) {
+	trail := newMockTrail(m.accountid, *input.Name, m.region, aws.BoolValue(input.IsMultiRegionTrail), false)
+	trail.S3BucketName = input.S3BucketName
+	trail.LogFileValidationEnabled = input.EnableLogFileValidation
+	m.trails.trails = append(m.trails.trails, trail)
+	m.trails.created = append(m.trails.created, *trail.TrailARN)
+	return &cloudtrail.CreateTrailOutput{TrailARN: trail.TrailARN}, nil
+}
+
+func (m *mockCloudTrailSvc) UpdateTrailWithContext(ctx aws.Context, input *cloudtrail.UpdateTrailInput, opts ...request.Option) (*cloudtrail.UpdateTrailOutput, error) {
+	for _, trail := range m.trails.trails {
+		if *trail.TrailARN == *input.Name {
+			if *trail.HomeRegion != m.region {
+				return nil, fmt.Errorf("InvalidHomeRegionException: %s", *input.Name)
+			}
+			trail.S3BucketName = input.S3BucketName
+			trail.LogFileValidationEnabled = input.EnableLogFileValidation
+			trail.IsMultiRegionTrail = input.IsMultiRegionTrail
+			m.trails.updated = append(m.trails.updated, *input.Name)
+			return &cloudtrail.UpdateTrailOutput{TrailARN: trail.TrailARN}, nil
+		}
+	}
+	return nil, fmt.Errorf("TrailNotFoundException: %s", *input.Name)
+}
+
+func (m *mockCloudTrailSvc) StartLoggingWithContext(ctx aws.Context, input *cloudtrail.StartLoggingInput, opts ...request.Option) (*cloudtrail.StartLoggingOutput, error) {
+	return &cloudtrail.StartLoggingOutput{}, nil
+}
+
+// mockStsSvc answers as the member account role of one account.
+type mockStsSvc struct {
+	stsiface.STSAPI
+	accountid string
+}
+
+func (m *mockStsSvc) GetCallerIdentityWithContext(ctx aws.Context, input *sts.GetCallerIdentityInput, opts ...request.Option) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{
+		Account: aws.String(m.accountid),
+		Arn:     aws.String(fmt.Sprintf("arn:aws:sts::%s:assumed-role/OrganizationAccountAccessRole/organizer-%s", m.accountid, m.accountid)),
+	}, nil
+}
+
+func newMockTrail(accountid string, name string, region string, multi bool, org bool) *cloudtrail.Trail {
+	return &cloudtrail.Trail{
+		Name:                aws.String(name),
+		TrailARN:            aws.String(fmt.Sprintf("arn:aws:cloudtrail:%s:%s:trail/%s", region, accountid, name)),
+		HomeRegion:          aws.String(region),
+		IsMultiRegionTrail:  aws.Bool(multi),
+		IsOrganizationTrail: aws.Bool(org),
+	}
+}
+
+// newMockClientOrganization returns the mock organization with member
+// account clients for prod-app, dev-app and audit. The role cannot be
+// assumed in master.
+func newMockClientOrganization() (*Organization, *mockClients, error) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		return nil, nil, err
+	}
+	clients := newMockClients()
+	clients.s3["222222222222"] = &mockS3Svc{buckets: []string{"prod-logs", "prod-data"}}
+	clients.s3["333333333333"] = &mockS3Svc{}
+	clients.s3["444444444444"] = &mockS3Svc{buckets: []string{"audit-logs"}}
+	clients.iam["222222222222"] = newMockIamSvc(5, 1)
+	clients.iam["333333333333"] = newMockIamSvc(0, 0)
+	clients.iam["444444444444"] = newMockIamSvc(1, 1)
+	clients.iam["222222222222"].report = testCredentialReport
+	clients.cloudfront["222222222222"] = &mockCloudFrontSvc{distributions: []*cloudfront.DistributionSummary{
+		{Id: aws.String("E1")}, {Id: aws.String("E2")}, {Id: aws.String("E3")},
+	}}
+	clients.cloudfront["333333333333"] = &mockCloudFrontSvc{}
+	clients.cloudtrail["222222222222"] = &mockTrails{trails: []*cloudtrail.Trail{
+		newMockTrail("222222222222", "legacy-audit", "us-east-1", true, false),
+		newMockTrail("222222222222", "legacy-eu", "eu-west-1", false, false),
+		newMockTrail("111111111111", "org-trail", "us-east-1", true, true),
+	}}
+	clients.cloudtrail["333333333333"] = &mockTrails{}
+	for _, accountid := range []string{"222222222222", "333333333333", "444444444444"} {
+		clients.sts[accountid] = &mockStsSvc{accountid: accountid}
+	}
+	org.SetClients(clients)
+	return org, clients, nil
+
+}
+
+// mockAccounts returns the accounts of the mock organization with the ids.
+func mockAccounts(t *testing.T, org *Organization, ids ...string) []*organizations.Account {
+	svc := org.svc.(*mockOrganizationsSvc)
+	accounts := make([]*organizations.Account, 0, len(ids))
+	for _, id := range ids {
+		account := svc.account(id)
+		if account == nil {
+			t.Fatalf("no mock account %s", id)
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts
+}
+
+// failedAccounts returns the sorted ids of the accounts in errs.
+func failedAccounts(errs AccountErrors) string {
+	ids := make([]string, 0, len(errs))
+	for _, e := range errs {
+		ids = append(ids, e.AccountId)
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+func TestGetBuckets(t *testing.T) {
+
+	org, _, err := newMockClientOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	tests := []struct {
+		accounts []string
+		buckets  map[string]int
+		failed   string
+	}{
+		{[]string{"222222222222"}, map[string]int{"222222222222": 2}, ""},
+		{[]string{"222222222222", "333333333333", "444444444444"}, map[string]int{"222222222222": 2, "333333333333": 0, "444444444444": 1}, ""},
+		{[]string{"111111111111", "444444444444"}, map[string]int{"444444444444": 1}, "111111111111"},
+		{[]string{}, map[string]int{}, ""},
+	}
+
+	for _, test := range tests {
+		buckets, errs := org.GetBuckets(context.Background(), mockAccounts(t, org, test.accounts...))
+		if failedAccounts(errs) != test.failed {
+			t.Errorf("GetBuckets(%v) failed for %q, expected %q", test.accounts, failedAccounts(errs), test.failed)
+		}
+		if len(buckets) != len(test.buckets) {
+			t.Errorf("GetBuckets(%v) returned %d accounts, expected %d", test.accounts, len(buckets), len(test.buckets))
+		}
+		for id, n := range test.buckets {
+			if len(buckets[id]) != n {
+				t.Errorf("GetBuckets(%v) returned %d buckets for %s, expected %d", test.accounts, len(buckets[id]), id, n)
+			}
+		}
+	}
+
+}
+
+func TestGetUsers(t *testing.T) {
+
+	org, _, err := newMockClientOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	tests := []struct {
+		accounts []string
+		users    map[string]int
+		failed   string
+	}{
+		{[]string{"222222222222"}, map[string]int{"222222222222": 5}, ""},
+		{[]string{"222222222222", "333333333333", "444444444444"}, map[string]int{"222222222222": 5, "333333333333": 0, "444444444444": 1}, ""},
+		{[]string{"111111111111", "555555555555"}, map[string]int{}, "111111111111,555555555555"},
+	}
+
+	for _, test := range tests {
+		users, errs := org.GetUsers(context.Background(), mockAccounts(t, org, test.accounts...))
+		if failedAccounts(errs) != test.failed {
+			t.Errorf("GetUsers(%v) failed for %q, expected %q", test.accounts, failedAccounts(errs), test.failed)
+		}
+		if len(users) != len(test.users) {
+			t.Errorf("GetUsers(%v) returned %d accounts, expected %d", test.accounts, len(users), len(test.users))
+		}
+		for id, n := range test.users {
+			if len(users[id]) != n {
+				t.Errorf("GetUsers(%v) returned %d users for %s, expected %d", test.accounts, len(users[id]), id, n)
+			}
+		}
+	}
+
+}
+
+func TestGetCloudfronts(t *testing.T) {
+
+	org, _, err := newMockClientOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	tests := []struct {
+		accounts []string
+		distros  map[string]int
+		failed   string
+	}{
+		{[]string{"222222222222"}, map[string]int{"222222222222": 3}, ""},
+		{[]string{"222222222222", "333333333333"}, map[string]int{"222222222222": 3, "333333333333": 0}, ""},
+		{[]string{"333333333333", "444444444444"}, map[string]int{"333333333333": 0}, "444444444444"},
+	}
+
+	for _, test := range tests {
+		distros, errs := org.GetCloudfronts(context.Background(), mockAccounts(t, org, test.accounts...))
+		if failedAccounts(errs) != test.failed {
+			t.Errorf("GetCloudfronts(%v) failed for %q, expected %q", test.accounts, failedAccounts(errs), test.failed)
+		}
+		if len(distros) != len(test.distros) {
+			t.Errorf("GetCloudfronts(%v) returned %d accounts, expected %d", test.accounts, len(distros), len(test.distros))
+		}
+		for id, n := range test.distros {
+			if len(distros[id]) != n {
+				t.Errorf("GetCloudfronts(%v) returned %d distributions for %s, expected %d", test.accounts, len(distros[id]), id, n)
+			}
+		}
+	}
+
+}
+
+func TestTrailPurge(t *testing.T) {
+
+	tests := []struct {
+		opts    *TrailPurgeOptions
+		deleted []string
+	}{
+		{&TrailPurgeOptions{}, []string{"legacy-audit", "legacy-eu"}},
+		{&TrailPurgeOptions{Names: []string{"legacy-e*"}}, []string{"legacy-eu"}},
+		{&TrailPurgeOptions{Exclude: []string{"legacy-*"}}, []string{}},
+	}
+
+	for _, test := range tests {
+		org, clients, err := newMockClientOrganization()
+		if err != nil {
+			t.Fatalf("could not create mock organization: %s", err)
+		}
+		accounts := mockAccounts(t, org, "222222222222", "333333333333")
+
+		deletions, errs := org.PlanTrailPurge(context.Background(), accounts, test.opts)
+		if len(errs) > 0 {
+			t.Fatalf("PlanTrailPurge error: %s", errs[0])
+		}
+		// the organization trail is seen but never deleted
+		if len(deletions) != 3 {
+			t.Errorf("PlanTrailPurge(%+v) planned %d trails, expected 3", test.opts, len(deletions))
+		}
+
+		var audit bytes.Buffer
+		errs = org.DeleteTrails(context.Background(), deletions, &audit)
+		if len(errs) > 0 {
+			t.Fatalf("DeleteTrails error: %s", errs[0])
+		}
+
+		deleted := make([]string, 0, 2)
+		for _, arn := range clients.cloudtrail["222222222222"].deleted {
+			deleted = append(deleted, arn[strings.LastIndex(arn, "/")+1:])
+		}
+		sort.Strings(deleted)
+		if strings.Join(deleted, ",") != strings.Join(test.deleted, ",") {
+			t.Errorf("DeleteTrails(%+v) deleted %v, expected %v", test.opts, deleted, test.deleted)
+		}
+		if lines := strings.Count(audit.String(), "\n"); lines != len(test.deleted) {
+			t.Errorf("DeleteTrails(%+v) wrote %d audit records, expected %d", test.opts, lines, len(test.deleted))
+		}
+	}
+
+}
+
+func TestGetCredentialReports(t *testing.T) {
+
+	org, _, err := newMockClientOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	tests := []struct {
+		accounts []string
+		rows     int
+		failed   string
+	}{
+		{[]string{"222222222222"}, 3, ""},
+		{[]string{"222222222222", "333333333333"}, 3, "333333333333"},
+		{[]string{"111111111111"}, 0, "111111111111"},
+	}
+
+	for _, test := range tests {
+		rows, errs := org.GetCredentialReports(context.Background(), mockAccounts(t, org, test.accounts...))
+		if failedAccounts(errs) != test.failed {
+			t.Errorf("GetCredentialReports(%v) failed for %q, expected %q", test.accounts, failedAccounts(errs), test.failed)
+		}
+		if len(rows) != test.rows {
+			t.Errorf("GetCredentialReports(%v) returned %d rows, expected %d", test.accounts, len(rows), test.rows)
+		}
+		for _, row := range rows {
+			if row.AccountId != "222222222222" {
+				t.Errorf("GetCredentialReports(%v) returned a row for %s", test.accounts, row.AccountId)
+			}
+		}
+	}
+
+}
@@ -1,13 +1,12 @@
 package aws
 
 import (
-	"fmt"
+	"context"
+	"sort"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudfront"
-	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+	"github.com/aws/aws-sdk-go/service/organizations"
 )
 
 type CloudfrontsPerAccount map[string][]*cloudfront.DistributionSummary
@@ -32,109 +31,54 @@ func (s CloudfrontsPerAccount) Set(key string, value []*cloudfront.DistributionS
 	s[key] = value
 }
 
-func (o *Organization) GetCloudfrontsForAccount(accountid string) ([]*cloudfront.DistributionSummary, error) {
-
-	role := fmt.Sprintf("arn:aws:iam::%v:role/OrganizationAccountAccessRole", accountid)
-
-	sess := session.Must(session.NewSession())
-	stssvc := sts.New(sess)
-
-	params := &sts.AssumeRoleInput{
-		RoleArn:         aws.String(role),                   // Required
-		RoleSessionName: aws.String("organizer-cloudfront"), // Required
-		DurationSeconds: aws.Int64(900),
-	}
-	resp, err := stssvc.AssumeRole(params)
-	if err != nil {
-		return nil, err
+func (s CloudfrontsPerAccount) Accounts() []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+	return keys
+}
 
-	config := aws.NewConfig().WithCredentials(
-		credentials.NewStaticCredentials(
-			*resp.Credentials.AccessKeyId,
-			*resp.Credentials.SecretAccessKey,
-			*resp.Credentials.SessionToken,
-		),
-	).WithRegion(o.region)
-
-	sess = session.New(config)
-	svc := cloudfront.New(sess)
+func (o *Organization) GetCloudfrontsForAccount(ctx context.Context, accountid string) ([]*cloudfront.DistributionSummary, error) {
 
-	cparams := &cloudfront.ListDistributionsInput{
-		MaxItems: aws.Int64(100),
-	}
-	cresp, err := svc.ListDistributions(cparams)
+	svc, err := o.clients.CloudFront(accountid, o.region)
 	if err != nil {
-		// Print the error, cast err to awserr.Error to get the Code and
-		// Message from an error.
-		fmt.Println(err.Error())
 		return nil, err
 	}
-
-	// Pretty-print the response data.
-	// fmt.Println(resp)
-
-	distros := make([]*cloudfront.DistributionSummary, 0, 500)
-
-	for _, distro := range cresp.DistributionList.Items {
-		distros = append(distros, distro)
-	}
-
-	return distros, nil
+	return listDistributions(ctx, svc, o.newPageLimit("cloudfront distributions in account "+accountid))
 
 }
 
-func (o *Organization) GetCloudfronts() (map[string][]*cloudfront.DistributionSummary, error) {
+func listDistributions(ctx context.Context, svc cloudfrontiface.CloudFrontAPI, limit *pageLimit) ([]*cloudfront.DistributionSummary, error) {
 
-	accounts, err := o.GetActiveAccounts()
+	distros := make([]*cloudfront.DistributionSummary, 0, 100)
+	err := svc.ListDistributionsPagesWithContext(ctx, &cloudfront.ListDistributionsInput{}, func(page *cloudfront.ListDistributionsOutput, last bool) bool {
+		if page.DistributionList != nil {
+			distros = append(distros, page.DistributionList.Items...)
+		}
+		return limit.next(last)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	distros := make(CloudfrontsPerAccount)
-
-	for _, account := range accounts {
-		distributions, err := o.GetCloudfrontsForAccount(*account.Id)
-		if err != nil {
-			fmt.Printf("warning: could not list users for account %s\n\twarning: %s\n", *account.Name, err)
-			continue
-		}
-		distros.Set(*account.Id, distributions)
-	}
 	return distros, nil
 
 }
 
-func (o *Organization) PrintCloudfrontsForAccount(accountid string) error {
-	distros, err := o.GetCloudfrontsForAccount(accountid)
-	if err != nil {
-		return err
-	}
-
-	if len(distros) == 0 {
-		fmt.Printf("warning: no cloudfront distributions found in account %s\n", accountid)
-		return nil
-	}
-
-	for _, distro := range distros {
-		fmt.Printf("%s,%s\n", accountid, *distro.DomainName)
-	}
-
-	return nil
-
-}
+func (o *Organization) GetCloudfronts(ctx context.Context, accounts []*organizations.Account) (CloudfrontsPerAccount, AccountErrors) {
 
-func (o *Organization) PrintCloudfronts() error {
-	distros, err := o.GetCloudfronts()
-	if err != nil {
-		return err
-	}
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		return o.GetCloudfrontsForAccount(ctx, *account.Id)
+	})
 
-	for accountid, accountdistros := range distros {
-		for _, distro := range accountdistros {
-			fmt.Printf("%s,%s\n", accountid, *distro.DomainName)
+	distros := make(CloudfrontsPerAccount)
+	for _, result := range results {
+		if result.Err == nil {
+			distros.Set(*result.Account.Id, result.Value.([]*cloudfront.DistributionSummary))
 		}
 	}
-	return nil
+	return distros, Errors(results)
 
 }
@@ -0,0 +1,259 @@
+package aws
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config controls how organizer reaches the organization master and the
+// member accounts.
+type Config struct {
+	// RoleName is the role assumed in every member account
+	RoleName string `yaml:"role_name"`
+	// RolePath is the iam path of the member account role
+	RolePath string `yaml:"role_path"`
+	// ExternalId is passed when assuming the member account role
+	ExternalId string `yaml:"external_id"`
+	// SessionPrefix is prepended to every assumed role session name
+	SessionPrefix string `yaml:"session_prefix"`
+	// SessionDuration is the lifetime of each assumed role session
+	SessionDuration time.Duration `yaml:"session_duration"`
+	// RoleChain lists role arns assumed in order before reaching the
+	// organization master, eg. a tooling account role then the master role
+	RoleChain []string `yaml:"role_chain"`
+	// Parallel is the number of accounts worked on at once
+	Parallel int `yaml:"parallel"`
+	// AccountTimeout bounds the time spent on any one account, 0 for no limit
+	AccountTimeout time.Duration `yaml:"account_timeout"`
+	// Output is the format list commands print their results in
+	Output string `yaml:"output"`
+	// Trail is the standard cloudtrail ensured in every account
+	Trail StandardTrail `yaml:"trail"`
+	// AccountStatusInterval is how often a new account is checked while
+	// it is being created
+	AccountStatusInterval time.Duration `yaml:"account_status_interval"`
+	// Partition is the aws partition the organization is in: aws,
+	// aws-us-gov or aws-cn
+	Partition string `yaml:"partition"`
+	// Endpoint sends every aws api call to this url instead of aws, eg. a
+	// local emulation of the services for testing
+	Endpoint string `yaml:"endpoint"`
+	// Endpoints overrides the url of single services by endpoint id, eg.
+	// iam or s3, ahead of Endpoint
+	Endpoints map[string]string `yaml:"endpoints"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		RoleName:        "OrganizationAccountAccessRole",
+		RolePath:        "/",
+		SessionPrefix:   "organizer",
+		SessionDuration: roleSessionDuration,
+		RoleChain:       []string{},
+		Parallel:        defaultParallel,
+		Output:          "table",
+		Trail: StandardTrail{
+			Name:              "organizer",
+			LogFileValidation: true,
+		},
+		AccountStatusInterval: accountStatusInterval,
+		Partition:             defaultPartition,
+		Endpoints:             make(map[string]string),
+	}
+}
+
+// LoadConfigFile reads a yaml config file over the current settings.
+func (c *Config) LoadConfigFile(path string) error {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	err = yaml.Unmarshal(data, c)
+	if err != nil {
+		return fmt.Errorf("could not parse config file %s: %s", path, err)
+	}
+	return nil
+
+}
+
+// ApplyEnv overrides settings from ORGANIZER_* environment variables.
+func (c *Config) ApplyEnv() error {
+
+	if v := os.Getenv("ORGANIZER_ROLE_NAME"); v != "" {
+		c.RoleName = v
+	}
+	if v := os.Getenv("ORGANIZER_ROLE_PATH"); v != "" {
+		c.RolePath = v
+	}
+	if v := os.Getenv("ORGANIZER_EXTERNAL_ID"); v != "" {
+		c.ExternalId = v
+	}
+	if v := os.Getenv("ORGANIZER_SESSION_PREFIX"); v != "" {
+		c.SessionPrefix = v
+	}
+	if v := os.Getenv("ORGANIZER_SESSION_DURATION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid ORGANIZER_SESSION_DURATION: %s", err)
+		}
+		c.SessionDuration = d
+	}
+	if v := os.Getenv("ORGANIZER_ROLE_CHAIN"); v != "" {
+		c.RoleChain = SplitList(v)
+	}
+	if v := os.Getenv("ORGANIZER_PARALLEL"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid ORGANIZER_PARALLEL: %s", err)
+		}
+		c.Parallel = n
+	}
+	if v := os.Getenv("ORGANIZER_OUTPUT"); v != "" {
+		c.Output = v
+	}
+	if v := os.Getenv("ORGANIZER_ACCOUNT_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid ORGANIZER_ACCOUNT_TIMEOUT: %s", err)
+		}
+		c.AccountTimeout = d
+	}
+	if v := os.Getenv("ORGANIZER_ACCOUNT_STATUS_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid ORGANIZER_ACCOUNT_STATUS_INTERVAL: %s", err)
+		}
+		c.AccountStatusInterval = d
+	}
+	if v := os.Getenv("ORGANIZER_PARTITION"); v != "" {
+		c.Partition = v
+	}
+	if v := os.Getenv("ORGANIZER_ENDPOINT"); v != "" {
+		c.Endpoint = v
+	}
+	if v := os.Getenv("ORGANIZER_ENDPOINTS"); v != "" {
+		for _, pair := range SplitList(v) {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("invalid ORGANIZER_ENDPOINTS entry %s, expected service=url", pair)
+			}
+			if c.Endpoints == nil {
+				c.Endpoints = make(map[string]string)
+			}
+			c.Endpoints[kv[0]] = kv[1]
+		}
+	}
+	return nil
+
+}
+
+func (c *Config) Validate() error {
+
+	if len(c.RoleName) == 0 {
+		return fmt.Errorf("member account role name must not be empty")
+	}
+	if c.SessionDuration < roleSessionDuration {
+		return fmt.Errorf("session duration must be at least %s", roleSessionDuration)
+	}
+	if c.SessionDuration > roleSessionMaxDuration {
+		return fmt.Errorf("session duration must be at most %s", roleSessionMaxDuration)
+	}
+	if len(c.RoleChain) > 0 && c.SessionDuration > chainedSessionMaxDuration {
+		return fmt.Errorf("session duration must be at most %s with a role chain", chainedSessionMaxDuration)
+	}
+	if c.Parallel < 1 {
+		return fmt.Errorf("parallel must be at least 1")
+	}
+	if c.AccountTimeout < 0 {
+		return fmt.Errorf("account timeout must not be negative")
+	}
+	if c.AccountStatusInterval <= 0 {
+		return fmt.Errorf("account status interval must be positive")
+	}
+	if _, ok := partitions[c.Partition]; !ok {
+		return fmt.Errorf("unknown partition %s, expected one of %s", c.Partition, strings.Join(partitionIds(), ", "))
+	}
+	if len(c.Endpoint) > 0 && !isHttpURL(c.Endpoint) {
+		return fmt.Errorf("endpoint %s is not an http or https url", c.Endpoint)
+	}
+	for service, url := range c.Endpoints {
+		if len(service) == 0 {
+			return fmt.Errorf("endpoint %s has no service", url)
+		}
+		if !isHttpURL(url) {
+			return fmt.Errorf("endpoint %s for %s is not an http or https url", url, service)
+		}
+	}
+	for _, arn := range c.RoleChain {
+		if !strings.HasPrefix(arn, "arn:") {
+			return fmt.Errorf("role chain entry %s is not a role arn", arn)
+		}
+	}
+	return nil
+
+}
+
+// RoleArn returns the arn of the role to assume in a member account.
+func (c *Config) RoleArn(accountid string) string {
+
+	path := c.RolePath
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if !strings.HasSuffix(path, "/") {
+		path = path + "/"
+	}
+	return fmt.Sprintf("arn:%s:iam::%v:role%s%s", c.Partition, accountid, path, c.RoleName)
+
+}
+
+// EndpointFor returns the url calls to a service are sent to instead of
+// aws, empty if there is no override.
+func (c *Config) EndpointFor(service string) string {
+	if url, ok := c.Endpoints[service]; ok {
+		return url
+	}
+	return c.Endpoint
+}
+
+func isHttpURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// DefaultRegion returns the region from the environment, or else the
+// region of the partition.
+func (c *Config) DefaultRegion() string {
+
+	if v := os.Getenv("AWS_REGION"); v != "" {
+		return v
+	}
+	if v := os.Getenv("AWS_DEFAULT_REGION"); v != "" {
+		return v
+	}
+	return partitions[c.Partition].region
+
+}
+
+// SessionName returns the assumed role session name for a member account.
+func (c *Config) SessionName(accountid string) string {
+	return c.SessionPrefix + "-" + accountid
+}
+
+// SplitList splits a comma separated list, dropping empty entries.
+func SplitList(s string) []string {
+	list := make([]string, 0, 10)
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if len(v) > 0 {
+			list = append(list, v)
+		}
+	}
+	return list
+}
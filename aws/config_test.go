@@ -0,0 +1,133 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoleArn(t *testing.T) {
+
+	tests := []struct {
+		name string
+		path string
+		arn  string
+	}{
+		{"OrganizationAccountAccessRole", "/", "arn:aws:iam::123456789012:role/OrganizationAccountAccessRole"},
+		{"LandingZoneAdmin", "", "arn:aws:iam::123456789012:role/LandingZoneAdmin"},
+		{"LandingZoneAdmin", "/admin", "arn:aws:iam::123456789012:role/admin/LandingZoneAdmin"},
+		{"LandingZoneAdmin", "admin/", "arn:aws:iam::123456789012:role/admin/LandingZoneAdmin"},
+	}
+
+	for _, test := range tests {
+		config := DefaultConfig()
+		config.RoleName = test.name
+		config.RolePath = test.path
+		arn := config.RoleArn("123456789012")
+		if arn != test.arn {
+			t.Errorf("config RoleArn returned %s, expected %s", arn, test.arn)
+		}
+	}
+
+}
+
+func TestConfigValidate(t *testing.T) {
+
+	config := DefaultConfig()
+	if err := config.Validate(); err != nil {
+		t.Errorf("default config is invalid: %s", err)
+	}
+
+	config.RoleChain = []string{"tooling-role"}
+	if err := config.Validate(); err == nil {
+		t.Errorf("config Validate accepted a role chain entry that is not an arn")
+	}
+
+	config = DefaultConfig()
+	config.SessionDuration = 0
+	if err := config.Validate(); err == nil {
+		t.Errorf("config Validate accepted a session duration below the minimum")
+	}
+
+	config = DefaultConfig()
+	config.SessionDuration = 13 * time.Hour
+	if err := config.Validate(); err == nil {
+		t.Errorf("config Validate accepted a session duration above the maximum")
+	}
+
+	config = DefaultConfig()
+	config.SessionDuration = 2 * time.Hour
+	if err := config.Validate(); err != nil {
+		t.Errorf("config Validate rejected a 2h session duration: %s", err)
+	}
+	config.RoleChain = []string{"arn:aws:iam::222222222222:role/OrganizerMaster"}
+	if err := config.Validate(); err == nil {
+		t.Errorf("config Validate accepted a 2h session duration with a role chain")
+	}
+
+	config = DefaultConfig()
+	config.Endpoint = "localhost:4566"
+	if err := config.Validate(); err == nil {
+		t.Errorf("config Validate accepted an endpoint that is not a url")
+	}
+
+}
+
+func TestPartition(t *testing.T) {
+
+	config := DefaultConfig()
+	config.Partition = "aws-us-gov"
+	if arn := config.RoleArn("123456789012"); arn != "arn:aws-us-gov:iam::123456789012:role/OrganizationAccountAccessRole" {
+		t.Errorf("config RoleArn returned %s in aws-us-gov", arn)
+	}
+
+	org, err := NewOrganizationWithConfig(config)
+	if err != nil {
+		t.Fatalf("could not create organization: %s", err)
+	}
+	regions := strings.Join(org.GetRegions(), ",")
+	if !strings.Contains(regions, "us-gov-west-1") || strings.Contains(regions, "us-east-1") {
+		t.Errorf("GetRegions returned %s in aws-us-gov", regions)
+	}
+
+	config.Partition = "aws-mars"
+	if err := config.Validate(); err == nil {
+		t.Errorf("config Validate accepted an unknown partition")
+	}
+
+}
+
+func TestEndpointFor(t *testing.T) {
+
+	config := DefaultConfig()
+	if url := config.EndpointFor("iam"); url != "" {
+		t.Errorf("EndpointFor returned %s with no overrides", url)
+	}
+
+	config.Endpoint = "http://localhost:4566"
+	config.Endpoints["iam"] = "http://localhost:4593"
+	if url := config.EndpointFor("iam"); url != "http://localhost:4593" {
+		t.Errorf("EndpointFor iam returned %s, expected the service override", url)
+	}
+	if url := config.EndpointFor("s3"); url != "http://localhost:4566" {
+		t.Errorf("EndpointFor s3 returned %s, expected the endpoint for every service", url)
+	}
+
+	resolved, err := endpointResolver(config).EndpointFor("iam", "us-east-1")
+	if err != nil || resolved.URL != "http://localhost:4593" || resolved.SigningRegion != "us-east-1" {
+		t.Errorf("endpointResolver resolved iam to %+v, %v", resolved, err)
+	}
+
+	config = DefaultConfig()
+	config.Partition = "aws-cn"
+	resolved, err = endpointResolver(config).EndpointFor("sts", "cn-northwest-1")
+	if err != nil || !strings.HasSuffix(resolved.URL, ".amazonaws.com.cn") {
+		t.Errorf("endpointResolver resolved sts in aws-cn to %+v, %v", resolved, err)
+	}
+
+	config.Endpoints["s3"] = "localhost:4572"
+	if err := config.Validate(); err == nil {
+		t.Errorf("config Validate accepted a service endpoint that is not a url")
+	}
+
+}
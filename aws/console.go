@@ -0,0 +1,97 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// GetConsoleURL returns a url that signs in to the aws console of a member
+// account as the member account role. The url is valid for 15 minutes and
+// the console session lasts as long as the assumed role credentials. The
+// console of the organization's partition is opened by default.
+func (o *Organization) GetConsoleURL(ctx context.Context, accountid string, destination string) (string, error) {
+
+	creds, _, err := o.GetCredentialsForAccount(accountid)
+	if err != nil {
+		return "", err
+	}
+	p := partitions[o.config.Partition]
+	if len(destination) == 0 {
+		destination = p.console
+	}
+	return signinURL(ctx, p.signin, creds, destination)
+
+}
+
+// GetCallerIdentity returns the arn that calls in a member account are
+// made as, the assumed member account role.
+func (o *Organization) GetCallerIdentity(ctx context.Context, accountid string) (string, error) {
+
+	svc, err := o.clients.STS(accountid, o.region)
+	if err != nil {
+		return "", err
+	}
+	resp, err := svc.GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(resp.Arn), nil
+
+}
+
+// signinURL swaps credentials for a sign in token at the federation
+// endpoint and builds the console login url from it.
+func signinURL(ctx context.Context, endpoint string, creds credentials.Value, destination string) (string, error) {
+
+	session, err := json.Marshal(map[string]string{
+		"sessionId":    creds.AccessKeyID,
+		"sessionKey":   creds.SecretAccessKey,
+		"sessionToken": creds.SessionToken,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("Action", "getSigninToken")
+	query.Set("Session", string(session))
+	req, err := http.NewRequest("GET", endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("could not get sign in token: %s", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("could not get sign in token: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("could not get sign in token: %s", resp.Status)
+	}
+
+	token := struct {
+		SigninToken string
+	}{}
+	if err := json.Unmarshal(body, &token); err != nil || len(token.SigninToken) == 0 {
+		return "", fmt.Errorf("could not get sign in token: unexpected response from %s", endpoint)
+	}
+
+	query = url.Values{}
+	query.Set("Action", "login")
+	query.Set("Issuer", "organizer")
+	query.Set("Destination", destination)
+	query.Set("SigninToken", token.SigninToken)
+	return endpoint + "?" + query.Encode(), nil
+
+}
@@ -0,0 +1,68 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+func TestSigninURL(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := map[string]string{}
+		if r.URL.Query().Get("Action") != "getSigninToken" {
+			http.Error(w, "bad action", http.StatusBadRequest)
+			return
+		}
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("Session")), &session); err != nil || session["sessionId"] != "AKIDEXAMPLE" {
+			http.Error(w, "bad session", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"SigninToken":"token-123"}`))
+	}))
+	defer server.Close()
+
+	creds := credentials.Value{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret", SessionToken: "session"}
+	signin, err := signinURL(context.Background(), server.URL, creds, partitions[defaultPartition].console)
+	if err != nil {
+		t.Fatalf("signinURL error: %s", err)
+	}
+
+	u, err := url.Parse(signin)
+	if err != nil {
+		t.Fatalf("signinURL returned an invalid url %s: %s", signin, err)
+	}
+	query := u.Query()
+	if query.Get("Action") != "login" || query.Get("SigninToken") != "token-123" || query.Get("Destination") != partitions[defaultPartition].console {
+		t.Errorf("signinURL returned an incorrect login url: %s", signin)
+	}
+
+	creds.AccessKeyID = "wrong"
+	if _, err := signinURL(context.Background(), server.URL, creds, partitions[defaultPartition].console); err == nil {
+		t.Errorf("signinURL should fail when the federation endpoint rejects the session")
+	}
+
+}
+
+func TestGetCallerIdentity(t *testing.T) {
+
+	org, _, err := newMockClientOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	arn, err := org.GetCallerIdentity(context.Background(), "222222222222")
+	if err != nil || arn != "arn:aws:sts::222222222222:assumed-role/OrganizationAccountAccessRole/organizer-222222222222" {
+		t.Errorf("GetCallerIdentity returned %q, %v, expected the member account role of prod-app", arn, err)
+	}
+	// the role cannot be assumed in master
+	if _, err := org.GetCallerIdentity(context.Background(), "111111111111"); err == nil {
+		t.Errorf("GetCallerIdentity in master should fail")
+	}
+
+}
@@ -0,0 +1,241 @@
+package aws
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+const (
+	// the user name iam gives the root account in a credential report
+	rootUser = "<root_account>"
+	// how often to check if a credential report is ready
+	credentialReportPollInterval = 2 * time.Second
+)
+
+// CredentialReportRow is one user of an iam credential report. Times are
+// nil where iam reports N/A, no_information or not_supported.
+type CredentialReportRow struct {
+	AccountId             string
+	User                  string
+	Arn                   string
+	UserCreationTime      *time.Time
+	PasswordEnabled       bool
+	PasswordLastUsed      *time.Time
+	PasswordLastChanged   *time.Time
+	MFAActive             bool
+	AccessKey1Active      bool
+	AccessKey1LastRotated *time.Time
+	AccessKey1LastUsed    *time.Time
+	AccessKey2Active      bool
+	AccessKey2LastRotated *time.Time
+	AccessKey2LastUsed    *time.Time
+}
+
+// Finding is a credential problem found in a credential report.
+type Finding struct {
+	AccountId string
+	User      string
+	Check     string
+	Detail    string
+}
+
+// FindingOptions sets the age limits used by the credential checks.
+type FindingOptions struct {
+	MaxKeyAge time.Duration
+	MaxUnused time.Duration
+}
+
+// GenerateCredentialReportForAccount asks iam for a fresh credential
+// report and waits until it is complete.
+func (o *Organization) GenerateCredentialReportForAccount(ctx context.Context, accountid string) error {
+
+	svc, err := o.GetIamSvcForAccount(accountid)
+	if err != nil {
+		return err
+	}
+
+	for {
+		resp, err := svc.GenerateCredentialReportWithContext(ctx, &iam.GenerateCredentialReportInput{})
+		if err != nil {
+			return err
+		}
+		if aws.StringValue(resp.State) == iam.ReportStateTypeComplete {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(credentialReportPollInterval):
+		}
+	}
+
+}
+
+// GetCredentialReportForAccount generates, fetches and parses the
+// credential report of an account.
+func (o *Organization) GetCredentialReportForAccount(ctx context.Context, accountid string) ([]*CredentialReportRow, error) {
+
+	err := o.GenerateCredentialReportForAccount(ctx, accountid)
+	if err != nil {
+		return nil, err
+	}
+	svc, err := o.GetIamSvcForAccount(accountid)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := svc.GetCredentialReportWithContext(ctx, &iam.GetCredentialReportInput{})
+	if err != nil {
+		return nil, err
+	}
+	return ParseCredentialReport(accountid, string(resp.Content))
+
+}
+
+// GetCredentialReports returns the credential report rows of every account
+// in one list, ordered by account.
+func (o *Organization) GetCredentialReports(ctx context.Context, accounts []*organizations.Account) ([]*CredentialReportRow, AccountErrors) {
+
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		return o.GetCredentialReportForAccount(ctx, *account.Id)
+	})
+
+	rows := make([]*CredentialReportRow, 0, 500)
+	for _, result := range results {
+		if result.Err == nil {
+			rows = append(rows, result.Value.([]*CredentialReportRow)...)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].AccountId < rows[j].AccountId })
+	return rows, Errors(results)
+
+}
+
+// ParseCredentialReport parses the csv content of a credential report.
+func ParseCredentialReport(accountid string, content string) ([]*CredentialReportRow, error) {
+
+	records, err := csv.NewReader(strings.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse credential report: %s", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("credential report is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	for _, name := range []string{"user", "arn", "password_enabled", "mfa_active", "access_key_1_active", "access_key_2_active"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("credential report has no %s column", name)
+		}
+	}
+
+	rows := make([]*CredentialReportRow, 0, len(records)-1)
+	for _, record := range records[1:] {
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return record[i]
+		}
+		when := func(name string) *time.Time {
+			t, err := time.Parse(time.RFC3339, field(name))
+			if err != nil {
+				return nil
+			}
+			return &t
+		}
+		rows = append(rows, &CredentialReportRow{
+			AccountId:             accountid,
+			User:                  field("user"),
+			Arn:                   field("arn"),
+			UserCreationTime:      when("user_creation_time"),
+			PasswordEnabled:       field("password_enabled") == "true",
+			PasswordLastUsed:      when("password_last_used"),
+			PasswordLastChanged:   when("password_last_changed"),
+			MFAActive:             field("mfa_active") == "true",
+			AccessKey1Active:      field("access_key_1_active") == "true",
+			AccessKey1LastRotated: when("access_key_1_last_rotated"),
+			AccessKey1LastUsed:    when("access_key_1_last_used_date"),
+			AccessKey2Active:      field("access_key_2_active") == "true",
+			AccessKey2LastRotated: when("access_key_2_last_rotated"),
+			AccessKey2LastUsed:    when("access_key_2_last_used_date"),
+		})
+	}
+	return rows, nil
+
+}
+
+// CredentialFindings runs the built in credential checks over report rows.
+func CredentialFindings(rows []*CredentialReportRow, opts *FindingOptions, now time.Time) []*Finding {
+
+	findings := make([]*Finding, 0, 50)
+	add := func(row *CredentialReportRow, check string, format string, args ...interface{}) {
+		findings = append(findings, &Finding{AccountId: row.AccountId, User: row.User, Check: check, Detail: fmt.Sprintf(format, args...)})
+	}
+	days := func(d time.Duration) int {
+		return int(d.Hours() / 24)
+	}
+	// unused returns true if a credential has not been used within the
+	// limit, counting a never used credential from when it was created
+	unused := func(lastUsed *time.Time, since *time.Time) bool {
+		if lastUsed != nil {
+			return now.Sub(*lastUsed) > opts.MaxUnused
+		}
+		return since != nil && now.Sub(*since) > opts.MaxUnused
+	}
+
+	for _, row := range rows {
+		keys := []struct {
+			n        int
+			active   bool
+			rotated  *time.Time
+			lastUsed *time.Time
+		}{
+			{1, row.AccessKey1Active, row.AccessKey1LastRotated, row.AccessKey1LastUsed},
+			{2, row.AccessKey2Active, row.AccessKey2LastRotated, row.AccessKey2LastUsed},
+		}
+
+		if row.User == rootUser {
+			for _, key := range keys {
+				if key.active {
+					add(row, "root-access-key", "root access key %d is active", key.n)
+				}
+			}
+			if !row.MFAActive {
+				add(row, "root-mfa", "root account has no mfa device")
+			}
+			continue
+		}
+
+		if row.PasswordEnabled && !row.MFAActive {
+			add(row, "user-mfa", "console password without mfa")
+		}
+		if row.PasswordEnabled && opts.MaxUnused > 0 && unused(row.PasswordLastUsed, row.UserCreationTime) {
+			add(row, "password-unused", "password not used for over %d days", days(opts.MaxUnused))
+		}
+		for _, key := range keys {
+			if !key.active {
+				continue
+			}
+			if opts.MaxKeyAge > 0 && key.rotated != nil && now.Sub(*key.rotated) > opts.MaxKeyAge {
+				add(row, "access-key-age", "access key %d is %d days old", key.n, days(now.Sub(*key.rotated)))
+			}
+			if opts.MaxUnused > 0 && unused(key.lastUsed, key.rotated) {
+				add(row, "access-key-unused", "access key %d not used for over %d days", key.n, days(opts.MaxUnused))
+			}
+		}
+	}
+	return findings
+
+}
@@ -0,0 +1,53 @@
+package aws
+
+import (
+	"testing"
+	"time"
+)
+
+const testCredentialReport = `user,arn,user_creation_time,password_enabled,password_last_used,password_last_changed,password_next_rotation,mfa_active,access_key_1_active,access_key_1_last_rotated,access_key_1_last_used_date,access_key_1_last_used_region,access_key_1_last_used_service,access_key_2_active,access_key_2_last_rotated,access_key_2_last_used_date,access_key_2_last_used_region,access_key_2_last_used_service,cert_1_active,cert_1_last_rotated,cert_2_active,cert_2_last_rotated
+<root_account>,arn:aws:iam::222222222222:root,2017-01-01T00:00:00+00:00,not_supported,2019-04-01T00:00:00+00:00,not_supported,not_supported,false,true,2017-01-01T00:00:00+00:00,N/A,N/A,N/A,false,N/A,N/A,N/A,N/A,false,N/A,false,N/A
+alice,arn:aws:iam::222222222222:user/alice,2018-01-01T00:00:00+00:00,true,2019-04-30T00:00:00+00:00,2018-01-01T00:00:00+00:00,N/A,true,true,2019-03-01T00:00:00+00:00,2019-04-30T00:00:00+00:00,us-east-1,s3,false,N/A,N/A,N/A,N/A,false,N/A,false,N/A
+bob,arn:aws:iam::222222222222:user/bob,2018-01-01T00:00:00+00:00,true,no_information,2018-01-01T00:00:00+00:00,N/A,false,true,2018-01-01T00:00:00+00:00,N/A,N/A,N/A,false,N/A,N/A,N/A,N/A,false,N/A,false,N/A
+`
+
+func TestCredentialFindings(t *testing.T) {
+
+	rows, err := ParseCredentialReport("222222222222", testCredentialReport)
+	if err != nil {
+		t.Fatalf("ParseCredentialReport error: %s", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("ParseCredentialReport returned %d rows, expected 3", len(rows))
+	}
+	alice := rows[1]
+	if alice.User != "alice" || !alice.MFAActive || alice.AccessKey1LastUsed == nil || alice.AccessKey2LastRotated != nil {
+		t.Errorf("ParseCredentialReport parsed alice incorrectly: %+v", alice)
+	}
+
+	now := time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+	opts := &FindingOptions{MaxKeyAge: 90 * 24 * time.Hour, MaxUnused: 90 * 24 * time.Hour}
+	findings := CredentialFindings(rows, opts, now)
+
+	want := map[string]string{
+		"root-access-key":   "<root_account>",
+		"root-mfa":          "<root_account>",
+		"user-mfa":          "bob",
+		"password-unused":   "bob",
+		"access-key-age":    "bob",
+		"access-key-unused": "bob",
+	}
+	if len(findings) != len(want) {
+		t.Errorf("CredentialFindings returned %d findings, expected %d", len(findings), len(want))
+	}
+	for _, finding := range findings {
+		if user, ok := want[finding.Check]; !ok || user != finding.User {
+			t.Errorf("CredentialFindings returned an unexpected finding %s for %s", finding.Check, finding.User)
+		}
+	}
+
+	if _, err := ParseCredentialReport("222222222222", "user,arn\nalice,arn\n"); err == nil {
+		t.Errorf("ParseCredentialReport should fail on a report missing columns")
+	}
+
+}
@@ -0,0 +1,73 @@
+package aws
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+)
+
+const defaultPartition = endpoints.AwsPartitionID
+
+// partition is what organizer needs to know about an aws partition beyond
+// the endpoints the sdk resolves.
+type partition struct {
+	// region is where the organizations service of the partition lives
+	region  string
+	signin  string
+	console string
+}
+
+var partitions = map[string]*partition{
+	endpoints.AwsPartitionID: {
+		region:  "us-east-1",
+		signin:  "https://signin.aws.amazon.com/federation",
+		console: "https://console.aws.amazon.com/",
+	},
+	endpoints.AwsUsGovPartitionID: {
+		region:  "us-gov-west-1",
+		signin:  "https://signin.amazonaws-us-gov.com/federation",
+		console: "https://console.amazonaws-us-gov.com/",
+	},
+	endpoints.AwsCnPartitionID: {
+		region:  "cn-northwest-1",
+		signin:  "https://signin.amazonaws.cn/federation",
+		console: "https://console.amazonaws.cn/",
+	},
+}
+
+func partitionIds() []string {
+	ids := make([]string, 0, len(partitions))
+	for id := range partitions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// partitionRegions returns the regions of a partition known to the sdk.
+func partitionRegions(id string) []string {
+	regions := make([]string, 0, 30)
+	for _, p := range endpoints.DefaultPartitions() {
+		if p.ID() != id {
+			continue
+		}
+		for region := range p.Regions() {
+			regions = append(regions, region)
+		}
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// endpointResolver resolves service endpoints as the sdk does, except for
+// services with an endpoint override in c. Overridden calls are still
+// signed for the service and region they are meant for, which is how a
+// local emulation tells them apart.
+func endpointResolver(c *Config) endpoints.Resolver {
+	return endpoints.ResolverFunc(func(service, region string, opts ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
+		if url := c.EndpointFor(service); len(url) > 0 {
+			return endpoints.ResolvedEndpoint{URL: url, SigningRegion: region}, nil
+		}
+		return endpoints.DefaultResolver().EndpointFor(service, region, opts...)
+	})
+}
@@ -0,0 +1,127 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+const (
+	// default number of accounts worked on at once
+	defaultParallel = 10
+)
+
+// AccountFunc does some work against a single account.
+type AccountFunc func(ctx context.Context, account *organizations.Account) (interface{}, error)
+
+// AccountResult holds the outcome of an AccountFunc for one account.
+type AccountResult struct {
+	Account *organizations.Account
+	Value   interface{}
+	Err     error
+}
+
+// AccountError records a failure against a single account.
+type AccountError struct {
+	AccountId   string
+	AccountName string
+	Err         error
+}
+
+func (e *AccountError) Error() string {
+	return fmt.Sprintf("account %s (%s): %s", e.AccountId, e.AccountName, e.Err)
+}
+
+type AccountErrors []*AccountError
+
+func (e AccountErrors) Error() string {
+	return fmt.Sprintf("%d accounts failed", len(e))
+}
+
+// ForEachAccount runs fn against every account using a bounded pool of
+// workers. Each call gets its own context which is cancelled after the
+// configured account timeout or when ctx is cancelled. Results are
+// returned in the same order as accounts.
+func (o *Organization) ForEachAccount(ctx context.Context, accounts []*organizations.Account, fn AccountFunc) []*AccountResult {
+
+	results := make([]*AccountResult, len(accounts))
+	jobs := make(chan int)
+
+	parallel := o.config.Parallel
+	if parallel < 1 {
+		parallel = defaultParallel
+	}
+	if parallel > len(accounts) {
+		parallel = len(accounts)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = o.runForAccount(ctx, accounts[i], fn)
+			}
+		}()
+	}
+
+	for i := range accounts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+
+}
+
+func (o *Organization) runForAccount(ctx context.Context, account *organizations.Account, fn AccountFunc) *AccountResult {
+
+	result := &AccountResult{Account: account}
+
+	// don't start any more work once the run has been cancelled
+	if err := ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	actx, cancel := ctx, context.CancelFunc(func() {})
+	if o.config.AccountTimeout > 0 {
+		actx, cancel = context.WithTimeout(ctx, o.config.AccountTimeout)
+	}
+	defer cancel()
+
+	result.Value, result.Err = fn(actx, account)
+	return result
+
+}
+
+// Errors returns the per account failures from a set of results.
+func Errors(results []*AccountResult) AccountErrors {
+
+	var errs AccountErrors
+	for _, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		errs = append(errs, &AccountError{
+			AccountId:   stringValue(result.Account.Id),
+			AccountName: stringValue(result.Account.Name),
+			Err:         result.Err,
+		})
+	}
+	return errs
+
+}
+
+// accountOrId returns account, or an account with only an id for results
+// that were built without one.
+func accountOrId(account *organizations.Account, accountid string) *organizations.Account {
+	if account != nil {
+		return account
+	}
+	return &organizations.Account{Id: &accountid}
+}
@@ -0,0 +1,108 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+func testAccounts(n int) []*organizations.Account {
+	accounts := make([]*organizations.Account, n)
+	for i := range accounts {
+		accounts[i] = &organizations.Account{
+			Id:     aws.String(fmt.Sprintf("%012d", i)),
+			Name:   aws.String(fmt.Sprintf("account-%d", i)),
+			Status: aws.String("ACTIVE"),
+		}
+	}
+	return accounts
+}
+
+func TestForEachAccount(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+	org.config.Parallel = 4
+	accounts := testAccounts(25)
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+
+	results := org.ForEachAccount(context.Background(), accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if account == accounts[3] {
+			return nil, fmt.Errorf("access denied")
+		}
+		return *account.Name, nil
+	})
+
+	if len(results) != len(accounts) {
+		t.Fatalf("ForEachAccount returned %d results, expected %d", len(results), len(accounts))
+	}
+	for i, result := range results {
+		if result.Account != accounts[i] {
+			t.Errorf("ForEachAccount result %d is out of order", i)
+		}
+	}
+	if peak > 4 {
+		t.Errorf("ForEachAccount ran %d accounts at once, expected at most 4", peak)
+	}
+
+	errs := Errors(results)
+	if len(errs) != 1 || errs[0].AccountId != *accounts[3].Id {
+		t.Errorf("ForEachAccount errors incorrect: %v", errs)
+	}
+
+}
+
+func TestForEachAccountCancel(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+	org.config.AccountTimeout = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := org.ForEachAccount(ctx, testAccounts(3), func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		return nil, nil
+	})
+	if len(Errors(results)) != 3 {
+		t.Errorf("ForEachAccount ran accounts after the context was cancelled")
+	}
+
+	results = org.ForEachAccount(context.Background(), testAccounts(2), func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	for _, e := range Errors(results) {
+		if e.Err != context.DeadlineExceeded {
+			t.Errorf("ForEachAccount account timeout returned %s", e.Err)
+		}
+	}
+	if len(Errors(results)) != 2 {
+		t.Errorf("ForEachAccount did not time out every account")
+	}
+
+}
@@ -1,13 +1,15 @@
 package aws
 
 import (
-	"fmt"
+	"context"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/iam"
-	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/organizations"
 )
 
 type UsersPerAccount map[string][]*iam.User
@@ -33,319 +35,182 @@ func (s UsersPerAccount) Set(key string, value []*iam.User) {
 	s[key] = value
 }
 
-func (s AliasesPerAccount) Set(key string, value []*string) {
-	s[key] = value
-}
-
-func (o *Organization) GetIamSvcForAccount(accountid string) (*iam.IAM, error) {
-
-	role := fmt.Sprintf("arn:aws:iam::%v:role/OrganizationAccountAccessRole", accountid)
-
-	sess := session.Must(session.NewSession())
-	stssvc := sts.New(sess)
-	sess_name := "organizer-iam-" + accountid
-
-	params := &sts.AssumeRoleInput{
-		RoleArn:         aws.String(role),      // Required
-		RoleSessionName: aws.String(sess_name), // Required
-		DurationSeconds: aws.Int64(900),
+func (s UsersPerAccount) Accounts() []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
 	}
-	resp, err := stssvc.AssumeRole(params)
-	if err != nil {
-		return nil, err
-	}
-
-	config := aws.NewConfig().WithCredentials(
-		credentials.NewStaticCredentials(
-			*resp.Credentials.AccessKeyId,
-			*resp.Credentials.SecretAccessKey,
-			*resp.Credentials.SessionToken,
-		),
-	).WithRegion(o.region)
-
-	sess = session.New(config)
-	return iam.New(sess), nil
-
+	sort.Strings(keys)
+	return keys
 }
 
-func (o *Organization) GenerateCredentialReportForAccount(accountid string) error {
-
-	svc, err := o.GetIamSvcForAccount(accountid)
-	if err != nil {
-		return err
-	}
-
-	input := &iam.GenerateCredentialReportInput{}
-	resp, err := svc.GenerateCredentialReport(input)
-	if err != nil {
-		// Print the error, cast err to awserr.Error to get the Code and
-		// Message from an error.
-		fmt.Println(err.Error())
-		return err
-	}
-
-	// Pretty-print the response data.
-	fmt.Println(resp)
-
-	return nil
-
+func (s AliasesPerAccount) Set(key string, value []*string) {
+	s[key] = value
 }
 
-func (o *Organization) GenerateCredentialReports() error {
-
-	accounts, err := o.GetActiveAccounts()
-	if err != nil {
-		return err
-	}
-
-	for _, account := range accounts {
-		err := o.GenerateCredentialReportForAccount(*account.Id)
-		if err != nil {
-			fmt.Printf("warning: could not generate credential report for account %s\n\twarning: %s\n", *account.Name, err)
-			continue
-		}
-		fmt.Printf("info: generated credential report for account %s\n", *account.Id)
+func (s AliasesPerAccount) Accounts() []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
 	}
-	return nil
+	sort.Strings(keys)
+	return keys
+}
 
+func (o *Organization) GetIamSvcForAccount(accountid string) (iamiface.IAMAPI, error) {
+	return o.clients.IAM(accountid, o.region)
 }
 
-func (o *Organization) GetCredentialReportForAccount(accountid string) (string, error) {
+func (o *Organization) GetUsersForAccount(ctx context.Context, accountid string) ([]*iam.User, error) {
 
 	svc, err := o.GetIamSvcForAccount(accountid)
 	if err != nil {
-		return "", err
-	}
-
-	input := &iam.GetCredentialReportInput{}
-	resp, err := svc.GetCredentialReport(input)
-	if err != nil {
-		// Print the error, cast err to awserr.Error to get the Code and
-		// Message from an error.
-		fmt.Println(err.Error())
-		return "", err
+		return nil, err
 	}
 
-	// Pretty-print the response data.
-	// fmt.Println(resp)
-	n := len(resp.Content)
-
-	return string(resp.Content[:n]), nil
+	return listUsers(ctx, svc, o.newPageLimit("users in account "+accountid))
 
 }
 
-func (o *Organization) PrintCredentialReportForAccount(accountid string) error {
+func listUsers(ctx context.Context, svc iamiface.IAMAPI, limit *pageLimit) ([]*iam.User, error) {
 
-	report, err := o.GetCredentialReportForAccount(accountid)
+	users := make([]*iam.User, 0, 500)
+	err := svc.ListUsersPagesWithContext(ctx, &iam.ListUsersInput{}, func(page *iam.ListUsersOutput, last bool) bool {
+		users = append(users, page.Users...)
+		return limit.next(last)
+	})
 	if err != nil {
-		return err
-	}
-
-	if len(report) == 0 {
-		fmt.Printf("warning: no credential report found for account %s\n", accountid)
-		return nil
+		return nil, err
 	}
 
-	fmt.Printf("\naccount id: %s\n", accountid)
-	fmt.Printf("%s\n", report)
-
-	return nil
+	return users, nil
 
 }
 
-func (o *Organization) PrintCredentialReports() error {
+func (o *Organization) GetUsers(ctx context.Context, accounts []*organizations.Account) (UsersPerAccount, AccountErrors) {
 
-	accounts, err := o.GetActiveAccounts()
-	if err != nil {
-		return err
-	}
-
-	for _, account := range accounts {
-		err := o.GenerateCredentialReportForAccount(*account.Id)
-		if err != nil {
-			fmt.Printf("warning: could not generate credentials report for account %s\n\twarning: %s\n", *account.Id, err)
-			continue
-		}
-	}
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		return o.GetUsersForAccount(ctx, *account.Id)
+	})
 
-	for _, account := range accounts {
-		err := o.PrintCredentialReportForAccount(*account.Id)
-		if err != nil {
-			fmt.Printf("warning: could not print credentials report for account %s\n\twarning: %s\n", *account.Id, err)
-			continue
+	users := make(UsersPerAccount)
+	for _, result := range results {
+		if result.Err == nil {
+			users.Set(*result.Account.Id, result.Value.([]*iam.User))
 		}
 	}
-	return nil
+	return users, Errors(results)
 
 }
 
-func (o *Organization) GetUsersForAccount(accountid string) ([]*iam.User, error) {
+func (o *Organization) GetAliasesForAccount(ctx context.Context, accountid string) ([]*string, error) {
 
 	svc, err := o.GetIamSvcForAccount(accountid)
 	if err != nil {
 		return nil, err
 	}
 
-	input := &iam.ListUsersInput{}
-	uresp, err := svc.ListUsers(input)
-
-	if err != nil {
-		// Print the error, cast err to awserr.Error to get the Code and
-		// Message from an error.
-		fmt.Println(err.Error())
-		return nil, err
-	}
-
-	// Pretty-print the response data.
-	// fmt.Println(resp)
-
-	users := make([]*iam.User, 0, 500)
-
-	for _, user := range uresp.Users {
-		users = append(users, user)
-	}
-
-	return users, nil
+	return listAccountAliases(ctx, svc, o.newPageLimit("aliases in account "+accountid))
 
 }
 
-func (o *Organization) GetUsers() (map[string][]*iam.User, error) {
+func listAccountAliases(ctx context.Context, svc iamiface.IAMAPI, limit *pageLimit) ([]*string, error) {
 
-	accounts, err := o.GetActiveAccounts()
+	aliases := make([]*string, 0, 5)
+	err := svc.ListAccountAliasesPagesWithContext(ctx, &iam.ListAccountAliasesInput{}, func(page *iam.ListAccountAliasesOutput, last bool) bool {
+		aliases = append(aliases, page.AccountAliases...)
+		return limit.next(last)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	users := make(UsersPerAccount)
-
-	for _, account := range accounts {
-		lusers, err := o.GetUsersForAccount(*account.Id)
-		if err != nil {
-			fmt.Printf("warning: could not list users for account %s\n\twarning: %s\n", *account.Name, err)
-			continue
-		}
-		users.Set(*account.Id, lusers)
-	}
-	return users, nil
+	return aliases, nil
 
 }
 
-func (o *Organization) PrintUsersForAccount(accountid string) error {
-	users, err := o.GetUsersForAccount(accountid)
-	if err != nil {
-		return err
-	}
-
-	if len(users) == 0 {
-		fmt.Printf("warning: no users found in account %s\n", accountid)
-		return nil
-	}
+func (o *Organization) GetAliases(ctx context.Context, accounts []*organizations.Account) (AliasesPerAccount, AccountErrors) {
 
-	for _, user := range users {
-		fmt.Printf("%s,%s,%s,%s\n", accountid, *user.UserName, user.CreateDate, user.PasswordLastUsed)
-	}
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		return o.GetAliasesForAccount(ctx, *account.Id)
+	})
 
-	return nil
-
-}
-
-func (o *Organization) PrintUsers() error {
-	users, err := o.GetUsers()
-	if err != nil {
-		return err
-	}
-
-	for accountid, accountusers := range users {
-		for _, user := range accountusers {
-			fmt.Printf("%s,%s,%s,%s\n", accountid, *user.UserName, user.CreateDate, user.PasswordLastUsed)
+	aliases := make(AliasesPerAccount)
+	for _, result := range results {
+		if result.Err == nil {
+			aliases.Set(*result.Account.Id, result.Value.([]*string))
 		}
 	}
-	return nil
+	return aliases, Errors(results)
 
 }
 
-func (o *Organization) GetAliasesForAccount(accountid string) ([]*string, error) {
-
-	svc, err := o.GetIamSvcForAccount(accountid)
-	if err != nil {
-		return nil, err
-	}
-
-	input := &iam.ListAccountAliasesInput{}
-	aresp, err := svc.ListAccountAliases(input)
-
-	if err != nil {
-		// Print the error, cast err to awserr.Error to get the Code and
-		// Message from an error.
-		fmt.Println(err.Error())
-		return nil, err
-	}
-
-	// Pretty-print the response data.
-	// fmt.Println(resp)
-
-	aliases := make([]*string, 0, 500)
-
-	for _, alias := range aresp.AccountAliases {
-		aliases = append(aliases, alias)
-	}
-
-	return aliases, nil
+var aliasUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
 
+// AliasChange is an account alias an ensure would create, or the reason
+// the account is left alone.
+type AliasChange struct {
+	Account *organizations.Account
+	Alias   string
+	Skip    string
 }
 
-func (o *Organization) GetAliases() (map[string][]*string, error) {
-
-	accounts, err := o.GetActiveAccounts()
-	if err != nil {
-		return nil, err
+// accountAlias turns an account name into a valid iam account alias of
+// lower case letters, digits and hyphens.
+func accountAlias(name string) string {
+	alias := strings.Trim(aliasUnsafe.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(alias) > 63 {
+		alias = strings.TrimRight(alias[:63], "-")
 	}
+	return alias
+}
 
-	aliases := make(AliasesPerAccount)
+// PlanAccountAliases works out the alias to set for every account without
+// one, taken from the organizations account name.
+func PlanAccountAliases(accounts []*organizations.Account, aliases AliasesPerAccount) []*AliasChange {
 
+	changes := make([]*AliasChange, 0, len(accounts))
 	for _, account := range accounts {
-		alii, err := o.GetAliasesForAccount(*account.Id)
-		if err != nil {
-			fmt.Printf("warning: could not list aliases for account %s\n\twarning: %s\n", *account.Name, err)
+		if _, ok := aliases[*account.Id]; !ok {
+			// aliases could not be read, so it is not known to be missing
 			continue
 		}
-		aliases.Set(*account.Id, alii)
-	}
-	return aliases, nil
-
-}
-
-func (o *Organization) PrintAliases() error {
-	aliases, err := o.GetAliases()
-	if err != nil {
-		return err
-	}
-
-	for accountid, accountaliases := range aliases {
-		for _, alias := range accountaliases {
-			fmt.Printf("%s,%s\n", accountid, *alias)
+		if len(aliases[*account.Id]) > 0 {
+			continue
 		}
+		change := &AliasChange{Account: account, Alias: accountAlias(aws.StringValue(account.Name))}
+		if len(change.Alias) < 3 {
+			change.Skip = "account name does not make a valid alias"
+		}
+		changes = append(changes, change)
 	}
-
-	return nil
+	return changes
 
 }
 
-func (o *Organization) PrintAliasesForAccount(accountid string) error {
-	aliases, err := o.GetAliasesForAccount(accountid)
-	if err != nil {
-		return err
-	}
-
-	if len(aliases) == 0 {
-		fmt.Printf("warning: no aliases found for account %s\n", accountid)
-		return nil
-	}
+// CreateAccountAliases sets the planned aliases. Aliases are unique across
+// all of aws, so one may fail if it is taken.
+func (o *Organization) CreateAccountAliases(ctx context.Context, changes []*AliasChange) AccountErrors {
 
-	for _, alias := range aliases {
-		fmt.Printf("%s,%s\n", accountid, *alias)
+	byAccount := make(map[string]*AliasChange)
+	accounts := make([]*organizations.Account, 0, len(changes))
+	for _, change := range changes {
+		if len(change.Skip) == 0 {
+			byAccount[*change.Account.Id] = change
+			accounts = append(accounts, change.Account)
+		}
 	}
 
-	return nil
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		svc, err := o.GetIamSvcForAccount(*account.Id)
+		if err != nil {
+			return nil, err
+		}
+		_, err = svc.CreateAccountAliasWithContext(ctx, &iam.CreateAccountAliasInput{
+			AccountAlias: aws.String(byAccount[*account.Id].Alias),
+		})
+		return nil, err
+	})
+	return Errors(results)
 
 }
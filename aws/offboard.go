@@ -0,0 +1,354 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+// OffboardUser is an iam user with everything that has to be removed
+// before the user can be deleted.
+type OffboardUser struct {
+	Account             *organizations.Account
+	AccountId           string
+	UserName            string
+	Arn                 string
+	LoginProfile        bool
+	AccessKeys          []string
+	SigningCertificates []string
+	SSHPublicKeys       []string
+	ServiceCredentials  []string
+	MFADevices          []string
+	Groups              []string
+	AttachedPolicies    []string
+	InlinePolicies      []string
+}
+
+// OffboardStep is one api call made to disable or delete a user.
+type OffboardStep struct {
+	Action string
+	Target string
+}
+
+// OffboardRecord is a step taken for a user, written to the offboard report.
+type OffboardRecord struct {
+	Time      time.Time `json:"time"`
+	AccountId string    `json:"account_id"`
+	UserName  string    `json:"user_name"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// FindUsers returns the users matching any of the name patterns in every
+// account, along with their credentials, groups and policies.
+func (o *Organization) FindUsers(ctx context.Context, accounts []*organizations.Account, patterns []string) ([]*OffboardUser, AccountErrors) {
+
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		users, err := o.GetUsersForAccount(ctx, *account.Id)
+		if err != nil {
+			return nil, err
+		}
+		found := make([]*OffboardUser, 0, 5)
+		for _, user := range users {
+			if !matchUser(patterns, aws.StringValue(user.UserName)) {
+				continue
+			}
+			u, err := o.describeOffboardUser(ctx, *account.Id, user)
+			if err != nil {
+				return nil, err
+			}
+			u.Account = account
+			found = append(found, u)
+		}
+		return found, nil
+	})
+
+	users := make([]*OffboardUser, 0, 20)
+	for _, result := range results {
+		if result.Err == nil {
+			users = append(users, result.Value.([]*OffboardUser)...)
+		}
+	}
+	sort.SliceStable(users, func(i, j int) bool { return users[i].AccountId < users[j].AccountId })
+	return users, Errors(results)
+
+}
+
+// matchUser matches user names case insensitively, as iam does.
+func matchUser(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Organization) describeOffboardUser(ctx context.Context, accountid string, user *iam.User) (*OffboardUser, error) {
+
+	svc, err := o.GetIamSvcForAccount(accountid)
+	if err != nil {
+		return nil, err
+	}
+	u := &OffboardUser{
+		AccountId: accountid,
+		UserName:  aws.StringValue(user.UserName),
+		Arn:       aws.StringValue(user.Arn),
+	}
+	name := user.UserName
+	wrap := func(what string, err error) error {
+		return fmt.Errorf("could not list %s of user %s: %s", what, u.UserName, err)
+	}
+
+	_, err = svc.GetLoginProfileWithContext(ctx, &iam.GetLoginProfileInput{UserName: name})
+	if err == nil {
+		u.LoginProfile = true
+	} else if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != iam.ErrCodeNoSuchEntityException {
+		return nil, wrap("login profile", err)
+	}
+
+	limit := func(what string) *pageLimit {
+		return o.newPageLimit(fmt.Sprintf("%s of user %s in account %s", what, u.UserName, accountid))
+	}
+
+	keys := limit("access keys")
+	err = svc.ListAccessKeysPagesWithContext(ctx, &iam.ListAccessKeysInput{UserName: name}, func(page *iam.ListAccessKeysOutput, last bool) bool {
+		for _, key := range page.AccessKeyMetadata {
+			u.AccessKeys = append(u.AccessKeys, aws.StringValue(key.AccessKeyId))
+		}
+		return keys.next(last)
+	})
+	if err != nil {
+		return nil, wrap("access keys", err)
+	}
+	certs := limit("signing certificates")
+	err = svc.ListSigningCertificatesPagesWithContext(ctx, &iam.ListSigningCertificatesInput{UserName: name}, func(page *iam.ListSigningCertificatesOutput, last bool) bool {
+		for _, cert := range page.Certificates {
+			u.SigningCertificates = append(u.SigningCertificates, aws.StringValue(cert.CertificateId))
+		}
+		return certs.next(last)
+	})
+	if err != nil {
+		return nil, wrap("signing certificates", err)
+	}
+	sshKeys := limit("ssh public keys")
+	err = svc.ListSSHPublicKeysPagesWithContext(ctx, &iam.ListSSHPublicKeysInput{UserName: name}, func(page *iam.ListSSHPublicKeysOutput, last bool) bool {
+		for _, key := range page.SSHPublicKeys {
+			u.SSHPublicKeys = append(u.SSHPublicKeys, aws.StringValue(key.SSHPublicKeyId))
+		}
+		return sshKeys.next(last)
+	})
+	if err != nil {
+		return nil, wrap("ssh public keys", err)
+	}
+	// service specific credentials are not paged
+	creds, err := svc.ListServiceSpecificCredentialsWithContext(ctx, &iam.ListServiceSpecificCredentialsInput{UserName: name})
+	if err != nil {
+		return nil, wrap("service specific credentials", err)
+	}
+	for _, cred := range creds.ServiceSpecificCredentials {
+		u.ServiceCredentials = append(u.ServiceCredentials, aws.StringValue(cred.ServiceSpecificCredentialId))
+	}
+	mfa := limit("mfa devices")
+	err = svc.ListMFADevicesPagesWithContext(ctx, &iam.ListMFADevicesInput{UserName: name}, func(page *iam.ListMFADevicesOutput, last bool) bool {
+		for _, device := range page.MFADevices {
+			u.MFADevices = append(u.MFADevices, aws.StringValue(device.SerialNumber))
+		}
+		return mfa.next(last)
+	})
+	if err != nil {
+		return nil, wrap("mfa devices", err)
+	}
+
+	groups := limit("groups")
+	err = svc.ListGroupsForUserPagesWithContext(ctx, &iam.ListGroupsForUserInput{UserName: name}, func(page *iam.ListGroupsForUserOutput, last bool) bool {
+		for _, group := range page.Groups {
+			u.Groups = append(u.Groups, aws.StringValue(group.GroupName))
+		}
+		return groups.next(last)
+	})
+	if err != nil {
+		return nil, wrap("groups", err)
+	}
+	attached := limit("attached policies")
+	err = svc.ListAttachedUserPoliciesPagesWithContext(ctx, &iam.ListAttachedUserPoliciesInput{UserName: name}, func(page *iam.ListAttachedUserPoliciesOutput, last bool) bool {
+		for _, policy := range page.AttachedPolicies {
+			u.AttachedPolicies = append(u.AttachedPolicies, aws.StringValue(policy.PolicyArn))
+		}
+		return attached.next(last)
+	})
+	if err != nil {
+		return nil, wrap("attached policies", err)
+	}
+	inline := limit("inline policies")
+	err = svc.ListUserPoliciesPagesWithContext(ctx, &iam.ListUserPoliciesInput{UserName: name}, func(page *iam.ListUserPoliciesOutput, last bool) bool {
+		u.InlinePolicies = append(u.InlinePolicies, aws.StringValueSlice(page.PolicyNames)...)
+		return inline.next(last)
+	})
+	if err != nil {
+		return nil, wrap("inline policies", err)
+	}
+	return u, nil
+
+}
+
+// OffboardSteps lists the calls that disable a user, or delete it if
+// remove is set. A user can only be deleted once its credentials, mfa
+// devices, groups and policies are gone, so those come first.
+func OffboardSteps(u *OffboardUser, remove bool) []*OffboardStep {
+
+	steps := make([]*OffboardStep, 0, 20)
+	add := func(action string, targets ...string) {
+		for _, target := range targets {
+			steps = append(steps, &OffboardStep{Action: action, Target: target})
+		}
+	}
+
+	if u.LoginProfile {
+		add("DeleteLoginProfile", u.UserName)
+	}
+	if !remove {
+		add("DeactivateAccessKey", u.AccessKeys...)
+		add("DeactivateSigningCertificate", u.SigningCertificates...)
+		add("DeactivateSSHPublicKey", u.SSHPublicKeys...)
+		add("DeactivateServiceSpecificCredential", u.ServiceCredentials...)
+		return steps
+	}
+
+	add("DeleteAccessKey", u.AccessKeys...)
+	add("DeleteSigningCertificate", u.SigningCertificates...)
+	add("DeleteSSHPublicKey", u.SSHPublicKeys...)
+	add("DeleteServiceSpecificCredential", u.ServiceCredentials...)
+	add("DeactivateMFADevice", u.MFADevices...)
+	for _, serial := range u.MFADevices {
+		// hardware devices and security keys are only deactivated, virtual
+		// devices have an :mfa/ arn, security keys a :u2f/ arn
+		if strings.HasPrefix(serial, "arn:") && strings.Contains(serial, ":mfa/") {
+			add("DeleteVirtualMFADevice", serial)
+		}
+	}
+	add("RemoveUserFromGroup", u.Groups...)
+	add("DetachUserPolicy", u.AttachedPolicies...)
+	add("DeleteUserPolicy", u.InlinePolicies...)
+	add("DeleteUser", u.UserName)
+	return steps
+
+}
+
+// OffboardUsers disables or deletes users. The steps for a user stop at
+// the first failure. Every step taken is written to report as a line of
+// json and returned.
+func (o *Organization) OffboardUsers(ctx context.Context, users []*OffboardUser, remove bool, report io.Writer) ([]*OffboardRecord, AccountErrors) {
+
+	byAccount := make(map[string][]*OffboardUser)
+	accounts := make([]*organizations.Account, 0, 20)
+	for _, u := range users {
+		if _, ok := byAccount[u.AccountId]; !ok {
+			accounts = append(accounts, accountOrId(u.Account, u.AccountId))
+		}
+		byAccount[u.AccountId] = append(byAccount[u.AccountId], u)
+	}
+
+	var mu sync.Mutex
+	records := make([]*OffboardRecord, 0, 100)
+	record := func(u *OffboardUser, step *OffboardStep, err error) {
+		r := &OffboardRecord{
+			Time:      time.Now().UTC(),
+			AccountId: u.AccountId,
+			UserName:  u.UserName,
+			Action:    step.Action,
+			Target:    step.Target,
+		}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		line, _ := json.Marshal(r)
+		mu.Lock()
+		records = append(records, r)
+		fmt.Fprintf(report, "%s\n", line)
+		mu.Unlock()
+	}
+
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		svc, err := o.GetIamSvcForAccount(*account.Id)
+		if err != nil {
+			return nil, err
+		}
+		failed := 0
+		for _, u := range byAccount[*account.Id] {
+			for _, step := range OffboardSteps(u, remove) {
+				err := offboardStep(ctx, svc, u.UserName, step)
+				record(u, step, err)
+				if err != nil {
+					failed++
+					break
+				}
+			}
+		}
+		if failed > 0 {
+			return nil, fmt.Errorf("could not offboard %d users, see the report", failed)
+		}
+		return nil, nil
+	})
+
+	sort.SliceStable(records, func(i, j int) bool { return records[i].AccountId < records[j].AccountId })
+	return records, Errors(results)
+
+}
+
+func offboardStep(ctx context.Context, svc iamiface.IAMAPI, user string, step *OffboardStep) error {
+
+	name := aws.String(user)
+	target := aws.String(step.Target)
+	inactive := aws.String(iam.StatusTypeInactive)
+	var err error
+	switch step.Action {
+	case "DeleteLoginProfile":
+		_, err = svc.DeleteLoginProfileWithContext(ctx, &iam.DeleteLoginProfileInput{UserName: name})
+	case "DeactivateAccessKey":
+		_, err = svc.UpdateAccessKeyWithContext(ctx, &iam.UpdateAccessKeyInput{UserName: name, AccessKeyId: target, Status: inactive})
+	case "DeactivateSigningCertificate":
+		_, err = svc.UpdateSigningCertificateWithContext(ctx, &iam.UpdateSigningCertificateInput{UserName: name, CertificateId: target, Status: inactive})
+	case "DeactivateSSHPublicKey":
+		_, err = svc.UpdateSSHPublicKeyWithContext(ctx, &iam.UpdateSSHPublicKeyInput{UserName: name, SSHPublicKeyId: target, Status: inactive})
+	case "DeactivateServiceSpecificCredential":
+		_, err = svc.UpdateServiceSpecificCredentialWithContext(ctx, &iam.UpdateServiceSpecificCredentialInput{UserName: name, ServiceSpecificCredentialId: target, Status: inactive})
+	case "DeleteAccessKey":
+		_, err = svc.DeleteAccessKeyWithContext(ctx, &iam.DeleteAccessKeyInput{UserName: name, AccessKeyId: target})
+	case "DeleteSigningCertificate":
+		_, err = svc.DeleteSigningCertificateWithContext(ctx, &iam.DeleteSigningCertificateInput{UserName: name, CertificateId: target})
+	case "DeleteSSHPublicKey":
+		_, err = svc.DeleteSSHPublicKeyWithContext(ctx, &iam.DeleteSSHPublicKeyInput{UserName: name, SSHPublicKeyId: target})
+	case "DeleteServiceSpecificCredential":
+		_, err = svc.DeleteServiceSpecificCredentialWithContext(ctx, &iam.DeleteServiceSpecificCredentialInput{UserName: name, ServiceSpecificCredentialId: target})
+	case "DeactivateMFADevice":
+		_, err = svc.DeactivateMFADeviceWithContext(ctx, &iam.DeactivateMFADeviceInput{UserName: name, SerialNumber: target})
+	case "DeleteVirtualMFADevice":
+		_, err = svc.DeleteVirtualMFADeviceWithContext(ctx, &iam.DeleteVirtualMFADeviceInput{SerialNumber: target})
+	case "RemoveUserFromGroup":
+		_, err = svc.RemoveUserFromGroupWithContext(ctx, &iam.RemoveUserFromGroupInput{UserName: name, GroupName: target})
+	case "DetachUserPolicy":
+		_, err = svc.DetachUserPolicyWithContext(ctx, &iam.DetachUserPolicyInput{UserName: name, PolicyArn: target})
+	case "DeleteUserPolicy":
+		_, err = svc.DeleteUserPolicyWithContext(ctx, &iam.DeleteUserPolicyInput{UserName: name, PolicyName: target})
+	case "DeleteUser":
+		_, err = svc.DeleteUserWithContext(ctx, &iam.DeleteUserInput{UserName: name})
+	default:
+		err = fmt.Errorf("unknown offboard action %s", step.Action)
+	}
+	return err
+
+}
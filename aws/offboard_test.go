@@ -0,0 +1,84 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestOffboardSteps(t *testing.T) {
+
+	user := &OffboardUser{
+		AccountId:        "222222222222",
+		UserName:         "jdoe",
+		LoginProfile:     true,
+		AccessKeys:       []string{"AKIAONE"},
+		MFADevices:       []string{"arn:aws:iam::222222222222:mfa/jdoe", "GAHT12345678", "arn:aws:iam::222222222222:u2f/user/jdoe/yubikey-ABCDEFGHIJKLMNOPQRSTUVWXYZ"},
+		Groups:           []string{"developers"},
+		AttachedPolicies: []string{"arn:aws:iam::aws:policy/ReadOnlyAccess"},
+		InlinePolicies:   []string{"extra"},
+	}
+
+	actions := func(steps []*OffboardStep) []string {
+		names := make([]string, 0, len(steps))
+		for _, step := range steps {
+			names = append(names, step.Action+" "+step.Target)
+		}
+		return names
+	}
+
+	tests := []struct {
+		remove bool
+		want   []string
+	}{
+		{false, []string{
+			"DeleteLoginProfile jdoe",
+			"DeactivateAccessKey AKIAONE",
+		}},
+		{true, []string{
+			"DeleteLoginProfile jdoe",
+			"DeleteAccessKey AKIAONE",
+			"DeactivateMFADevice arn:aws:iam::222222222222:mfa/jdoe",
+			"DeactivateMFADevice GAHT12345678",
+			"DeactivateMFADevice arn:aws:iam::222222222222:u2f/user/jdoe/yubikey-ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+			"DeleteVirtualMFADevice arn:aws:iam::222222222222:mfa/jdoe",
+			"RemoveUserFromGroup developers",
+			"DetachUserPolicy arn:aws:iam::aws:policy/ReadOnlyAccess",
+			"DeleteUserPolicy extra",
+			"DeleteUser jdoe",
+		}},
+	}
+	for _, test := range tests {
+		got := actions(OffboardSteps(user, test.remove))
+		if len(got) != len(test.want) {
+			t.Fatalf("remove %t: OffboardSteps returned %v, expected %v", test.remove, got, test.want)
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("remove %t: step %d is %q, expected %q", test.remove, i, got[i], test.want[i])
+			}
+		}
+	}
+
+	if !matchUser([]string{"JDoe*"}, "jdoe-admin") || matchUser([]string{"jdoe"}, "jdoe-admin") {
+		t.Errorf("matchUser should match patterns case insensitively and in full")
+	}
+
+}
+
+func TestOffboardUsersAccountErrors(t *testing.T) {
+
+	org, _, err := newMockClientOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	// the master account role cannot be assumed
+	user := &OffboardUser{Account: mockAccounts(t, org, "111111111111")[0], AccountId: "111111111111", UserName: "jdoe"}
+	var report bytes.Buffer
+	records, errs := org.OffboardUsers(context.Background(), []*OffboardUser{user}, false, &report)
+	if len(records) != 0 || len(errs) != 1 || errs[0].AccountName != "master" {
+		t.Errorf("OffboardUsers returned %d records and errors %v, expected an error naming the master account", len(records), errs)
+	}
+
+}
@@ -1,8 +1,13 @@
 package aws
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/organizations"
 	"github.com/aws/aws-sdk-go/service/organizations/organizationsiface"
@@ -10,38 +15,91 @@ import (
 
 type Organization struct {
 	svc      organizationsiface.OrganizationsAPI
+	log      io.Writer
+	config   *Config
+	sess     *session.Session
+	sessions map[string]*session.Session
+	clients  ClientFactory
+	mu       sync.Mutex
 	accounts []*organizations.Account
 	regions  []string
 	region   string
 }
 
 func NewOrganization() (*Organization, error) {
+	return NewOrganizationWithConfig(DefaultConfig())
+}
+
+func NewOrganizationWithConfig(c *Config) (*Organization, error) {
+
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
 
-	region := "us-east-1"
-	config := aws.NewConfig().WithRegion(region)
+	// sessions start in the region of the organizations service, every
+	// service client made from them resolves its endpoint in the partition
+	region := partitions[c.Partition].region
+	config := aws.NewConfig().WithRegion(region).WithEndpointResolver(endpointResolver(c))
+	if len(c.Endpoint) > 0 || len(c.Endpoints) > 0 {
+		config = config.WithS3ForcePathStyle(true)
+	}
 	sess := session.New(config)
+
+	// walk the role chain to reach the organization master, Validate has
+	// already held the session duration to the hour iam allows a chain
+	for i, arn := range c.RoleChain {
+		creds := stscreds.NewCredentials(sess, arn, func(p *stscreds.AssumeRoleProvider) {
+			p.RoleSessionName = fmt.Sprintf("%s-chain-%d", c.SessionPrefix, i)
+			p.Duration = c.SessionDuration
+			p.ExpiryWindow = roleExpiryWindow
+		})
+		sess = sess.Copy(aws.NewConfig().WithCredentials(creds))
+	}
+
 	svc := organizations.New(sess)
 	accounts := make([]*organizations.Account, 0, 100)
 	regions := make([]string, 0, 20)
 
-	return &Organization{
+	o := &Organization{
 		svc:      svc,
+		log:      os.Stderr,
+		config:   c,
+		sess:     sess,
+		sessions: make(map[string]*session.Session),
 		accounts: accounts,
 		regions:  regions,
 		region:   region,
-	}, nil
+	}
+	o.clients = &sessionClients{o: o}
+	return o, nil
 
 }
 
 func (o *Organization) GetRegions() []string {
 
+	// called from many account workers at once
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
 	if len(o.regions) == 0 {
-		p := endpoints.AwsPartition()
-		for id := range p.Regions() {
-			o.regions = append(o.regions, id)
-		}
+		o.regions = partitionRegions(o.config.Partition)
 	}
 
 	return o.regions
 
 }
+
+// Config returns the settings the organization was created with.
+func (o *Organization) Config() *Config {
+	return o.config
+}
+
+// SetLogOutput sets where progress messages are written, stderr by default.
+func (o *Organization) SetLogOutput(w io.Writer) {
+	o.log = w
+}
+
+func (o *Organization) logf(format string, args ...interface{}) {
+	fmt.Fprintf(o.log, format, args...)
+}
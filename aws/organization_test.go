@@ -1,7 +1,12 @@
 package aws
 
 import (
+	"fmt"
+	"sort"
   "testing"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/organizations"
 	"github.com/aws/aws-sdk-go/service/organizations/organizationsiface"
 )
 
@@ -9,10 +14,23 @@ var (
   testRegions []string
 )
 
+// mockOrganizationsSvc is an in memory organization. List calls return
+// pages of mockPageSize items so that callers must follow NextToken.
 type mockOrganizationsSvc struct {
     organizationsiface.OrganizationsAPI
+	root     *organizations.Root
+	ous      map[string]*organizations.OrganizationalUnit
+	parents  map[string]string
+	accounts []*organizations.Account
+	policies map[string]*organizations.Policy
+	attached map[string][]string
+	tags     map[string]map[string]string
+	creating map[string]*organizations.CreateAccountStatus
+	nextId   int
 }
 
+const mockPageSize = 2
+
 func init() {
 
 	testRegions = []string{
@@ -41,6 +59,405 @@ func (o *Organization) SetRegions(regions []string)  {
   o.regions = regions
 }
 
+// newMockOrganizationsSvc returns the test organization:
+//
+//	/                 111111111111 master, 555555555555 old (suspended)
+//	/Workloads
+//	/Workloads/Prod   222222222222 prod-app
+//	/Workloads/Dev    333333333333 dev-app
+//	/Security         444444444444 audit
+//
+// FullAWSAccess is attached to the root and DenyLeave to /Workloads.
+func newMockOrganizationsSvc() *mockOrganizationsSvc {
+
+	svc := &mockOrganizationsSvc{
+		root:     &organizations.Root{Id: aws.String("r-root"), Name: aws.String("Root")},
+		ous:      make(map[string]*organizations.OrganizationalUnit),
+		parents:  make(map[string]string),
+		policies: make(map[string]*organizations.Policy),
+		attached: make(map[string][]string),
+		tags:     make(map[string]map[string]string),
+		creating: make(map[string]*organizations.CreateAccountStatus),
+	}
+	svc.addOU("ou-work", "Workloads", "r-root")
+	svc.addOU("ou-prod", "Prod", "ou-work")
+	svc.addOU("ou-dev", "Dev", "ou-work")
+	svc.addOU("ou-sec", "Security", "r-root")
+	svc.addAccount("111111111111", "master", "ACTIVE", "r-root")
+	svc.addAccount("222222222222", "prod-app", "ACTIVE", "ou-prod")
+	svc.addAccount("333333333333", "dev-app", "ACTIVE", "ou-dev")
+	svc.addAccount("444444444444", "audit", "ACTIVE", "ou-sec")
+	svc.addAccount("555555555555", "old", "SUSPENDED", "r-root")
+	svc.tags["222222222222"] = map[string]string{"env": "prod", "owner": "payments"}
+	svc.tags["333333333333"] = map[string]string{"env": "dev", "owner": "payments"}
+	svc.tags["444444444444"] = map[string]string{"env": "prod", "owner": "security"}
+	svc.addPolicy("p-full", "FullAWSAccess", `{"Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`, "r-root")
+	svc.addPolicy("p-deny", "DenyLeave", `{"Statement":[{"Effect":"Deny","Action":"organizations:LeaveOrganization","Resource":"*"}]}`, "ou-work")
+	return svc
+
+}
+
+func (m *mockOrganizationsSvc) addOU(id string, name string, parent string) {
+	m.ous[id] = &organizations.OrganizationalUnit{Id: aws.String(id), Name: aws.String(name)}
+	m.parents[id] = parent
+}
+
+func (m *mockOrganizationsSvc) addAccount(id string, name string, status string, parent string) {
+	m.accounts = append(m.accounts, &organizations.Account{
+		Id:     aws.String(id),
+		Name:   aws.String(name),
+		Email:  aws.String(name + "@example.com"),
+		Status: aws.String(status),
+	})
+	m.parents[id] = parent
+}
+
+func (m *mockOrganizationsSvc) addPolicy(id string, name string, content string, target string) {
+	m.policies[id] = &organizations.Policy{
+		Content: aws.String(content),
+		PolicySummary: &organizations.PolicySummary{
+			Id:   aws.String(id),
+			Name: aws.String(name),
+			Type: aws.String("SERVICE_CONTROL_POLICY"),
+		},
+	}
+	m.attached[target] = append(m.attached[target], id)
+}
+
+func (m *mockOrganizationsSvc) account(id string) *organizations.Account {
+	for _, account := range m.accounts {
+		if *account.Id == id {
+			return account
+		}
+	}
+	return nil
+}
+
+// page returns the slice bounds for the page starting at token.
+func page(token *string, n int) (int, int, *string) {
+	start := 0
+	if token != nil {
+		fmt.Sscanf(*token, "%d", &start)
+	}
+	end := start + mockPageSize
+	if end >= n {
+		return start, n, nil
+	}
+	return start, end, aws.String(fmt.Sprintf("%d", end))
+}
+
+// The Pages methods follow NextToken over the paged list methods the way the
+// sdk does, stopping early when fn returns false.
+
+func (m *mockOrganizationsSvc) ListAccountsPages(input *organizations.ListAccountsInput, fn func(*organizations.ListAccountsOutput, bool) bool) error {
+	params := *input
+	for {
+		resp, err := m.ListAccounts(&params)
+		if err != nil {
+			return err
+		}
+		if !fn(resp, resp.NextToken == nil) || resp.NextToken == nil {
+			return nil
+		}
+		params.NextToken = resp.NextToken
+	}
+}
+
+func (m *mockOrganizationsSvc) ListAccountsForParentPages(input *organizations.ListAccountsForParentInput, fn func(*organizations.ListAccountsForParentOutput, bool) bool) error {
+	params := *input
+	for {
+		resp, err := m.ListAccountsForParent(&params)
+		if err != nil {
+			return err
+		}
+		if !fn(resp, resp.NextToken == nil) || resp.NextToken == nil {
+			return nil
+		}
+		params.NextToken = resp.NextToken
+	}
+}
+
+func (m *mockOrganizationsSvc) ListOrganizationalUnitsForParentPages(input *organizations.ListOrganizationalUnitsForParentInput, fn func(*organizations.ListOrganizationalUnitsForParentOutput, bool) bool) error {
+	params := *input
+	for {
+		resp, err := m.ListOrganizationalUnitsForParent(&params)
+		if err != nil {
+			return err
+		}
+		if !fn(resp, resp.NextToken == nil) || resp.NextToken == nil {
+			return nil
+		}
+		params.NextToken = resp.NextToken
+	}
+}
+
+func (m *mockOrganizationsSvc) ListPoliciesPages(input *organizations.ListPoliciesInput, fn func(*organizations.ListPoliciesOutput, bool) bool) error {
+	params := *input
+	for {
+		resp, err := m.ListPolicies(&params)
+		if err != nil {
+			return err
+		}
+		if !fn(resp, resp.NextToken == nil) || resp.NextToken == nil {
+			return nil
+		}
+		params.NextToken = resp.NextToken
+	}
+}
+
+func (m *mockOrganizationsSvc) ListPoliciesForTargetPages(input *organizations.ListPoliciesForTargetInput, fn func(*organizations.ListPoliciesForTargetOutput, bool) bool) error {
+	params := *input
+	for {
+		resp, err := m.ListPoliciesForTarget(&params)
+		if err != nil {
+			return err
+		}
+		if !fn(resp, resp.NextToken == nil) || resp.NextToken == nil {
+			return nil
+		}
+		params.NextToken = resp.NextToken
+	}
+}
+
+func (m *mockOrganizationsSvc) ListTagsForResourcePagesWithContext(ctx aws.Context, input *organizations.ListTagsForResourceInput, fn func(*organizations.ListTagsForResourceOutput, bool) bool, opts ...request.Option) error {
+	params := *input
+	for {
+		resp, err := m.ListTagsForResource(&params)
+		if err != nil {
+			return err
+		}
+		if !fn(resp, resp.NextToken == nil) || resp.NextToken == nil {
+			return nil
+		}
+		params.NextToken = resp.NextToken
+	}
+}
+
+func (m *mockOrganizationsSvc) ListRoots(input *organizations.ListRootsInput) (*organizations.ListRootsOutput, error) {
+	return &organizations.ListRootsOutput{Roots: []*organizations.Root{m.root}}, nil
+}
+
+func (m *mockOrganizationsSvc) ListAccounts(input *organizations.ListAccountsInput) (*organizations.ListAccountsOutput, error) {
+	start, end, next := page(input.NextToken, len(m.accounts))
+	return &organizations.ListAccountsOutput{Accounts: m.accounts[start:end], NextToken: next}, nil
+}
+
+func (m *mockOrganizationsSvc) ListAccountsForParent(input *organizations.ListAccountsForParentInput) (*organizations.ListAccountsForParentOutput, error) {
+	accounts := make([]*organizations.Account, 0)
+	for _, account := range m.accounts {
+		if m.parents[*account.Id] == *input.ParentId {
+			accounts = append(accounts, account)
+		}
+	}
+	start, end, next := page(input.NextToken, len(accounts))
+	return &organizations.ListAccountsForParentOutput{Accounts: accounts[start:end], NextToken: next}, nil
+}
+
+func (m *mockOrganizationsSvc) ListOrganizationalUnitsForParent(input *organizations.ListOrganizationalUnitsForParentInput) (*organizations.ListOrganizationalUnitsForParentOutput, error) {
+	ids := make([]string, 0)
+	for id := range m.ous {
+		if m.parents[id] == *input.ParentId {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	ous := make([]*organizations.OrganizationalUnit, 0)
+	for _, id := range ids {
+		ous = append(ous, m.ous[id])
+	}
+	start, end, next := page(input.NextToken, len(ous))
+	return &organizations.ListOrganizationalUnitsForParentOutput{OrganizationalUnits: ous[start:end], NextToken: next}, nil
+}
+
+func (m *mockOrganizationsSvc) ListParents(input *organizations.ListParentsInput) (*organizations.ListParentsOutput, error) {
+	parent, ok := m.parents[*input.ChildId]
+	if !ok {
+		return nil, fmt.Errorf("ChildNotFoundException: %s", *input.ChildId)
+	}
+	kind := "ORGANIZATIONAL_UNIT"
+	if parent == *m.root.Id {
+		kind = "ROOT"
+	}
+	return &organizations.ListParentsOutput{
+		Parents: []*organizations.Parent{{Id: aws.String(parent), Type: aws.String(kind)}},
+	}, nil
+}
+
+func (m *mockOrganizationsSvc) CreateOrganizationalUnit(input *organizations.CreateOrganizationalUnitInput) (*organizations.CreateOrganizationalUnitOutput, error) {
+	m.nextId++
+	id := fmt.Sprintf("ou-new%d", m.nextId)
+	m.addOU(id, *input.Name, *input.ParentId)
+	return &organizations.CreateOrganizationalUnitOutput{OrganizationalUnit: m.ous[id]}, nil
+}
+
+func (m *mockOrganizationsSvc) UpdateOrganizationalUnit(input *organizations.UpdateOrganizationalUnitInput) (*organizations.UpdateOrganizationalUnitOutput, error) {
+	ou, ok := m.ous[*input.OrganizationalUnitId]
+	if !ok {
+		return nil, fmt.Errorf("OrganizationalUnitNotFoundException: %s", *input.OrganizationalUnitId)
+	}
+	ou.Name = input.Name
+	return &organizations.UpdateOrganizationalUnitOutput{OrganizationalUnit: ou}, nil
+}
+
+func (m *mockOrganizationsSvc) DeleteOrganizationalUnit(input *organizations.DeleteOrganizationalUnitInput) (*organizations.DeleteOrganizationalUnitOutput, error) {
+	id := *input.OrganizationalUnitId
+	for _, parent := range m.parents {
+		if parent == id {
+			return nil, fmt.Errorf("OrganizationalUnitNotEmptyException: %s", id)
+		}
+	}
+	delete(m.ous, id)
+	delete(m.parents, id)
+	return &organizations.DeleteOrganizationalUnitOutput{}, nil
+}
+
+func (m *mockOrganizationsSvc) MoveAccount(input *organizations.MoveAccountInput) (*organizations.MoveAccountOutput, error) {
+	if m.parents[*input.AccountId] != *input.SourceParentId {
+		return nil, fmt.Errorf("SourceParentNotFoundException: %s", *input.SourceParentId)
+	}
+	m.parents[*input.AccountId] = *input.DestinationParentId
+	return &organizations.MoveAccountOutput{}, nil
+}
+
+func (m *mockOrganizationsSvc) DescribeOrganizationalUnit(input *organizations.DescribeOrganizationalUnitInput) (*organizations.DescribeOrganizationalUnitOutput, error) {
+	ou, ok := m.ous[*input.OrganizationalUnitId]
+	if !ok {
+		return nil, fmt.Errorf("OrganizationalUnitNotFoundException: %s", *input.OrganizationalUnitId)
+	}
+	return &organizations.DescribeOrganizationalUnitOutput{OrganizationalUnit: ou}, nil
+}
+
+func (m *mockOrganizationsSvc) ListPolicies(input *organizations.ListPoliciesInput) (*organizations.ListPoliciesOutput, error) {
+	ids := make([]string, 0)
+	for id := range m.policies {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	policies := make([]*organizations.PolicySummary, 0)
+	for _, id := range ids {
+		policies = append(policies, m.policies[id].PolicySummary)
+	}
+	start, end, next := page(input.NextToken, len(policies))
+	return &organizations.ListPoliciesOutput{Policies: policies[start:end], NextToken: next}, nil
+}
+
+func (m *mockOrganizationsSvc) DescribePolicy(input *organizations.DescribePolicyInput) (*organizations.DescribePolicyOutput, error) {
+	policy, ok := m.policies[*input.PolicyId]
+	if !ok {
+		return nil, fmt.Errorf("PolicyNotFoundException: %s", *input.PolicyId)
+	}
+	return &organizations.DescribePolicyOutput{Policy: policy}, nil
+}
+
+func (m *mockOrganizationsSvc) CreatePolicy(input *organizations.CreatePolicyInput) (*organizations.CreatePolicyOutput, error) {
+	m.nextId++
+	id := fmt.Sprintf("p-new%d", m.nextId)
+	m.addPolicy(id, *input.Name, *input.Content, "")
+	delete(m.attached, "")
+	return &organizations.CreatePolicyOutput{Policy: m.policies[id]}, nil
+}
+
+func (m *mockOrganizationsSvc) AttachPolicy(input *organizations.AttachPolicyInput) (*organizations.AttachPolicyOutput, error) {
+	if _, ok := m.policies[*input.PolicyId]; !ok {
+		return nil, fmt.Errorf("PolicyNotFoundException: %s", *input.PolicyId)
+	}
+	for _, id := range m.attached[*input.TargetId] {
+		if id == *input.PolicyId {
+			return nil, fmt.Errorf("DuplicatePolicyAttachmentException: %s", id)
+		}
+	}
+	m.attached[*input.TargetId] = append(m.attached[*input.TargetId], *input.PolicyId)
+	return &organizations.AttachPolicyOutput{}, nil
+}
+
+func (m *mockOrganizationsSvc) DetachPolicy(input *organizations.DetachPolicyInput) (*organizations.DetachPolicyOutput, error) {
+	ids := m.attached[*input.TargetId]
+	for i, id := range ids {
+		if id == *input.PolicyId {
+			m.attached[*input.TargetId] = append(ids[:i], ids[i+1:]...)
+			return &organizations.DetachPolicyOutput{}, nil
+		}
+	}
+	return nil, fmt.Errorf("PolicyNotAttachedException: %s", *input.PolicyId)
+}
+
+func (m *mockOrganizationsSvc) ListPoliciesForTarget(input *organizations.ListPoliciesForTargetInput) (*organizations.ListPoliciesForTargetOutput, error) {
+	policies := make([]*organizations.PolicySummary, 0)
+	for _, id := range m.attached[*input.TargetId] {
+		policies = append(policies, m.policies[id].PolicySummary)
+	}
+	start, end, next := page(input.NextToken, len(policies))
+	return &organizations.ListPoliciesForTargetOutput{Policies: policies[start:end], NextToken: next}, nil
+}
+
+// CreateAccount adds the account straight away, DescribeCreateAccountStatus
+// then reports it as SUCCEEDED.
+func (m *mockOrganizationsSvc) CreateAccount(input *organizations.CreateAccountInput) (*organizations.CreateAccountOutput, error) {
+	m.nextId++
+	id := fmt.Sprintf("%012d", 900000000000+m.nextId)
+	m.addAccount(id, *input.AccountName, "ACTIVE", *m.root.Id)
+	m.account(id).Email = input.Email
+	for _, tag := range input.Tags {
+		if m.tags[id] == nil {
+			m.tags[id] = make(map[string]string)
+		}
+		m.tags[id][*tag.Key] = *tag.Value
+	}
+	requestid := fmt.Sprintf("car-%d", m.nextId)
+	m.creating[requestid] = &organizations.CreateAccountStatus{
+		Id:          aws.String(requestid),
+		AccountId:   aws.String(id),
+		AccountName: input.AccountName,
+		State:       aws.String("SUCCEEDED"),
+	}
+	return &organizations.CreateAccountOutput{
+		CreateAccountStatus: &organizations.CreateAccountStatus{
+			Id:          aws.String(requestid),
+			AccountName: input.AccountName,
+			State:       aws.String("IN_PROGRESS"),
+		},
+	}, nil
+}
+
+func (m *mockOrganizationsSvc) DescribeCreateAccountStatus(input *organizations.DescribeCreateAccountStatusInput) (*organizations.DescribeCreateAccountStatusOutput, error) {
+	status, ok := m.creating[*input.CreateAccountRequestId]
+	if !ok {
+		return nil, fmt.Errorf("CreateAccountStatusNotFoundException: %s", *input.CreateAccountRequestId)
+	}
+	return &organizations.DescribeCreateAccountStatusOutput{CreateAccountStatus: status}, nil
+}
+
+func (m *mockOrganizationsSvc) ListTagsForResource(input *organizations.ListTagsForResourceInput) (*organizations.ListTagsForResourceOutput, error) {
+	keys := make([]string, 0)
+	for key := range m.tags[*input.ResourceId] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	tags := make([]*organizations.Tag, 0)
+	for _, key := range keys {
+		tags = append(tags, &organizations.Tag{Key: aws.String(key), Value: aws.String(m.tags[*input.ResourceId][key])})
+	}
+	start, end, next := page(input.NextToken, len(tags))
+	return &organizations.ListTagsForResourceOutput{Tags: tags[start:end], NextToken: next}, nil
+}
+
+func (m *mockOrganizationsSvc) TagResource(input *organizations.TagResourceInput) (*organizations.TagResourceOutput, error) {
+	if m.tags[*input.ResourceId] == nil {
+		m.tags[*input.ResourceId] = make(map[string]string)
+	}
+	for _, tag := range input.Tags {
+		m.tags[*input.ResourceId][*tag.Key] = *tag.Value
+	}
+	return &organizations.TagResourceOutput{}, nil
+}
+
+func (m *mockOrganizationsSvc) UntagResource(input *organizations.UntagResourceInput) (*organizations.UntagResourceOutput, error) {
+	for _, key := range input.TagKeys {
+		delete(m.tags[*input.ResourceId], *key)
+	}
+	return &organizations.UntagResourceOutput{}, nil
+}
+
 func NewMockOrganization() (*Organization, error) {
 
   org, err := NewOrganization()
@@ -48,7 +465,7 @@ func NewMockOrganization() (*Organization, error) {
     return nil, err
 	}
 
-  mockSvc := &mockOrganizationsSvc{}
+  mockSvc := newMockOrganizationsSvc()
 	org.SetSvc(mockSvc)
 	org.SetRegions(testRegions)
 	return org, nil
@@ -75,3 +492,33 @@ func TestGetRegions(t *testing.T) {
 
 
 }
+
+func TestGetActiveAccounts(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	accounts, err := org.GetAccounts()
+	if err != nil {
+		t.Fatalf("organization GetAccounts error: %s", err)
+	}
+	if len(accounts) != 5 {
+		t.Errorf("organization GetAccounts returned %d accounts, expected 5", len(accounts))
+	}
+
+	active, err := org.GetActiveAccounts()
+	if err != nil {
+		t.Fatalf("organization GetActiveAccounts error: %s", err)
+	}
+	if len(active) != 4 {
+		t.Errorf("organization GetActiveAccounts returned %d accounts, expected 4", len(active))
+	}
+	for _, account := range active {
+		if *account.Status != "ACTIVE" {
+			t.Errorf("organization GetActiveAccounts returned %s account %s", *account.Status, *account.Id)
+		}
+	}
+
+}
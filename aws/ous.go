@@ -0,0 +1,278 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+// OrganizationalUnit is a node in the organization tree, either the root
+// or an organizational unit. Paths are made from ou names, eg. /Workloads/Prod,
+// with the root at /.
+type OrganizationalUnit struct {
+	Id       string
+	Name     string
+	Path     string
+	Children []*OrganizationalUnit
+	Accounts []*organizations.Account
+}
+
+func (o *Organization) GetRoot() (*organizations.Root, error) {
+
+	resp, err := o.svc.ListRoots(&organizations.ListRootsInput{})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Roots) == 0 {
+		return nil, fmt.Errorf("organization has no root")
+	}
+	return resp.Roots[0], nil
+
+}
+
+func (o *Organization) getOUsForParent(parentid string) ([]*organizations.OrganizationalUnit, error) {
+
+	params := &organizations.ListOrganizationalUnitsForParentInput{
+		ParentId: aws.String(parentid),
+	}
+	limit := o.newPageLimit("organizational units under " + parentid)
+	ous := make([]*organizations.OrganizationalUnit, 0, 20)
+	err := o.svc.ListOrganizationalUnitsForParentPages(params, func(page *organizations.ListOrganizationalUnitsForParentOutput, last bool) bool {
+		ous = append(ous, page.OrganizationalUnits...)
+		return limit.next(last)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ous, nil
+
+}
+
+func (o *Organization) getAccountsForParent(parentid string) ([]*organizations.Account, error) {
+
+	params := &organizations.ListAccountsForParentInput{
+		ParentId: aws.String(parentid),
+	}
+	limit := o.newPageLimit("accounts under " + parentid)
+	accounts := make([]*organizations.Account, 0, 20)
+	err := o.svc.ListAccountsForParentPages(params, func(page *organizations.ListAccountsForParentOutput, last bool) bool {
+		accounts = append(accounts, page.Accounts...)
+		return limit.next(last)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+
+}
+
+// GetOUTree returns the whole organization tree with the accounts under
+// each organizational unit.
+func (o *Organization) GetOUTree() (*OrganizationalUnit, error) {
+
+	root, err := o.GetRoot()
+	if err != nil {
+		return nil, err
+	}
+	tree := &OrganizationalUnit{
+		Id:   *root.Id,
+		Name: *root.Name,
+		Path: "/",
+	}
+	err = o.fillOUTree(tree)
+	if err != nil {
+		return nil, err
+	}
+	return tree, nil
+
+}
+
+func (o *Organization) fillOUTree(node *OrganizationalUnit) error {
+
+	accounts, err := o.getAccountsForParent(node.Id)
+	if err != nil {
+		return err
+	}
+	node.Accounts = accounts
+
+	ous, err := o.getOUsForParent(node.Id)
+	if err != nil {
+		return err
+	}
+	for _, ou := range ous {
+		child := &OrganizationalUnit{
+			Id:   *ou.Id,
+			Name: *ou.Name,
+			Path: joinOUPath(node.Path, *ou.Name),
+		}
+		err = o.fillOUTree(child)
+		if err != nil {
+			return err
+		}
+		node.Children = append(node.Children, child)
+	}
+	return nil
+
+}
+
+// GetOUByPath looks up a single organizational unit by its path.
+func (o *Organization) GetOUByPath(path string) (*OrganizationalUnit, error) {
+
+	root, err := o.GetRoot()
+	if err != nil {
+		return nil, err
+	}
+	node := &OrganizationalUnit{
+		Id:   *root.Id,
+		Name: *root.Name,
+		Path: "/",
+	}
+
+	for _, name := range splitOUPath(path) {
+		ous, err := o.getOUsForParent(node.Id)
+		if err != nil {
+			return nil, err
+		}
+		var found *organizations.OrganizationalUnit
+		for _, ou := range ous {
+			if *ou.Name == name {
+				found = ou
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("organizational unit %s not found", joinOUPath(node.Path, name))
+		}
+		node = &OrganizationalUnit{
+			Id:   *found.Id,
+			Name: *found.Name,
+			Path: joinOUPath(node.Path, name),
+		}
+	}
+	return node, nil
+
+}
+
+// CreateOU creates the organizational unit at path. Its parent must exist.
+func (o *Organization) CreateOU(path string) (*organizations.OrganizationalUnit, error) {
+
+	names := splitOUPath(path)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("cannot create the organization root")
+	}
+	parent, err := o.GetOUByPath("/" + strings.Join(names[:len(names)-1], "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	input := &organizations.CreateOrganizationalUnitInput{
+		Name:     aws.String(names[len(names)-1]),
+		ParentId: aws.String(parent.Id),
+	}
+	resp, err := o.svc.CreateOrganizationalUnit(input)
+	if err != nil {
+		return nil, err
+	}
+	return resp.OrganizationalUnit, nil
+
+}
+
+func (o *Organization) RenameOU(path string, name string) (*organizations.OrganizationalUnit, error) {
+
+	ou, err := o.GetOUByPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if ou.Path == "/" {
+		return nil, fmt.Errorf("cannot rename the organization root")
+	}
+
+	input := &organizations.UpdateOrganizationalUnitInput{
+		Name:                 aws.String(name),
+		OrganizationalUnitId: aws.String(ou.Id),
+	}
+	resp, err := o.svc.UpdateOrganizationalUnit(input)
+	if err != nil {
+		return nil, err
+	}
+	return resp.OrganizationalUnit, nil
+
+}
+
+// DeleteOU deletes an organizational unit. It must not contain any accounts
+// or other organizational units.
+func (o *Organization) DeleteOU(path string) error {
+
+	ou, err := o.GetOUByPath(path)
+	if err != nil {
+		return err
+	}
+	if ou.Path == "/" {
+		return fmt.Errorf("cannot delete the organization root")
+	}
+
+	input := &organizations.DeleteOrganizationalUnitInput{
+		OrganizationalUnitId: aws.String(ou.Id),
+	}
+	_, err = o.svc.DeleteOrganizationalUnit(input)
+	return err
+
+}
+
+// GetParentId returns the id of the root or organizational unit directly
+// above an account or organizational unit.
+func (o *Organization) GetParentId(childid string) (string, error) {
+
+	resp, err := o.svc.ListParents(&organizations.ListParentsInput{
+		ChildId: aws.String(childid),
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Parents) == 0 {
+		return "", fmt.Errorf("no parent found for %s", childid)
+	}
+	return *resp.Parents[0].Id, nil
+
+}
+
+// MoveAccount moves an account under the organizational unit at path.
+func (o *Organization) MoveAccount(accountid string, path string) error {
+
+	dest, err := o.GetOUByPath(path)
+	if err != nil {
+		return err
+	}
+	source, err := o.GetParentId(accountid)
+	if err != nil {
+		return err
+	}
+	if source == dest.Id {
+		return nil
+	}
+
+	input := &organizations.MoveAccountInput{
+		AccountId:           aws.String(accountid),
+		SourceParentId:      aws.String(source),
+		DestinationParentId: aws.String(dest.Id),
+	}
+	_, err = o.svc.MoveAccount(input)
+	return err
+
+}
+
+func splitOUPath(path string) []string {
+	names := make([]string, 0, 10)
+	for _, name := range strings.Split(path, "/") {
+		if len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func joinOUPath(parent string, name string) string {
+	return strings.TrimSuffix(parent, "/") + "/" + name
+}
@@ -0,0 +1,111 @@
+package aws
+
+import (
+	"testing"
+)
+
+func TestGetOUTree(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	tree, err := org.GetOUTree()
+	if err != nil {
+		t.Fatalf("organization GetOUTree error: %s", err)
+	}
+
+	if tree.Path != "/" || len(tree.Accounts) != 2 || len(tree.Children) != 2 {
+		t.Fatalf("organization GetOUTree root incorrect: %s, %d accounts, %d children", tree.Path, len(tree.Accounts), len(tree.Children))
+	}
+
+	workloads := tree.Children[1]
+	if workloads.Path != "/Workloads" || len(workloads.Children) != 2 {
+		t.Fatalf("organization GetOUTree /Workloads incorrect: %+v", workloads)
+	}
+	prod := workloads.Children[1]
+	if prod.Path != "/Workloads/Prod" || len(prod.Accounts) != 1 || *prod.Accounts[0].Id != "222222222222" {
+		t.Errorf("organization GetOUTree /Workloads/Prod incorrect: %+v", prod)
+	}
+
+}
+
+func TestGetOUByPath(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	tests := []struct {
+		path string
+		id   string
+	}{
+		{"/", "r-root"},
+		{"", "r-root"},
+		{"/Workloads/Dev", "ou-dev"},
+		{"Workloads/Dev/", "ou-dev"},
+		{"/Security", "ou-sec"},
+	}
+	for _, test := range tests {
+		ou, err := org.GetOUByPath(test.path)
+		if err != nil {
+			t.Errorf("organization GetOUByPath %s error: %s", test.path, err)
+			continue
+		}
+		if ou.Id != test.id {
+			t.Errorf("organization GetOUByPath %s returned %s, expected %s", test.path, ou.Id, test.id)
+		}
+	}
+
+	if _, err := org.GetOUByPath("/Workloads/Test"); err == nil {
+		t.Errorf("organization GetOUByPath found a missing organizational unit")
+	}
+
+}
+
+func TestManageOUs(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	if _, err := org.CreateOU("/Workloads/Test"); err != nil {
+		t.Fatalf("organization CreateOU error: %s", err)
+	}
+	if _, err := org.CreateOU("/Sandbox/Test"); err == nil {
+		t.Errorf("organization CreateOU created an organizational unit without a parent")
+	}
+
+	if err := org.MoveAccount("333333333333", "/Workloads/Test"); err != nil {
+		t.Fatalf("organization MoveAccount error: %s", err)
+	}
+	if err := org.DeleteOU("/Workloads/Test"); err == nil {
+		t.Errorf("organization DeleteOU deleted an organizational unit containing an account")
+	}
+
+	if _, err := org.RenameOU("/Workloads/Test", "Staging"); err != nil {
+		t.Fatalf("organization RenameOU error: %s", err)
+	}
+	ou, err := org.GetOUByPath("/Workloads/Staging")
+	if err != nil {
+		t.Fatalf("organization RenameOU did not rename: %s", err)
+	}
+	parent, err := org.GetParentId("333333333333")
+	if err != nil || parent != ou.Id {
+		t.Errorf("organization MoveAccount left the account under %s", parent)
+	}
+
+	if err := org.MoveAccount("333333333333", "/Workloads/Dev"); err != nil {
+		t.Fatalf("organization MoveAccount error: %s", err)
+	}
+	if err := org.DeleteOU("/Workloads/Staging"); err != nil {
+		t.Errorf("organization DeleteOU error: %s", err)
+	}
+	if err := org.DeleteOU("/"); err == nil {
+		t.Errorf("organization DeleteOU deleted the root")
+	}
+
+}
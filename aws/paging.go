@@ -0,0 +1,28 @@
+package aws
+
+// maxListPages stops a list call that keeps returning pages, eg. because a
+// service keeps handing back the same token.
+var maxListPages = 1000
+
+// pageLimit counts the pages of a list call. The pagination helpers stop
+// when the page callback returns false.
+type pageLimit struct {
+	o     *Organization
+	what  string
+	pages int
+}
+
+func (o *Organization) newPageLimit(what string) *pageLimit {
+	return &pageLimit{o: o, what: what}
+}
+
+// next counts a page and returns false once the limit is reached before
+// the last page, warning that the list is incomplete.
+func (p *pageLimit) next(last bool) bool {
+	p.pages++
+	if last || p.pages < maxListPages {
+		return true
+	}
+	p.o.logf("warning: stopped listing %s after %d pages, the list is incomplete\n", p.what, p.pages)
+	return false
+}
@@ -0,0 +1,178 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/cloudfront/cloudfrontiface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+)
+
+// mockIamSvc serves users and aliases in pages of mockPageSize, marking
+// every page but the last as truncated.
+type mockIamSvc struct {
+	iamiface.IAMAPI
+	users   []*iam.User
+	aliases []*string
+	report  string
+	calls   int
+}
+
+func (m *mockIamSvc) ListUsersPagesWithContext(ctx aws.Context, input *iam.ListUsersInput, fn func(*iam.ListUsersOutput, bool) bool, opts ...request.Option) error {
+	marker := input.Marker
+	for {
+		m.calls++
+		start, end, next := page(marker, len(m.users))
+		resp := &iam.ListUsersOutput{Users: m.users[start:end], IsTruncated: aws.Bool(next != nil), Marker: next}
+		if !fn(resp, next == nil) || next == nil {
+			return nil
+		}
+		marker = next
+	}
+}
+
+func (m *mockIamSvc) ListAccountAliasesPagesWithContext(ctx aws.Context, input *iam.ListAccountAliasesInput, fn func(*iam.ListAccountAliasesOutput, bool) bool, opts ...request.Option) error {
+	marker := input.Marker
+	for {
+		m.calls++
+		start, end, next := page(marker, len(m.aliases))
+		resp := &iam.ListAccountAliasesOutput{AccountAliases: m.aliases[start:end], IsTruncated: aws.Bool(next != nil), Marker: next}
+		if !fn(resp, next == nil) || next == nil {
+			return nil
+		}
+		marker = next
+	}
+}
+
+// mockCloudFrontSvc serves distributions in pages of mockPageSize.
+type mockCloudFrontSvc struct {
+	cloudfrontiface.CloudFrontAPI
+	distributions []*cloudfront.DistributionSummary
+}
+
+func (m *mockCloudFrontSvc) ListDistributionsPagesWithContext(ctx aws.Context, input *cloudfront.ListDistributionsInput, fn func(*cloudfront.ListDistributionsOutput, bool) bool, opts ...request.Option) error {
+	marker := input.Marker
+	for {
+		start, end, next := page(marker, len(m.distributions))
+		resp := &cloudfront.ListDistributionsOutput{DistributionList: &cloudfront.DistributionList{
+			Items:       m.distributions[start:end],
+			IsTruncated: aws.Bool(next != nil),
+			NextMarker:  next,
+		}}
+		if !fn(resp, next == nil) || next == nil {
+			return nil
+		}
+		marker = next
+	}
+}
+
+func newMockIamSvc(users int, aliases int) *mockIamSvc {
+	m := &mockIamSvc{}
+	for i := 0; i < users; i++ {
+		m.users = append(m.users, &iam.User{UserName: aws.String(fmt.Sprintf("user%d", i))})
+	}
+	for i := 0; i < aliases; i++ {
+		m.aliases = append(m.aliases, aws.String(fmt.Sprintf("alias%d", i)))
+	}
+	return m
+}
+
+func TestListAllPages(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+	ctx := context.Background()
+
+	for _, n := range []int{0, 1, mockPageSize, mockPageSize*3 + 1} {
+		svc := newMockIamSvc(n, n)
+		users, err := listUsers(ctx, svc, org.newPageLimit("users"))
+		if err != nil {
+			t.Fatalf("listUsers: %s", err)
+		}
+		if len(users) != n {
+			t.Errorf("listUsers returned %d users, expected %d", len(users), n)
+		}
+		for i, user := range users {
+			if *user.UserName != fmt.Sprintf("user%d", i) {
+				t.Errorf("user %d is %s", i, *user.UserName)
+			}
+		}
+
+		aliases, err := listAccountAliases(ctx, svc, org.newPageLimit("aliases"))
+		if err != nil {
+			t.Fatalf("listAccountAliases: %s", err)
+		}
+		if len(aliases) != n {
+			t.Errorf("listAccountAliases returned %d aliases, expected %d", len(aliases), n)
+		}
+
+		cf := &mockCloudFrontSvc{}
+		for i := 0; i < n; i++ {
+			cf.distributions = append(cf.distributions, &cloudfront.DistributionSummary{Id: aws.String(fmt.Sprintf("E%d", i))})
+		}
+		distros, err := listDistributions(ctx, cf, org.newPageLimit("distributions"))
+		if err != nil {
+			t.Fatalf("listDistributions: %s", err)
+		}
+		if len(distros) != n {
+			t.Errorf("listDistributions returned %d distributions, expected %d", len(distros), n)
+		}
+	}
+
+	// organizations lists are paged by the mock organization as well
+	accounts, err := org.GetAccounts()
+	if err != nil {
+		t.Fatalf("GetAccounts: %s", err)
+	}
+	if len(accounts) <= mockPageSize {
+		t.Errorf("expected more than one page of accounts, got %d", len(accounts))
+	}
+
+}
+
+func TestPageLimit(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+	var log bytes.Buffer
+	org.SetLogOutput(&log)
+
+	saved := maxListPages
+	maxListPages = 3
+	defer func() { maxListPages = saved }()
+
+	svc := newMockIamSvc(mockPageSize*10, 0)
+	users, err := listUsers(context.Background(), svc, org.newPageLimit("users in account 111111111111"))
+	if err != nil {
+		t.Fatalf("listUsers: %s", err)
+	}
+	if svc.calls != 3 {
+		t.Errorf("listUsers fetched %d pages, expected 3", svc.calls)
+	}
+	if len(users) != mockPageSize*3 {
+		t.Errorf("listUsers returned %d users, expected %d", len(users), mockPageSize*3)
+	}
+	if !strings.Contains(log.String(), "warning: stopped listing users in account 111111111111 after 3 pages") {
+		t.Errorf("no warning for an incomplete list, got %q", log.String())
+	}
+
+	// reaching the limit on the last page is not a warning
+	log.Reset()
+	svc = newMockIamSvc(mockPageSize*3, 0)
+	users, _ = listUsers(context.Background(), svc, org.newPageLimit("users"))
+	if len(users) != mockPageSize*3 || log.Len() != 0 {
+		t.Errorf("complete list of %d users warned %q", len(users), log.String())
+	}
+
+}
@@ -0,0 +1,154 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+// PasswordPolicy is a baseline iam account password policy. Settings left
+// out of the baseline are not checked.
+type PasswordPolicy iam.UpdateAccountPasswordPolicyInput
+
+// PasswordPolicyDrift is a password policy setting that differs from the
+// baseline.
+type PasswordPolicyDrift struct {
+	AccountId string
+	Setting   string
+	Actual    string
+	Expected  string
+}
+
+// ParsePasswordPolicy reads a baseline password policy from json using
+// the iam field names, eg. {"MinimumPasswordLength": 14}. Unknown names
+// are refused, a misspelt setting would otherwise go unchecked.
+func ParsePasswordPolicy(data []byte) (*PasswordPolicy, error) {
+
+	input := &iam.UpdateAccountPasswordPolicyInput{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(input); err != nil {
+		return nil, fmt.Errorf("could not parse password policy: %s", err)
+	}
+	if *input == (iam.UpdateAccountPasswordPolicyInput{}) {
+		return nil, fmt.Errorf("the password policy baseline has no settings")
+	}
+	if err := input.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid password policy: %s", err)
+	}
+	policy := PasswordPolicy(*input)
+	return &policy, nil
+
+}
+
+// passwordPolicyDrift compares an account password policy with the
+// baseline. A nil policy means the account has none.
+func passwordPolicyDrift(accountid string, actual *iam.PasswordPolicy, baseline *PasswordPolicy) []*PasswordPolicyDrift {
+
+	if actual == nil {
+		actual = &iam.PasswordPolicy{}
+	}
+	drift := make([]*PasswordPolicyDrift, 0, 10)
+	intSetting := func(setting string, actual *int64, expected *int64) {
+		if expected != nil && aws.Int64Value(actual) != *expected {
+			drift = append(drift, &PasswordPolicyDrift{AccountId: accountid, Setting: setting, Actual: optionalInt(actual), Expected: fmt.Sprintf("%d", *expected)})
+		}
+	}
+	boolSetting := func(setting string, actual *bool, expected *bool) {
+		if expected != nil && aws.BoolValue(actual) != *expected {
+			drift = append(drift, &PasswordPolicyDrift{AccountId: accountid, Setting: setting, Actual: optionalBool(actual), Expected: fmt.Sprintf("%t", *expected)})
+		}
+	}
+	intSetting("MinimumPasswordLength", actual.MinimumPasswordLength, baseline.MinimumPasswordLength)
+	boolSetting("RequireSymbols", actual.RequireSymbols, baseline.RequireSymbols)
+	boolSetting("RequireNumbers", actual.RequireNumbers, baseline.RequireNumbers)
+	boolSetting("RequireUppercaseCharacters", actual.RequireUppercaseCharacters, baseline.RequireUppercaseCharacters)
+	boolSetting("RequireLowercaseCharacters", actual.RequireLowercaseCharacters, baseline.RequireLowercaseCharacters)
+	boolSetting("AllowUsersToChangePassword", actual.AllowUsersToChangePassword, baseline.AllowUsersToChangePassword)
+	intSetting("MaxPasswordAge", actual.MaxPasswordAge, baseline.MaxPasswordAge)
+	intSetting("PasswordReusePrevention", actual.PasswordReusePrevention, baseline.PasswordReusePrevention)
+	boolSetting("HardExpiry", actual.HardExpiry, baseline.HardExpiry)
+	return drift
+
+}
+
+func optionalInt(v *int64) string {
+	if v == nil {
+		return "unset"
+	}
+	return fmt.Sprintf("%d", *v)
+}
+
+func optionalBool(v *bool) string {
+	if v == nil {
+		return "unset"
+	}
+	return fmt.Sprintf("%t", *v)
+}
+
+// GetPasswordPolicyForAccount returns the password policy of an account,
+// nil if it has none.
+func (o *Organization) GetPasswordPolicyForAccount(ctx context.Context, accountid string) (*iam.PasswordPolicy, error) {
+
+	svc, err := o.GetIamSvcForAccount(accountid)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := svc.GetAccountPasswordPolicyWithContext(ctx, &iam.GetAccountPasswordPolicyInput{})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == iam.ErrCodeNoSuchEntityException {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return resp.PasswordPolicy, nil
+
+}
+
+// CheckPasswordPolicies compares the password policy of every account with
+// the baseline.
+func (o *Organization) CheckPasswordPolicies(ctx context.Context, accounts []*organizations.Account, baseline *PasswordPolicy) ([]*PasswordPolicyDrift, AccountErrors) {
+
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		policy, err := o.GetPasswordPolicyForAccount(ctx, *account.Id)
+		if err != nil {
+			return nil, err
+		}
+		return passwordPolicyDrift(*account.Id, policy, baseline), nil
+	})
+
+	drift := make([]*PasswordPolicyDrift, 0, 100)
+	for _, result := range results {
+		if result.Err == nil {
+			drift = append(drift, result.Value.([]*PasswordPolicyDrift)...)
+		}
+	}
+	sort.SliceStable(drift, func(i, j int) bool { return drift[i].AccountId < drift[j].AccountId })
+	return drift, Errors(results)
+
+}
+
+// ApplyPasswordPolicy sets the baseline as the password policy of every
+// account. iam replaces the whole policy, so settings left out of the
+// baseline go back to their defaults.
+func (o *Organization) ApplyPasswordPolicy(ctx context.Context, accounts []*organizations.Account, baseline *PasswordPolicy) AccountErrors {
+
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		svc, err := o.GetIamSvcForAccount(*account.Id)
+		if err != nil {
+			return nil, err
+		}
+		input := iam.UpdateAccountPasswordPolicyInput(*baseline)
+		_, err = svc.UpdateAccountPasswordPolicyWithContext(ctx, &input)
+		return nil, err
+	})
+	return Errors(results)
+
+}
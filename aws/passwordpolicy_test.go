@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+func TestPasswordPolicyDrift(t *testing.T) {
+
+	baseline, err := ParsePasswordPolicy([]byte(`{"MinimumPasswordLength": 14, "RequireSymbols": true, "PasswordReusePrevention": 24}`))
+	if err != nil {
+		t.Fatalf("ParsePasswordPolicy error: %s", err)
+	}
+
+	actual := &iam.PasswordPolicy{
+		MinimumPasswordLength: aws.Int64(8),
+		RequireSymbols:        aws.Bool(true),
+		RequireNumbers:        aws.Bool(false),
+	}
+	drift := passwordPolicyDrift("222222222222", actual, baseline)
+	want := map[string]string{"MinimumPasswordLength": "8", "PasswordReusePrevention": "unset"}
+	if len(drift) != len(want) {
+		t.Errorf("passwordPolicyDrift returned %d settings, expected %d", len(drift), len(want))
+	}
+	for _, d := range drift {
+		if want[d.Setting] != d.Actual {
+			t.Errorf("setting %s is %s, expected %s", d.Setting, d.Actual, want[d.Setting])
+		}
+	}
+
+	if drift := passwordPolicyDrift("222222222222", nil, baseline); len(drift) != 3 {
+		t.Errorf("an account without a policy should differ in 3 settings, got %d", len(drift))
+	}
+
+	if _, err := ParsePasswordPolicy([]byte(`{"MinimumPasswordLength": 2}`)); err == nil {
+		t.Errorf("ParsePasswordPolicy should reject a minimum length below 6")
+	}
+	if _, err := ParsePasswordPolicy([]byte(`{"MinPasswordLength": 14, "RequireSymbols": true}`)); err == nil {
+		t.Errorf("ParsePasswordPolicy should reject an unknown setting")
+	}
+	for _, empty := range []string{`{}`, `{"MinimumPasswordLength": null}`} {
+		if _, err := ParsePasswordPolicy([]byte(empty)); err == nil {
+			t.Errorf("ParsePasswordPolicy should reject the empty baseline %s", empty)
+		}
+	}
+
+}
+
+func TestPlanAccountAliases(t *testing.T) {
+
+	accounts := []*organizations.Account{
+		{Id: aws.String("111111111111"), Name: aws.String("Shared Services")},
+		{Id: aws.String("222222222222"), Name: aws.String("prod")},
+		{Id: aws.String("333333333333"), Name: aws.String("QA")},
+		{Id: aws.String("444444444444"), Name: aws.String("unreadable")},
+	}
+	aliases := AliasesPerAccount{
+		"111111111111": []*string{},
+		"222222222222": []*string{aws.String("example-prod")},
+		"333333333333": []*string{},
+	}
+
+	changes := PlanAccountAliases(accounts, aliases)
+	if len(changes) != 2 {
+		t.Fatalf("PlanAccountAliases returned %d changes, expected 2", len(changes))
+	}
+	if changes[0].Alias != "shared-services" || len(changes[0].Skip) > 0 {
+		t.Errorf("alias for Shared Services is %q skip %q, expected shared-services", changes[0].Alias, changes[0].Skip)
+	}
+	if len(changes[1].Skip) == 0 {
+		t.Errorf("alias for QA should be skipped as too short")
+	}
+
+}
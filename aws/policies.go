@@ -0,0 +1,226 @@
+package aws
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+const scpType = "SERVICE_CONTROL_POLICY"
+
+var accountIdPattern = regexp.MustCompile(`^[0-9]{12}$`)
+
+// EffectivePolicy is a service control policy that applies to an account,
+// along with where in the organization tree it is attached.
+type EffectivePolicy struct {
+	Policy     *organizations.PolicySummary
+	TargetId   string
+	TargetName string
+	TargetType string
+}
+
+func (o *Organization) GetPolicies() ([]*organizations.PolicySummary, error) {
+
+	params := &organizations.ListPoliciesInput{
+		Filter: aws.String(scpType),
+	}
+	limit := o.newPageLimit("service control policies")
+	policies := make([]*organizations.PolicySummary, 0, 50)
+	err := o.svc.ListPoliciesPages(params, func(page *organizations.ListPoliciesOutput, last bool) bool {
+		policies = append(policies, page.Policies...)
+		return limit.next(last)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policies, nil
+
+}
+
+// GetPolicyId returns the id of a service control policy given its name or id.
+func (o *Organization) GetPolicyId(policy string) (string, error) {
+
+	if strings.HasPrefix(policy, "p-") {
+		return policy, nil
+	}
+	policies, err := o.GetPolicies()
+	if err != nil {
+		return "", err
+	}
+	for _, p := range policies {
+		if *p.Name == policy {
+			return *p.Id, nil
+		}
+	}
+	return "", fmt.Errorf("service control policy %s not found", policy)
+
+}
+
+func (o *Organization) GetPolicy(policy string) (*organizations.Policy, error) {
+
+	id, err := o.GetPolicyId(policy)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := o.svc.DescribePolicy(&organizations.DescribePolicyInput{
+		PolicyId: aws.String(id),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Policy, nil
+
+}
+
+func (o *Organization) CreatePolicy(name string, description string, content string) (*organizations.Policy, error) {
+
+	input := &organizations.CreatePolicyInput{
+		Name:        aws.String(name),
+		Description: aws.String(description),
+		Content:     aws.String(content),
+		Type:        aws.String(scpType),
+	}
+	resp, err := o.svc.CreatePolicy(input)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Policy, nil
+
+}
+
+// GetTargetId resolves a policy target, which may be an account id, a root
+// or organizational unit id, or an organizational unit path such as /Workloads.
+func (o *Organization) GetTargetId(target string) (string, error) {
+
+	if accountIdPattern.MatchString(target) || strings.HasPrefix(target, "r-") || strings.HasPrefix(target, "ou-") {
+		return target, nil
+	}
+	if strings.HasPrefix(target, "/") {
+		ou, err := o.GetOUByPath(target)
+		if err != nil {
+			return "", err
+		}
+		return ou.Id, nil
+	}
+	return "", fmt.Errorf("invalid target %s, expected an account id, ou id or ou path", target)
+
+}
+
+func (o *Organization) AttachPolicy(policy string, target string) error {
+
+	policyid, err := o.GetPolicyId(policy)
+	if err != nil {
+		return err
+	}
+	targetid, err := o.GetTargetId(target)
+	if err != nil {
+		return err
+	}
+	_, err = o.svc.AttachPolicy(&organizations.AttachPolicyInput{
+		PolicyId: aws.String(policyid),
+		TargetId: aws.String(targetid),
+	})
+	return err
+
+}
+
+func (o *Organization) DetachPolicy(policy string, target string) error {
+
+	policyid, err := o.GetPolicyId(policy)
+	if err != nil {
+		return err
+	}
+	targetid, err := o.GetTargetId(target)
+	if err != nil {
+		return err
+	}
+	_, err = o.svc.DetachPolicy(&organizations.DetachPolicyInput{
+		PolicyId: aws.String(policyid),
+		TargetId: aws.String(targetid),
+	})
+	return err
+
+}
+
+// GetPoliciesForTarget returns the service control policies attached
+// directly to an account, organizational unit or root.
+func (o *Organization) GetPoliciesForTarget(target string) ([]*organizations.PolicySummary, error) {
+
+	targetid, err := o.GetTargetId(target)
+	if err != nil {
+		return nil, err
+	}
+	params := &organizations.ListPoliciesForTargetInput{
+		Filter:   aws.String(scpType),
+		TargetId: aws.String(targetid),
+	}
+	limit := o.newPageLimit("policies attached to " + targetid)
+	policies := make([]*organizations.PolicySummary, 0, 10)
+	err = o.svc.ListPoliciesForTargetPages(params, func(page *organizations.ListPoliciesForTargetOutput, last bool) bool {
+		policies = append(policies, page.Policies...)
+		return limit.next(last)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return policies, nil
+
+}
+
+// GetEffectivePolicies walks up from an account to the organization root
+// and returns every service control policy that applies to the account,
+// starting at the root.
+func (o *Organization) GetEffectivePolicies(accountid string) ([]*EffectivePolicy, error) {
+
+	effective := make([]*EffectivePolicy, 0, 20)
+	id, name, kind := accountid, accountid, "ACCOUNT"
+
+	for {
+		policies, err := o.GetPoliciesForTarget(id)
+		if err != nil {
+			return nil, err
+		}
+		// prepend so the list reads from the root down
+		level := make([]*EffectivePolicy, 0, len(policies))
+		for _, policy := range policies {
+			level = append(level, &EffectivePolicy{
+				Policy:     policy,
+				TargetId:   id,
+				TargetName: name,
+				TargetType: kind,
+			})
+		}
+		effective = append(level, effective...)
+
+		if kind == "ROOT" {
+			break
+		}
+
+		resp, err := o.svc.ListParents(&organizations.ListParentsInput{
+			ChildId: aws.String(id),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Parents) == 0 {
+			return nil, fmt.Errorf("no parent found for %s", id)
+		}
+		id, kind = *resp.Parents[0].Id, *resp.Parents[0].Type
+
+		name = "Root"
+		if kind == "ORGANIZATIONAL_UNIT" {
+			ou, err := o.svc.DescribeOrganizationalUnit(&organizations.DescribeOrganizationalUnitInput{
+				OrganizationalUnitId: aws.String(id),
+			})
+			if err != nil {
+				return nil, err
+			}
+			name = *ou.OrganizationalUnit.Name
+		}
+	}
+	return effective, nil
+
+}
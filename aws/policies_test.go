@@ -0,0 +1,80 @@
+package aws
+
+import (
+	"testing"
+)
+
+func TestGetEffectivePolicies(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	if err := org.AttachPolicy("DenyLeave", "222222222222"); err != nil {
+		t.Fatalf("organization AttachPolicy error: %s", err)
+	}
+
+	policies, err := org.GetEffectivePolicies("222222222222")
+	if err != nil {
+		t.Fatalf("organization GetEffectivePolicies error: %s", err)
+	}
+
+	expected := []struct {
+		policy string
+		target string
+	}{
+		{"FullAWSAccess", "Root"},
+		{"DenyLeave", "Workloads"},
+		{"DenyLeave", "222222222222"},
+	}
+	if len(policies) != len(expected) {
+		t.Fatalf("organization GetEffectivePolicies returned %d policies, expected %d", len(policies), len(expected))
+	}
+	for i, e := range expected {
+		if *policies[i].Policy.Name != e.policy || policies[i].TargetName != e.target {
+			t.Errorf("organization GetEffectivePolicies %d returned %s on %s, expected %s on %s", i, *policies[i].Policy.Name, policies[i].TargetName, e.policy, e.target)
+		}
+	}
+
+	if err := org.DetachPolicy("p-deny", "222222222222"); err != nil {
+		t.Fatalf("organization DetachPolicy error: %s", err)
+	}
+	policies, err = org.GetEffectivePolicies("444444444444")
+	if err != nil {
+		t.Fatalf("organization GetEffectivePolicies error: %s", err)
+	}
+	if len(policies) != 1 || *policies[0].Policy.Id != "p-full" {
+		t.Errorf("organization GetEffectivePolicies for /Security incorrect")
+	}
+
+}
+
+func TestGetTargetId(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	tests := []struct {
+		target string
+		id     string
+		ok     bool
+	}{
+		{"222222222222", "222222222222", true},
+		{"ou-prod", "ou-prod", true},
+		{"r-root", "r-root", true},
+		{"/Workloads/Prod", "ou-prod", true},
+		{"/", "r-root", true},
+		{"/Missing", "", false},
+		{"prod-app", "", false},
+	}
+	for _, test := range tests {
+		id, err := org.GetTargetId(test.target)
+		if (err == nil) != test.ok || id != test.id {
+			t.Errorf("organization GetTargetId %s returned %s, %v", test.target, id, err)
+		}
+	}
+
+}
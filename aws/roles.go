@@ -0,0 +1,330 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+// Role is an iam role with who may assume it and what it is allowed to do.
+type Role struct {
+	AccountId        string
+	RoleName         string
+	Arn              string
+	Path             string
+	CreateDate       *time.Time
+	Principals       []*TrustPrincipal
+	AttachedPolicies []string
+	InlinePolicies   []string
+	LastUsed         *time.Time
+	LastUsedRegion   string
+}
+
+// TrustPrincipal is a principal allowed to assume a role by its trust
+// policy. AccountId is set for aws principals naming an account.
+type TrustPrincipal struct {
+	Type      string
+	Value     string
+	AccountId string
+}
+
+// principal scopes, from the point of view of the account owning the role
+const (
+	PrincipalSameAccount  = "same-account"
+	PrincipalOrganization = "organization"
+	PrincipalExternal     = "external"
+	PrincipalService      = "service"
+	PrincipalFederated    = "federated"
+	PrincipalPublic       = "public"
+	PrincipalUnknown      = "unknown"
+)
+
+// IamPolicy is a customer managed iam policy.
+type IamPolicy struct {
+	AccountId                     string
+	PolicyName                    string
+	Arn                           string
+	Path                          string
+	DefaultVersionId              string
+	AttachmentCount               int64
+	PermissionsBoundaryUsageCount int64
+	UpdateDate                    *time.Time
+}
+
+// Scope says where a principal comes from relative to the account owning
+// the role. members holds the ids of the accounts in the organization.
+func (p *TrustPrincipal) Scope(owner string, members map[string]bool) string {
+
+	switch {
+	case p.Value == "*":
+		return PrincipalPublic
+	case p.Type == "Service":
+		return PrincipalService
+	case p.Type == "Federated":
+		return PrincipalFederated
+	case p.Type != "AWS":
+		return PrincipalExternal
+	case len(p.AccountId) == 0:
+		return PrincipalUnknown
+	case p.AccountId == owner:
+		return PrincipalSameAccount
+	case members[p.AccountId]:
+		return PrincipalOrganization
+	}
+	return PrincipalExternal
+
+}
+
+func (p *TrustPrincipal) String() string {
+	return strings.ToLower(p.Type) + ":" + p.Value
+}
+
+// ParseTrustPolicy returns the principals allowed to assume a role by its
+// trust policy. The document may be url encoded as returned by iam.
+func ParseTrustPolicy(document string) ([]*TrustPrincipal, error) {
+
+	// iam encodes documents as rfc 3986, where + is not a space and is
+	// valid in role and user names
+	if decoded, err := url.PathUnescape(document); err == nil {
+		document = decoded
+	}
+
+	var policy struct {
+		Statement json.RawMessage
+	}
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		return nil, fmt.Errorf("could not parse trust policy: %s", err)
+	}
+
+	// a policy may have a single statement instead of a list
+	type statement struct {
+		Effect    string
+		Principal interface{}
+	}
+	statements := make([]statement, 0, 5)
+	if err := json.Unmarshal(policy.Statement, &statements); err != nil {
+		var single statement
+		if err := json.Unmarshal(policy.Statement, &single); err != nil {
+			return nil, fmt.Errorf("could not parse trust policy statement: %s", err)
+		}
+		statements = append(statements, single)
+	}
+
+	principals := make([]*TrustPrincipal, 0, 5)
+	for _, s := range statements {
+		if s.Effect != "Allow" {
+			continue
+		}
+		switch p := s.Principal.(type) {
+		case string:
+			principals = append(principals, &TrustPrincipal{Type: "AWS", Value: p})
+		case map[string]interface{}:
+			types := make([]string, 0, len(p))
+			for t := range p {
+				types = append(types, t)
+			}
+			sort.Strings(types)
+			for _, t := range types {
+				for _, value := range policyStrings(p[t]) {
+					principal := &TrustPrincipal{Type: t, Value: value}
+					if t == "AWS" {
+						principal.AccountId = principalAccount(value)
+					}
+					principals = append(principals, principal)
+				}
+			}
+		}
+	}
+	return principals, nil
+
+}
+
+// policyStrings returns a policy value that may be a string or a list of
+// strings as a list.
+func policyStrings(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// principalAccount returns the account id of an aws principal given as an
+// account id or an arn. Unique ids, left behind when a principal is
+// deleted, have no account.
+func principalAccount(value string) string {
+	if len(value) == 12 && strings.Trim(value, "0123456789") == "" {
+		return value
+	}
+	parts := strings.SplitN(value, ":", 6)
+	if len(parts) == 6 && parts[0] == "arn" {
+		return parts[4]
+	}
+	return ""
+}
+
+// GetRolesForAccount describes every iam role in an account.
+func (o *Organization) GetRolesForAccount(ctx context.Context, accountid string) ([]*Role, error) {
+
+	svc, err := o.GetIamSvcForAccount(accountid)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := o.newPageLimit("roles in account " + accountid)
+	iamRoles := make([]*iam.Role, 0, 100)
+	err = svc.ListRolesPagesWithContext(ctx, &iam.ListRolesInput{}, func(page *iam.ListRolesOutput, last bool) bool {
+		iamRoles = append(iamRoles, page.Roles...)
+		return limit.next(last)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list roles: %s", err)
+	}
+
+	roles := make([]*Role, 0, len(iamRoles))
+	for _, r := range iamRoles {
+		role := &Role{
+			AccountId:  accountid,
+			RoleName:   aws.StringValue(r.RoleName),
+			Arn:        aws.StringValue(r.Arn),
+			Path:       aws.StringValue(r.Path),
+			CreateDate: r.CreateDate,
+		}
+		role.Principals, err = ParseTrustPolicy(aws.StringValue(r.AssumeRolePolicyDocument))
+		if err != nil {
+			return nil, fmt.Errorf("role %s: %s", role.RoleName, err)
+		}
+
+		attached := o.newPageLimit(fmt.Sprintf("policies attached to role %s in account %s", role.RoleName, accountid))
+		err = svc.ListAttachedRolePoliciesPagesWithContext(ctx, &iam.ListAttachedRolePoliciesInput{RoleName: r.RoleName}, func(page *iam.ListAttachedRolePoliciesOutput, last bool) bool {
+			for _, policy := range page.AttachedPolicies {
+				role.AttachedPolicies = append(role.AttachedPolicies, aws.StringValue(policy.PolicyName))
+			}
+			return attached.next(last)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not list policies attached to role %s: %s", role.RoleName, err)
+		}
+
+		inline := o.newPageLimit(fmt.Sprintf("inline policies of role %s in account %s", role.RoleName, accountid))
+		err = svc.ListRolePoliciesPagesWithContext(ctx, &iam.ListRolePoliciesInput{RoleName: r.RoleName}, func(page *iam.ListRolePoliciesOutput, last bool) bool {
+			role.InlinePolicies = append(role.InlinePolicies, aws.StringValueSlice(page.PolicyNames)...)
+			return inline.next(last)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not list inline policies of role %s: %s", role.RoleName, err)
+		}
+
+		// ListRoles does not return when a role was last used
+		resp, err := svc.GetRoleWithContext(ctx, &iam.GetRoleInput{RoleName: r.RoleName})
+		if err != nil {
+			return nil, fmt.Errorf("could not get role %s: %s", role.RoleName, err)
+		}
+		if resp.Role.RoleLastUsed != nil {
+			role.LastUsed = resp.Role.RoleLastUsed.LastUsedDate
+			role.LastUsedRegion = aws.StringValue(resp.Role.RoleLastUsed.Region)
+		}
+
+		roles = append(roles, role)
+	}
+	return roles, nil
+
+}
+
+// GetRoles returns the roles of every account in one list, ordered by
+// account and role name.
+func (o *Organization) GetRoles(ctx context.Context, accounts []*organizations.Account) ([]*Role, AccountErrors) {
+
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		return o.GetRolesForAccount(ctx, *account.Id)
+	})
+
+	roles := make([]*Role, 0, 500)
+	for _, result := range results {
+		if result.Err == nil {
+			roles = append(roles, result.Value.([]*Role)...)
+		}
+	}
+	sort.SliceStable(roles, func(i, j int) bool {
+		if roles[i].AccountId != roles[j].AccountId {
+			return roles[i].AccountId < roles[j].AccountId
+		}
+		return roles[i].RoleName < roles[j].RoleName
+	})
+	return roles, Errors(results)
+
+}
+
+// GetCustomerManagedPoliciesForAccount lists the customer managed iam
+// policies of an account with how often each is attached.
+func (o *Organization) GetCustomerManagedPoliciesForAccount(ctx context.Context, accountid string) ([]*IamPolicy, error) {
+
+	svc, err := o.GetIamSvcForAccount(accountid)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := o.newPageLimit("customer managed policies in account " + accountid)
+	policies := make([]*IamPolicy, 0, 100)
+	input := &iam.ListPoliciesInput{Scope: aws.String(iam.PolicyScopeTypeLocal)}
+	err = svc.ListPoliciesPagesWithContext(ctx, input, func(page *iam.ListPoliciesOutput, last bool) bool {
+		for _, p := range page.Policies {
+			policies = append(policies, &IamPolicy{
+				AccountId:                     accountid,
+				PolicyName:                    aws.StringValue(p.PolicyName),
+				Arn:                           aws.StringValue(p.Arn),
+				Path:                          aws.StringValue(p.Path),
+				DefaultVersionId:              aws.StringValue(p.DefaultVersionId),
+				AttachmentCount:               aws.Int64Value(p.AttachmentCount),
+				PermissionsBoundaryUsageCount: aws.Int64Value(p.PermissionsBoundaryUsageCount),
+				UpdateDate:                    p.UpdateDate,
+			})
+		}
+		return limit.next(last)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list policies: %s", err)
+	}
+	return policies, nil
+
+}
+
+// GetCustomerManagedPolicies returns the customer managed policies of every
+// account in one list, ordered by account and policy name.
+func (o *Organization) GetCustomerManagedPolicies(ctx context.Context, accounts []*organizations.Account) ([]*IamPolicy, AccountErrors) {
+
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		return o.GetCustomerManagedPoliciesForAccount(ctx, *account.Id)
+	})
+
+	policies := make([]*IamPolicy, 0, 500)
+	for _, result := range results {
+		if result.Err == nil {
+			policies = append(policies, result.Value.([]*IamPolicy)...)
+		}
+	}
+	sort.SliceStable(policies, func(i, j int) bool {
+		if policies[i].AccountId != policies[j].AccountId {
+			return policies[i].AccountId < policies[j].AccountId
+		}
+		return policies[i].PolicyName < policies[j].PolicyName
+	})
+	return policies, Errors(results)
+
+}
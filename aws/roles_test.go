@@ -0,0 +1,69 @@
+package aws
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseTrustPolicy(t *testing.T) {
+
+	// iam encodes a space as %20, a + as %2B
+	document := strings.Replace(url.QueryEscape(`{
+  "Version": "2012-10-17",
+  "Statement": [
+    {"Effect": "Allow", "Principal": {"Service": "ec2.amazonaws.com"}, "Action": "sts:AssumeRole"},
+    {"Effect": "Allow", "Principal": {"AWS": ["arn:aws:iam::222222222222:root", "333333333333", "arn:aws:iam::999999999999:role/vendor", "arn:aws:iam::999999999999:role/ci+deploy", "AROAEXAMPLEUNIQUEID"]}, "Action": "sts:AssumeRole"},
+    {"Effect": "Allow", "Principal": {"Federated": "arn:aws:iam::222222222222:saml-provider/okta"}, "Action": "sts:AssumeRoleWithSAML"},
+    {"Effect": "Deny", "Principal": "*", "Action": "sts:AssumeRole"}
+  ]
+}`), "+", "%20", -1)
+
+	principals, err := ParseTrustPolicy(document)
+	if err != nil {
+		t.Fatalf("ParseTrustPolicy error: %s", err)
+	}
+
+	members := map[string]bool{"222222222222": true, "333333333333": true}
+	want := []struct {
+		principal string
+		scope     string
+	}{
+		{"service:ec2.amazonaws.com", PrincipalService},
+		{"aws:arn:aws:iam::222222222222:root", PrincipalSameAccount},
+		{"aws:333333333333", PrincipalOrganization},
+		{"aws:arn:aws:iam::999999999999:role/vendor", PrincipalExternal},
+		{"aws:arn:aws:iam::999999999999:role/ci+deploy", PrincipalExternal},
+		{"aws:AROAEXAMPLEUNIQUEID", PrincipalUnknown},
+		{"federated:arn:aws:iam::222222222222:saml-provider/okta", PrincipalFederated},
+	}
+	if len(principals) != len(want) {
+		t.Fatalf("ParseTrustPolicy returned %d principals, expected %d", len(principals), len(want))
+	}
+	for i, p := range principals {
+		if p.String() != want[i].principal {
+			t.Errorf("principal %d is %s, expected %s", i, p, want[i].principal)
+		}
+		if scope := p.Scope("222222222222", members); scope != want[i].scope {
+			t.Errorf("principal %s scope is %s, expected %s", p, scope, want[i].scope)
+		}
+	}
+
+	principals, err = ParseTrustPolicy(`{"Statement": {"Effect": "Allow", "Principal": "*"}}`)
+	if err != nil {
+		t.Fatalf("ParseTrustPolicy error: %s", err)
+	}
+	if len(principals) != 1 || principals[0].Scope("222222222222", members) != PrincipalPublic {
+		t.Errorf("ParseTrustPolicy should return one public principal for a single statement")
+	}
+
+	// a document that is not encoded keeps the + in a role name
+	principals, err = ParseTrustPolicy(`{"Statement": {"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::999999999999:role/ci+deploy"}}}`)
+	if err != nil {
+		t.Fatalf("ParseTrustPolicy error: %s", err)
+	}
+	if len(principals) != 1 || principals[0].String() != "aws:arn:aws:iam::999999999999:role/ci+deploy" {
+		t.Errorf("ParseTrustPolicy returned %v, expected the role ci+deploy", principals)
+	}
+
+}
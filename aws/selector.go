@@ -0,0 +1,230 @@
+package aws
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+// Selector picks accounts from the organization. It is built from a comma
+// separated list of terms, any of which may be prefixed with ! to exclude
+// the matching accounts instead:
+//
+//	123456789012		an account id
+//	prod-*			an account name glob
+//	ou:/Workloads/Prod	every account in an organizational unit and below it
+//	tag:env=prod		every account with a tag
+//	@accounts.txt		terms read from a file, one per line
+//
+// An account is selected if it matches any included term and no excluded
+// term. With no included terms every active account is a candidate.
+type Selector struct {
+	include []string
+	exclude []string
+}
+
+// ParseSelector parses a selector expression, reading any @file terms.
+func ParseSelector(s string) (*Selector, error) {
+
+	selector := &Selector{}
+	terms, err := expandSelectorTerms(SplitList(s))
+	if err != nil {
+		return nil, err
+	}
+	for _, term := range terms {
+		exclude := strings.HasPrefix(term, "!")
+		term = strings.TrimPrefix(term, "!")
+		if err := validSelectorTerm(term); err != nil {
+			return nil, err
+		}
+		if exclude {
+			selector.exclude = append(selector.exclude, term)
+		} else {
+			selector.include = append(selector.include, term)
+		}
+	}
+	return selector, nil
+
+}
+
+func expandSelectorTerms(terms []string) ([]string, error) {
+
+	expanded := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if !strings.HasPrefix(term, "@") {
+			expanded = append(expanded, term)
+			continue
+		}
+		file, err := os.Open(strings.TrimPrefix(term, "@"))
+		if err != nil {
+			return nil, fmt.Errorf("could not read accounts file: %s", err)
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if len(line) == 0 || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if strings.HasPrefix(line, "@") {
+				file.Close()
+				return nil, fmt.Errorf("accounts file %s may not include other files", term)
+			}
+			expanded = append(expanded, SplitList(line)...)
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not read accounts file: %s", err)
+		}
+	}
+	return expanded, nil
+
+}
+
+func validSelectorTerm(term string) error {
+
+	switch {
+	case len(term) == 0:
+		return fmt.Errorf("empty account selector term")
+	case strings.HasPrefix(term, "ou:"):
+		if !strings.HasPrefix(term, "ou:/") {
+			return fmt.Errorf("invalid selector %s, expected ou:/path", term)
+		}
+	case strings.HasPrefix(term, "tag:"):
+		if _, err := ParseAccountFilter(term); err != nil {
+			return err
+		}
+	default:
+		if _, err := path.Match(term, ""); err != nil {
+			return fmt.Errorf("invalid account name pattern %s", term)
+		}
+	}
+	return nil
+
+}
+
+// Empty reports whether the selector has no terms at all.
+func (s *Selector) Empty() bool {
+	return s == nil || len(s.include)+len(s.exclude) == 0
+}
+
+// accountFacts holds what the selector terms match against, fetched only
+// when a term needs it.
+type accountFacts struct {
+	paths map[string]string
+	tags  map[string]map[string]string
+}
+
+func (s *Selector) needs(prefix string) bool {
+	for _, term := range append(s.include, s.exclude...) {
+		if strings.HasPrefix(term, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *accountFacts) match(term string, account *organizations.Account) bool {
+
+	switch {
+	case accountIdPattern.MatchString(term):
+		return *account.Id == term
+	case strings.HasPrefix(term, "ou:"):
+		ou := "/" + strings.Join(splitOUPath(strings.TrimPrefix(term, "ou:")), "/")
+		p := f.paths[*account.Id]
+		return ou == "/" || p == ou || strings.HasPrefix(p, ou+"/")
+	case strings.HasPrefix(term, "tag:"):
+		filter, _ := ParseAccountFilter(term)
+		return filter.Match(f.tags[*account.Id])
+	default:
+		ok, _ := path.Match(term, stringValue(account.Name))
+		return ok
+	}
+
+}
+
+// SelectAccounts resolves a selector against the active accounts of the
+// organization. Accounts are returned in the order GetAccounts lists them.
+func (o *Organization) SelectAccounts(ctx context.Context, s *Selector) ([]*organizations.Account, error) {
+
+	accounts, err := o.GetActiveAccounts()
+	if err != nil {
+		return nil, err
+	}
+	if s.Empty() {
+		return accounts, nil
+	}
+
+	// explicit account ids must name an active account
+	active := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		active[*account.Id] = true
+	}
+	for _, term := range append(s.include, s.exclude...) {
+		if accountIdPattern.MatchString(term) && !active[term] {
+			return nil, fmt.Errorf("account %s is not an active account in the organization", term)
+		}
+	}
+
+	facts := &accountFacts{}
+	if s.needs("ou:") {
+		tree, err := o.GetOUTree()
+		if err != nil {
+			return nil, err
+		}
+		facts.paths = make(map[string]string)
+		ous := make(map[string]bool)
+		var walk func(node *OrganizationalUnit)
+		walk = func(node *OrganizationalUnit) {
+			ous[node.Path] = true
+			for _, account := range node.Accounts {
+				facts.paths[*account.Id] = node.Path
+			}
+			for _, child := range node.Children {
+				walk(child)
+			}
+		}
+		walk(tree)
+		for _, term := range append(s.include, s.exclude...) {
+			if strings.HasPrefix(term, "ou:") {
+				ou := "/" + strings.Join(splitOUPath(strings.TrimPrefix(term, "ou:")), "/")
+				if !ous[ou] {
+					return nil, fmt.Errorf("organizational unit %s not found", ou)
+				}
+			}
+		}
+	}
+	if s.needs("tag:") {
+		tags, errs := o.GetTagsForAccounts(ctx, accounts)
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("could not get tags for %s", errs[0])
+		}
+		facts.tags = tags
+	}
+
+	selected := make([]*organizations.Account, 0, len(accounts))
+	for _, account := range accounts {
+		included := len(s.include) == 0
+		for _, term := range s.include {
+			if facts.match(term, account) {
+				included = true
+				break
+			}
+		}
+		for _, term := range s.exclude {
+			if included && facts.match(term, account) {
+				included = false
+			}
+		}
+		if included {
+			selected = append(selected, account)
+		}
+	}
+	return selected, nil
+
+}
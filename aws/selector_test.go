@@ -0,0 +1,73 @@
+package aws
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSelectAccounts(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	file, err := ioutil.TempFile("", "accounts")
+	if err != nil {
+		t.Fatalf("could not create accounts file: %s", err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("# payments accounts\n222222222222\n\n333333333333\n")
+	file.Close()
+
+	tests := []struct {
+		selector string
+		want     string
+	}{
+		{"", "111111111111,222222222222,333333333333,444444444444"},
+		{"222222222222,444444444444", "222222222222,444444444444"},
+		{"*-app", "222222222222,333333333333"},
+		{"ou:/Workloads", "222222222222,333333333333"},
+		{"ou:/Workloads/Prod,audit", "222222222222,444444444444"},
+		{"tag:env=prod", "222222222222,444444444444"},
+		{"!111111111111", "222222222222,333333333333,444444444444"},
+		{"ou:/Workloads,!dev-*", "222222222222"},
+		{"@" + file.Name() + ",!tag:env=dev", "222222222222"},
+	}
+	for _, test := range tests {
+		selector, err := ParseSelector(test.selector)
+		if err != nil {
+			t.Fatalf("ParseSelector(%q) error: %s", test.selector, err)
+		}
+		accounts, err := org.SelectAccounts(context.Background(), selector)
+		if err != nil {
+			t.Fatalf("SelectAccounts(%q) error: %s", test.selector, err)
+		}
+		ids := make([]string, 0, len(accounts))
+		for _, account := range accounts {
+			ids = append(ids, *account.Id)
+		}
+		if got := strings.Join(ids, ","); got != test.want {
+			t.Errorf("SelectAccounts(%q) = %s, expected %s", test.selector, got, test.want)
+		}
+	}
+
+	for _, bad := range []string{"ou:/Missing", "555555555555", "999999999999"} {
+		selector, err := ParseSelector(bad)
+		if err != nil {
+			t.Fatalf("ParseSelector(%q) error: %s", bad, err)
+		}
+		if _, err := org.SelectAccounts(context.Background(), selector); err == nil {
+			t.Errorf("SelectAccounts(%q) should fail", bad)
+		}
+	}
+	for _, bad := range []string{"ou:Workloads", "tag:env", "[", "@/no/such/file"} {
+		if _, err := ParseSelector(bad); err == nil {
+			t.Errorf("ParseSelector(%q) should fail", bad)
+		}
+	}
+
+}
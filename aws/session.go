@@ -0,0 +1,81 @@
+package aws
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+const (
+	// the default and minimum assumed role session lifetime
+	roleSessionDuration = 900 * time.Second
+	// the longest session iam allows for a role
+	roleSessionMaxDuration = 12 * time.Hour
+	// the longest session iam allows for a role assumed with the
+	// credentials of another assumed role
+	chainedSessionMaxDuration = time.Hour
+	// refresh assumed role credentials this long before they expire
+	roleExpiryWindow = 60 * time.Second
+)
+
+// GetSessionForAccount returns a session for a member account in the
+// organization default region.
+func (o *Organization) GetSessionForAccount(accountid string) (*session.Session, error) {
+	return o.GetSessionForAccountInRegion(accountid, o.region)
+}
+
+// GetSessionForAccountInRegion returns a session for a member account in
+// the given region. The member account role is assumed once per account
+// and the credentials are cached and refreshed before they expire, so
+// every service client for the same account shares them.
+func (o *Organization) GetSessionForAccountInRegion(accountid string, region string) (*session.Session, error) {
+
+	o.mu.Lock()
+	sess, ok := o.sessions[accountid]
+	if !ok {
+		role := o.config.RoleArn(accountid)
+		creds := stscreds.NewCredentials(o.sess, role, func(p *stscreds.AssumeRoleProvider) {
+			p.RoleSessionName = o.config.SessionName(accountid)
+			p.Duration = o.config.SessionDuration
+			p.ExpiryWindow = roleExpiryWindow
+			if len(o.config.ExternalId) > 0 {
+				p.ExternalID = aws.String(o.config.ExternalId)
+			}
+		})
+		sess = o.sess.Copy(aws.NewConfig().WithCredentials(creds))
+		o.sessions[accountid] = sess
+	}
+	o.mu.Unlock()
+
+	// assume the role up front so access problems are reported here
+	// rather than on the first service call
+	if _, err := sess.Config.Credentials.Get(); err != nil {
+		return nil, err
+	}
+
+	return sess.Copy(aws.NewConfig().WithRegion(region)), nil
+
+}
+
+// GetCredentialsForAccount returns the assumed role credentials for a
+// member account and when they expire, for handing to other programs.
+func (o *Organization) GetCredentialsForAccount(accountid string) (credentials.Value, time.Time, error) {
+
+	sess, err := o.GetSessionForAccount(accountid)
+	if err != nil {
+		return credentials.Value{}, time.Time{}, err
+	}
+	creds, err := sess.Config.Credentials.Get()
+	if err != nil {
+		return credentials.Value{}, time.Time{}, err
+	}
+	expires, err := sess.Config.Credentials.ExpiresAt()
+	if err != nil {
+		return credentials.Value{}, time.Time{}, err
+	}
+	return creds, expires, nil
+
+}
@@ -0,0 +1,159 @@
+package aws
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// stsStub answers AssumeRole with hour long credentials and records every
+// role assumed.
+type stsStub struct {
+	*httptest.Server
+	mu        sync.Mutex
+	assumed   []string
+	durations []string
+}
+
+func newStsStub() *stsStub {
+	s := &stsStub{}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+func (s *stsStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	r.ParseForm()
+	if r.Form.Get("Action") != "AssumeRole" {
+		http.Error(w, "unsupported action", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.assumed = append(s.assumed, r.Form.Get("RoleArn"))
+	s.durations = append(s.durations, r.Form.Get("DurationSeconds"))
+	n := len(s.assumed)
+	s.mu.Unlock()
+
+	fmt.Fprintf(w, `<AssumeRoleResponse><AssumeRoleResult><Credentials>
+<AccessKeyId>ASIASTUB%d</AccessKeyId><SecretAccessKey>secret</SecretAccessKey>
+<SessionToken>token</SessionToken><Expiration>%s</Expiration>
+</Credentials></AssumeRoleResult></AssumeRoleResponse>`, n, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+
+}
+
+// session returns a session with static credentials that sends every
+// call to the stub.
+func (s *stsStub) session() *session.Session {
+	config := aws.NewConfig().WithRegion("us-east-1").WithEndpoint(s.URL).
+		WithCredentials(credentials.NewStaticCredentials("AKIASTUB", "secret", ""))
+	return session.New(config)
+}
+
+// withStubCredentials gives the organization session static credentials
+// and returns a func restoring the environment.
+func withStubCredentials() func() {
+	saved := map[string]*string{}
+	for _, key := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE"} {
+		saved[key] = nil
+		if value, ok := os.LookupEnv(key); ok {
+			saved[key] = &value
+		}
+		os.Unsetenv(key)
+	}
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKIASTUB")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	return func() {
+		for key, value := range saved {
+			if value == nil {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, *value)
+			}
+		}
+	}
+}
+
+func TestSessionCache(t *testing.T) {
+
+	stub := newStsStub()
+	defer stub.Close()
+
+	org, err := NewOrganization()
+	if err != nil {
+		t.Fatalf("could not create organization: %s", err)
+	}
+	org.sess = stub.session()
+
+	regions := []string{"us-east-1", "eu-west-1", "ap-southeast-2"}
+	for _, accountid := range []string{"222222222222", "333333333333"} {
+		first, err := org.GetSessionForAccount(accountid)
+		if err != nil {
+			t.Fatalf("GetSessionForAccount %s error: %s", accountid, err)
+		}
+		for _, region := range regions {
+			sess, err := org.GetSessionForAccountInRegion(accountid, region)
+			if err != nil {
+				t.Fatalf("GetSessionForAccountInRegion %s %s error: %s", accountid, region, err)
+			}
+			if *sess.Config.Region != region {
+				t.Errorf("session for %s is in %s, expected %s", accountid, *sess.Config.Region, region)
+			}
+			if sess.Config.Credentials != first.Config.Credentials {
+				t.Errorf("session for %s in %s does not share the account credentials", accountid, region)
+			}
+		}
+	}
+
+	// the role is assumed once per account, whatever the region
+	expected := []string{
+		"arn:aws:iam::222222222222:role/OrganizationAccountAccessRole",
+		"arn:aws:iam::333333333333:role/OrganizationAccountAccessRole",
+	}
+	if strings.Join(stub.assumed, ",") != strings.Join(expected, ",") {
+		t.Errorf("assumed roles %v, expected %v", stub.assumed, expected)
+	}
+
+}
+
+func TestRoleChainSessions(t *testing.T) {
+
+	defer withStubCredentials()()
+	stub := newStsStub()
+	defer stub.Close()
+
+	config := DefaultConfig()
+	config.Endpoint = stub.URL
+	config.RoleChain = []string{"arn:aws:iam::999999999999:role/Tooling", "arn:aws:iam::111111111111:role/OrganizerMaster"}
+	config.SessionDuration = 45 * time.Minute
+	org, err := NewOrganizationWithConfig(config)
+	if err != nil {
+		t.Fatalf("could not create organization: %s", err)
+	}
+	if _, err := org.GetSessionForAccount("222222222222"); err != nil {
+		t.Fatalf("GetSessionForAccount error: %s", err)
+	}
+
+	// the chain is walked before the member account role is assumed and
+	// every hop lasts the configured session duration
+	expected := []string{
+		"arn:aws:iam::999999999999:role/Tooling",
+		"arn:aws:iam::111111111111:role/OrganizerMaster",
+		"arn:aws:iam::222222222222:role/OrganizationAccountAccessRole",
+	}
+	if strings.Join(stub.assumed, ",") != strings.Join(expected, ",") {
+		t.Errorf("assumed roles %v, expected %v", stub.assumed, expected)
+	}
+	if strings.Join(stub.durations, ",") != "2700,2700,2700" {
+		t.Errorf("assumed roles for %v seconds, expected 2700 each", stub.durations)
+	}
+
+}
@@ -0,0 +1,238 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+// StandardTrail describes the multi-region trail every account should have.
+type StandardTrail struct {
+	Name                   string `yaml:"name"`
+	Region                 string `yaml:"region"`
+	Bucket                 string `yaml:"bucket"`
+	KeyPrefix              string `yaml:"key_prefix"`
+	KmsKeyId               string `yaml:"kms_key_id"`
+	LogFileValidation      bool   `yaml:"log_file_validation"`
+	CloudWatchLogsGroupArn string `yaml:"cloudwatch_logs_group_arn"`
+	CloudWatchLogsRoleArn  string `yaml:"cloudwatch_logs_role_arn"`
+}
+
+func (t *StandardTrail) Validate() error {
+
+	if len(t.Name) == 0 {
+		return fmt.Errorf("the standard trail needs a name")
+	}
+	if len(t.Bucket) == 0 {
+		return fmt.Errorf("the standard trail needs an s3 bucket")
+	}
+	if (len(t.CloudWatchLogsGroupArn) == 0) != (len(t.CloudWatchLogsRoleArn) == 0) {
+		return fmt.Errorf("the standard trail needs both a cloudwatch logs group and role, or neither")
+	}
+	if strings.HasPrefix(t.KmsKeyId, "alias/") || strings.Contains(t.KmsKeyId, ":alias/") {
+		return fmt.Errorf("the standard trail kms key %s is an alias, use the key arn or id", t.KmsKeyId)
+	}
+	return nil
+
+}
+
+// TrailDrift is a difference between a trail and the standard. Drift in
+// the standard trail itself can be fixed, other trails are only reported.
+type TrailDrift struct {
+	Account  *organizations.Account
+	Region   string
+	TrailArn string
+	Issue    string
+	Standard bool
+	Fixed    bool
+}
+
+// trailDrift compares a trail with the standard. Any trail should be
+// logging, validating its log files and covering every region. The
+// standard trail must also match the standard settings, pass nil for
+// other trails.
+func trailDrift(trail *cloudtrail.Trail, logging bool, standard *StandardTrail) []string {
+
+	issues := make([]string, 0, 5)
+	if !logging {
+		issues = append(issues, "logging stopped")
+	}
+	if trail.LogFileValidationEnabled == nil || !*trail.LogFileValidationEnabled {
+		if standard == nil || standard.LogFileValidation {
+			issues = append(issues, "log file validation off")
+		}
+	}
+	if trail.IsMultiRegionTrail == nil || !*trail.IsMultiRegionTrail {
+		issues = append(issues, "single region")
+	}
+	if standard == nil {
+		return issues
+	}
+
+	differs := func(setting string, actual *string, expected string) {
+		if aws.StringValue(actual) != expected {
+			issues = append(issues, fmt.Sprintf("%s is %q, expected %q", setting, aws.StringValue(actual), expected))
+		}
+	}
+	differs("s3 bucket", trail.S3BucketName, standard.Bucket)
+	differs("s3 key prefix", trail.S3KeyPrefix, standard.KeyPrefix)
+	// cloudtrail reports the key arn, the standard may give the key id
+	if kmsKeyId(aws.StringValue(trail.KmsKeyId)) != kmsKeyId(standard.KmsKeyId) {
+		issues = append(issues, fmt.Sprintf("kms key is %q, expected %q", aws.StringValue(trail.KmsKeyId), standard.KmsKeyId))
+	}
+	differs("cloudwatch logs group", trail.CloudWatchLogsLogGroupArn, standard.CloudWatchLogsGroupArn)
+	differs("cloudwatch logs role", trail.CloudWatchLogsRoleArn, standard.CloudWatchLogsRoleArn)
+	return issues
+
+}
+
+// kmsKeyId returns the key id of a kms key arn, or the key id given.
+func kmsKeyId(key string) string {
+	if i := strings.LastIndex(key, ":key/"); i >= 0 {
+		return key[i+len(":key/"):]
+	}
+	return key
+}
+
+// EnsureTrails reports drift in every trail of the accounts. If apply is
+// set the standard trail is created, or updated to match the standard,
+// and logging is started.
+func (o *Organization) EnsureTrails(ctx context.Context, accounts []*organizations.Account, standard *StandardTrail, apply bool) ([]*TrailDrift, AccountErrors) {
+
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		return o.ensureTrailForAccount(ctx, account, standard, apply)
+	})
+
+	drift := make([]*TrailDrift, 0, 100)
+	for _, result := range results {
+		if result.Err == nil {
+			drift = append(drift, result.Value.([]*TrailDrift)...)
+		}
+	}
+	return drift, Errors(results)
+
+}
+
+func (o *Organization) ensureTrailForAccount(ctx context.Context, account *organizations.Account, standard *StandardTrail, apply bool) ([]*TrailDrift, error) {
+
+	accountid := *account.Id
+	trails, err := o.GetTrailsForAccount(ctx, accountid)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := make([]*TrailDrift, 0, 10)
+	var found *cloudtrail.Trail
+	var standardDrift []*TrailDrift
+	logging := false
+
+	for _, trail := range trails {
+		if trailOwner(trail) != accountid || aws.BoolValue(trail.IsOrganizationTrail) || trail.HomeRegion == nil {
+			continue
+		}
+		svc, err := o.clients.CloudTrail(accountid, *trail.HomeRegion)
+		if err != nil {
+			return nil, fmt.Errorf("could not assume role: %s", err)
+		}
+		status, err := svc.GetTrailStatusWithContext(ctx, &cloudtrail.GetTrailStatusInput{
+			Name: trail.TrailARN,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not get status of trail %s: %s", *trail.TrailARN, err)
+		}
+
+		var expected *StandardTrail
+		if aws.StringValue(trail.Name) == standard.Name {
+			found, expected, logging = trail, standard, aws.BoolValue(status.IsLogging)
+			// a trail cannot move home region, report it rather than
+			// create a second standard trail
+			if *trail.HomeRegion != standard.Region {
+				issue := fmt.Sprintf("home region is %q, expected %q", *trail.HomeRegion, standard.Region)
+				drift = append(drift, &TrailDrift{Account: account, Region: *trail.HomeRegion, TrailArn: *trail.TrailARN, Issue: issue, Standard: true})
+			}
+		}
+		for _, issue := range trailDrift(trail, aws.BoolValue(status.IsLogging), expected) {
+			d := &TrailDrift{Account: account, Region: *trail.HomeRegion, TrailArn: *trail.TrailARN, Issue: issue, Standard: expected != nil}
+			drift = append(drift, d)
+			if expected != nil {
+				standardDrift = append(standardDrift, d)
+			}
+		}
+	}
+
+	if found == nil {
+		missing := &TrailDrift{Account: account, Region: standard.Region, TrailArn: standard.Name, Issue: "standard trail missing", Standard: true}
+		drift = append(drift, missing)
+		standardDrift = append(standardDrift, missing)
+	}
+	if !apply || len(standardDrift) == 0 {
+		return drift, nil
+	}
+
+	// a trail is only updated from its home region
+	region := standard.Region
+	if found != nil {
+		region = *found.HomeRegion
+	}
+	svc, err := o.clients.CloudTrail(accountid, region)
+	if err != nil {
+		return nil, fmt.Errorf("could not assume role: %s", err)
+	}
+
+	if found == nil {
+		resp, err := svc.CreateTrailWithContext(ctx, &cloudtrail.CreateTrailInput{
+			Name:                       aws.String(standard.Name),
+			S3BucketName:               aws.String(standard.Bucket),
+			S3KeyPrefix:                optionalString(standard.KeyPrefix),
+			KmsKeyId:                   optionalString(standard.KmsKeyId),
+			EnableLogFileValidation:    aws.Bool(standard.LogFileValidation),
+			IsMultiRegionTrail:         aws.Bool(true),
+			IncludeGlobalServiceEvents: aws.Bool(true),
+			CloudWatchLogsLogGroupArn:  optionalString(standard.CloudWatchLogsGroupArn),
+			CloudWatchLogsRoleArn:      optionalString(standard.CloudWatchLogsRoleArn),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not create trail %s: %s", standard.Name, err)
+		}
+		found = &cloudtrail.Trail{TrailARN: resp.TrailARN}
+	} else {
+		// empty strings clear a setting in UpdateTrail
+		_, err := svc.UpdateTrailWithContext(ctx, &cloudtrail.UpdateTrailInput{
+			Name:                       found.TrailARN,
+			S3BucketName:               aws.String(standard.Bucket),
+			S3KeyPrefix:                aws.String(standard.KeyPrefix),
+			KmsKeyId:                   aws.String(standard.KmsKeyId),
+			EnableLogFileValidation:    aws.Bool(standard.LogFileValidation),
+			IsMultiRegionTrail:         aws.Bool(true),
+			IncludeGlobalServiceEvents: aws.Bool(true),
+			CloudWatchLogsLogGroupArn:  aws.String(standard.CloudWatchLogsGroupArn),
+			CloudWatchLogsRoleArn:      aws.String(standard.CloudWatchLogsRoleArn),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not update trail %s: %s", *found.TrailARN, err)
+		}
+	}
+	if !logging {
+		_, err := svc.StartLoggingWithContext(ctx, &cloudtrail.StartLoggingInput{Name: found.TrailARN})
+		if err != nil {
+			return nil, fmt.Errorf("could not start logging for trail %s: %s", *found.TrailARN, err)
+		}
+	}
+	for _, d := range standardDrift {
+		d.TrailArn = *found.TrailARN
+		d.Fixed = true
+	}
+	return drift, nil
+
+}
+
+func optionalString(s string) *string {
+	if len(s) == 0 {
+		return nil
+	}
+	return aws.String(s)
+}
@@ -0,0 +1,298 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"gopkg.in/yaml.v2"
+)
+
+// OrgState describes the desired organizational units, accounts and
+// service control policy attachments of an organization. Applying a state
+// only ever adds to the organization, anything not in the state is left alone.
+type OrgState struct {
+	OrganizationalUnits []string        `yaml:"organizational_units"`
+	Accounts            []*AccountState `yaml:"accounts"`
+	Policies            []*PolicyState  `yaml:"policies"`
+}
+
+type AccountState struct {
+	Name   string            `yaml:"name"`
+	Email  string            `yaml:"email"`
+	Parent string            `yaml:"parent"`
+	Tags   map[string]string `yaml:"tags"`
+}
+
+type PolicyState struct {
+	Name    string   `yaml:"name"`
+	Targets []string `yaml:"targets"`
+}
+
+// Change is a single step needed to bring the organization in line with
+// a state.
+type Change struct {
+	Action string
+	Target string
+	Detail string
+	apply  func() error
+}
+
+type Plan []*Change
+
+func LoadState(path string) (*OrgState, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state := &OrgState{}
+	err = yaml.UnmarshalStrict(data, state)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse state file %s: %s", path, err)
+	}
+	err = state.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+
+}
+
+func (s *OrgState) Validate() error {
+
+	emails := make(map[string]bool)
+	for _, account := range s.Accounts {
+		if len(account.Name) == 0 || len(account.Email) == 0 {
+			return fmt.Errorf("every account needs a name and an email")
+		}
+		email := strings.ToLower(account.Email)
+		if emails[email] {
+			return fmt.Errorf("account email %s is used more than once", account.Email)
+		}
+		emails[email] = true
+	}
+	for _, policy := range s.Policies {
+		if len(policy.Name) == 0 {
+			return fmt.Errorf("every policy needs a name")
+		}
+	}
+	return nil
+
+}
+
+// Plan compares a state with the live organization and returns the
+// changes needed, in the order they must be applied.
+func (o *Organization) Plan(ctx context.Context, state *OrgState) (Plan, error) {
+
+	tree, err := o.GetOUTree()
+	if err != nil {
+		return nil, err
+	}
+
+	// index the live organization by ou path and account email
+	ous := make(map[string]*OrganizationalUnit)
+	parents := make(map[string]string)
+	byEmail := make(map[string]*organizations.Account)
+	var walk func(node *OrganizationalUnit)
+	walk = func(node *OrganizationalUnit) {
+		ous[node.Path] = node
+		for _, account := range node.Accounts {
+			parents[*account.Id] = node.Path
+			if account.Email != nil {
+				byEmail[strings.ToLower(*account.Email)] = account
+			}
+		}
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(tree)
+
+	plan := make(Plan, 0, 20)
+
+	// organizational units, including any implied by account parents and
+	// policy targets, created from the top down
+	wanted := make(map[string]bool)
+	want := func(path string) {
+		names := splitOUPath(path)
+		for i := 1; i <= len(names); i++ {
+			wanted["/"+strings.Join(names[:i], "/")] = true
+		}
+	}
+	for _, path := range state.OrganizationalUnits {
+		want(path)
+	}
+	for _, account := range state.Accounts {
+		want(account.Parent)
+	}
+	for _, policy := range state.Policies {
+		for _, target := range policy.Targets {
+			if strings.HasPrefix(target, "/") {
+				want(target)
+			}
+		}
+	}
+	paths := make([]string, 0, len(wanted))
+	for path := range wanted {
+		if _, ok := ous[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		di, dj := len(splitOUPath(paths[i])), len(splitOUPath(paths[j]))
+		if di != dj {
+			return di < dj
+		}
+		return paths[i] < paths[j]
+	})
+	planned := make(map[string]bool)
+	for _, path := range paths {
+		path := path
+		planned[path] = true
+		plan = append(plan, &Change{
+			Action: "create-ou",
+			Target: path,
+			apply: func() error {
+				_, err := o.CreateOU(path)
+				return err
+			},
+		})
+	}
+
+	// accounts
+	for _, a := range state.Accounts {
+		a := a
+		parent := "/" + strings.Join(splitOUPath(a.Parent), "/")
+		account, ok := byEmail[strings.ToLower(a.Email)]
+
+		if !ok {
+			plan = append(plan, &Change{
+				Action: "create-account",
+				Target: a.Name,
+				Detail: fmt.Sprintf("%s in %s", a.Email, parent),
+				apply: func() error {
+					status, err := o.CreateAccount(a.Name, a.Email, a.Tags)
+					if err != nil {
+						return err
+					}
+					status, err = o.WaitForAccountStatus(status)
+					if err != nil {
+						return err
+					}
+					if parent != "/" {
+						return o.MoveAccount(*status.AccountId, parent)
+					}
+					return nil
+				},
+			})
+			continue
+		}
+
+		// an account without a parent stays wherever it is
+		accountid := *account.Id
+		if len(a.Parent) > 0 && parents[accountid] != parent {
+			plan = append(plan, &Change{
+				Action: "move-account",
+				Target: a.Name,
+				Detail: fmt.Sprintf("%s from %s to %s", accountid, parents[accountid], parent),
+				apply: func() error {
+					return o.MoveAccount(accountid, parent)
+				},
+			})
+		}
+
+		if len(a.Tags) > 0 {
+			current, err := o.GetAccountTags(ctx, accountid)
+			if err != nil {
+				return nil, err
+			}
+			tags := make(map[string]string)
+			for key, value := range a.Tags {
+				if v, ok := current[key]; !ok || v != value {
+					tags[key] = value
+				}
+			}
+			if len(tags) > 0 {
+				plan = append(plan, &Change{
+					Action: "tag-account",
+					Target: a.Name,
+					Detail: fmt.Sprintf("%s %s", accountid, formatTags(tags)),
+					apply: func() error {
+						return o.TagAccount(accountid, tags)
+					},
+				})
+			}
+		}
+	}
+
+	// policy attachments
+	for _, p := range state.Policies {
+		policyid, err := o.GetPolicyId(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range p.Targets {
+			target := target
+			if strings.HasPrefix(target, "/") {
+				target = "/" + strings.Join(splitOUPath(target), "/")
+			}
+			if !planned[target] {
+				attached, err := o.GetPoliciesForTarget(target)
+				if err != nil {
+					return nil, err
+				}
+				found := false
+				for _, policy := range attached {
+					if *policy.Id == policyid {
+						found = true
+						break
+					}
+				}
+				if found {
+					continue
+				}
+			}
+			plan = append(plan, &Change{
+				Action: "attach-policy",
+				Target: target,
+				Detail: p.Name,
+				apply: func() error {
+					return o.AttachPolicy(policyid, target)
+				},
+			})
+		}
+	}
+
+	return plan, nil
+
+}
+
+// Apply makes each change in a plan in order, stopping at the first failure.
+// progress is called before each change is made.
+func (o *Organization) Apply(plan Plan, progress func(change *Change)) error {
+
+	for _, change := range plan {
+		if progress != nil {
+			progress(change)
+		}
+		err := change.apply()
+		if err != nil {
+			return fmt.Errorf("%s %s failed: %s", change.Action, change.Target, err)
+		}
+	}
+	return nil
+
+}
+
+func formatTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for key, value := range tags {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,103 @@
+package aws
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+)
+
+func TestPlanApply(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+	org.SetLogOutput(ioutil.Discard)
+
+	state := &OrgState{
+		OrganizationalUnits: []string{"/Workloads/Prod", "/Sandbox"},
+		Accounts: []*AccountState{
+			{Name: "prod-app", Email: "PROD-APP@example.com", Parent: "/Workloads/Prod", Tags: map[string]string{"env": "prod", "owner": "payments"}},
+			{Name: "dev-app", Email: "dev-app@example.com", Parent: "/Sandbox", Tags: map[string]string{"env": "sandbox"}},
+			{Name: "data-lake", Email: "data-lake@example.com", Parent: "/Workloads/Data", Tags: map[string]string{"env": "prod"}},
+		},
+		Policies: []*PolicyState{
+			{Name: "DenyLeave", Targets: []string{"/Workloads", "/Sandbox"}},
+		},
+	}
+
+	plan, err := org.Plan(context.Background(), state)
+	if err != nil {
+		t.Fatalf("organization Plan error: %s", err)
+	}
+
+	expected := []struct {
+		action string
+		target string
+	}{
+		{"create-ou", "/Sandbox"},
+		{"create-ou", "/Workloads/Data"},
+		{"move-account", "dev-app"},
+		{"tag-account", "dev-app"},
+		{"create-account", "data-lake"},
+		{"attach-policy", "/Sandbox"},
+	}
+	if len(plan) != len(expected) {
+		for _, change := range plan {
+			t.Logf("planned %s %s %s", change.Action, change.Target, change.Detail)
+		}
+		t.Fatalf("organization Plan returned %d changes, expected %d", len(plan), len(expected))
+	}
+	for i, e := range expected {
+		if plan[i].Action != e.action || plan[i].Target != e.target {
+			t.Errorf("organization Plan change %d is %s %s, expected %s %s", i, plan[i].Action, plan[i].Target, e.action, e.target)
+		}
+	}
+
+	err = org.Apply(plan, nil)
+	if err != nil {
+		t.Fatalf("organization Apply error: %s", err)
+	}
+
+	plan, err = org.Plan(context.Background(), state)
+	if err != nil {
+		t.Fatalf("organization Plan error: %s", err)
+	}
+	if len(plan) != 0 {
+		for _, change := range plan {
+			t.Logf("planned %s %s %s", change.Action, change.Target, change.Detail)
+		}
+		t.Errorf("organization Plan after Apply returned %d changes, expected none", len(plan))
+	}
+
+}
+
+func TestPlanAccountWithoutParent(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	state := &OrgState{
+		Accounts: []*AccountState{
+			{Name: "prod-app", Email: "prod-app@example.com"},
+			{Name: "data-lake", Email: "data-lake@example.com"},
+		},
+	}
+
+	plan, err := org.Plan(context.Background(), state)
+	if err != nil {
+		t.Fatalf("organization Plan error: %s", err)
+	}
+
+	// an existing account is left where it is, a new one is created in
+	// the root
+	if len(plan) != 1 || plan[0].Action != "create-account" || plan[0].Detail != "data-lake@example.com in /" {
+		for _, change := range plan {
+			t.Logf("planned %s %s %s", change.Action, change.Target, change.Detail)
+		}
+		t.Errorf("organization Plan returned %d changes, expected only create-account data-lake in /", len(plan))
+	}
+
+}
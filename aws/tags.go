@@ -0,0 +1,155 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+// GetAccountTags returns the organizations tags on an account.
+func (o *Organization) GetAccountTags(ctx context.Context, accountid string) (map[string]string, error) {
+
+	params := &organizations.ListTagsForResourceInput{
+		ResourceId: aws.String(accountid),
+	}
+	limit := o.newPageLimit("tags of account " + accountid)
+	tags := make(map[string]string)
+	err := o.svc.ListTagsForResourcePagesWithContext(ctx, params, func(page *organizations.ListTagsForResourceOutput, last bool) bool {
+		for _, tag := range page.Tags {
+			tags[*tag.Key] = *tag.Value
+		}
+		return limit.next(last)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+
+}
+
+// TagAccount adds or updates tags on an account.
+func (o *Organization) TagAccount(accountid string, tags map[string]string) error {
+
+	if len(tags) == 0 {
+		return nil
+	}
+	_, err := o.svc.TagResource(&organizations.TagResourceInput{
+		ResourceId: aws.String(accountid),
+		Tags:       organizationsTags(tags),
+	})
+	return err
+
+}
+
+// UntagAccount removes tags from an account.
+func (o *Organization) UntagAccount(accountid string, keys []string) error {
+
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := o.svc.UntagResource(&organizations.UntagResourceInput{
+		ResourceId: aws.String(accountid),
+		TagKeys:    aws.StringSlice(keys),
+	})
+	return err
+
+}
+
+// GetTagsForAccounts fetches the tags of many accounts at once.
+func (o *Organization) GetTagsForAccounts(ctx context.Context, accounts []*organizations.Account) (map[string]map[string]string, AccountErrors) {
+
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		return o.GetAccountTags(ctx, *account.Id)
+	})
+
+	tags := make(map[string]map[string]string)
+	for _, result := range results {
+		if result.Err == nil {
+			tags[*result.Account.Id] = result.Value.(map[string]string)
+		}
+	}
+	return tags, Errors(results)
+
+}
+
+// AccountFilter selects accounts by their tags. Every tag must match.
+type AccountFilter struct {
+	Tags map[string]string
+}
+
+// ParseAccountFilter parses a filter such as tag:env=prod,tag:owner=payments
+func ParseAccountFilter(s string) (*AccountFilter, error) {
+
+	filter := &AccountFilter{Tags: make(map[string]string)}
+	for _, term := range SplitList(s) {
+		if !strings.HasPrefix(term, "tag:") {
+			return nil, fmt.Errorf("invalid filter %s, expected tag:key=value", term)
+		}
+		kv := strings.SplitN(strings.TrimPrefix(term, "tag:"), "=", 2)
+		if len(kv) != 2 || len(kv[0]) == 0 {
+			return nil, fmt.Errorf("invalid filter %s, expected tag:key=value", term)
+		}
+		filter.Tags[kv[0]] = kv[1]
+	}
+	return filter, nil
+
+}
+
+// Match reports whether an account with the given tags passes the filter.
+// A nil filter matches everything.
+func (f *AccountFilter) Match(tags map[string]string) bool {
+	if f == nil {
+		return true
+	}
+	for key, value := range f.Tags {
+		if v, ok := tags[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterAccounts returns the accounts whose tags match the filter.
+func (o *Organization) FilterAccounts(ctx context.Context, accounts []*organizations.Account, filter *AccountFilter) ([]*organizations.Account, error) {
+
+	if filter == nil || len(filter.Tags) == 0 {
+		return accounts, nil
+	}
+
+	tags, errs := o.GetTagsForAccounts(ctx, accounts)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("could not get tags for %s", errs[0])
+	}
+
+	selected := make([]*organizations.Account, 0, len(accounts))
+	for _, account := range accounts {
+		if filter.Match(tags[*account.Id]) {
+			selected = append(selected, account)
+		}
+	}
+	return selected, nil
+
+}
+
+func organizationsTags(tags map[string]string) []*organizations.Tag {
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	otags := make([]*organizations.Tag, 0, len(tags))
+	for _, key := range keys {
+		otags = append(otags, &organizations.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(tags[key]),
+		})
+	}
+	return otags
+
+}
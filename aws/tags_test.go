@@ -0,0 +1,80 @@
+package aws
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFilterAccounts(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+	accounts, err := org.GetActiveAccounts()
+	if err != nil {
+		t.Fatalf("organization GetActiveAccounts error: %s", err)
+	}
+
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"", []string{"111111111111", "222222222222", "333333333333", "444444444444"}},
+		{"tag:env=prod", []string{"222222222222", "444444444444"}},
+		{"tag:env=prod,tag:owner=payments", []string{"222222222222"}},
+		{"tag:env=staging", []string{}},
+	}
+	for _, test := range tests {
+		filter, err := ParseAccountFilter(test.filter)
+		if err != nil {
+			t.Fatalf("ParseAccountFilter(%q) error: %s", test.filter, err)
+		}
+		selected, err := org.FilterAccounts(context.Background(), accounts, filter)
+		if err != nil {
+			t.Fatalf("FilterAccounts(%q) error: %s", test.filter, err)
+		}
+		if len(selected) != len(test.want) {
+			t.Errorf("FilterAccounts(%q) returned %d accounts, expected %d", test.filter, len(selected), len(test.want))
+			continue
+		}
+		for i, account := range selected {
+			if *account.Id != test.want[i] {
+				t.Errorf("FilterAccounts(%q) account %d is %s, expected %s", test.filter, i, *account.Id, test.want[i])
+			}
+		}
+	}
+
+	for _, bad := range []string{"env=prod", "tag:env", "tag:=prod"} {
+		if _, err := ParseAccountFilter(bad); err == nil {
+			t.Errorf("ParseAccountFilter(%q) should fail", bad)
+		}
+	}
+
+}
+
+func TestTagAccount(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	err = org.TagAccount("333333333333", map[string]string{"env": "test", "team": "core"})
+	if err != nil {
+		t.Fatalf("organization TagAccount error: %s", err)
+	}
+	err = org.UntagAccount("333333333333", []string{"owner"})
+	if err != nil {
+		t.Fatalf("organization UntagAccount error: %s", err)
+	}
+
+	tags, err := org.GetAccountTags(context.Background(), "333333333333")
+	if err != nil {
+		t.Fatalf("organization GetAccountTags error: %s", err)
+	}
+	if got := formatTags(tags); got != "env=test,team=core" {
+		t.Errorf("organization account tags are %s, expected env=test,team=core", got)
+	}
+
+}
@@ -1,161 +1,245 @@
 package aws
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudtrail"
-	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/organizations"
 )
 
+type TrailsPerAccount map[string][]string
 
-func (o *Organization) GetTrailArnsForAccount(accountid string) ([]string, error) {
-
-	regions := o.GetRegions()
-	role := fmt.Sprintf("arn:aws:iam::%v:role/OrganizationAccountAccessRole", accountid)
+func (s TrailsPerAccount) Accounts() []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
 
-	sess := session.Must(session.NewSession())
-	stssvc := sts.New(sess)
+// TrailPurgeOptions limits which trails a purge deletes. Patterns are
+// matched against the trail name.
+type TrailPurgeOptions struct {
+	Names   []string
+	Exclude []string
+}
 
-	params := &sts.AssumeRoleInput{
-		RoleArn:         aws.String(role),                     // Required
-		RoleSessionName: aws.String("organizer-cloudtrailer"), // Required
-		DurationSeconds: aws.Int64(900),
-	}
-	resp, err := stssvc.AssumeRole(params)
-	if err != nil {
-		fmt.Printf("error: could not assume role %s\n", err.Error())
-		return nil, err
-	}
+// TrailDeletion is a trail a purge would delete, or the reason it is kept.
+type TrailDeletion struct {
+	Account *organizations.Account
+	Trail   *cloudtrail.Trail
+	Skip    string
+}
 
-	trailmap := make(map[string]bool, 100)
+// GetTrailsForAccount describes the trails visible in every region of an
+// account. A multi-region trail is listed once, as seen from its home region.
+func (o *Organization) GetTrailsForAccount(ctx context.Context, accountid string) ([]*cloudtrail.Trail, error) {
 
-	for _, region := range regions {
+	trailmap := make(map[string]*cloudtrail.Trail, 100)
 
-		config := aws.NewConfig().WithCredentials(
-			credentials.NewStaticCredentials(
-				*resp.Credentials.AccessKeyId,
-				*resp.Credentials.SecretAccessKey,
-				*resp.Credentials.SessionToken,
-			),
-		).WithRegion(region)
+	for _, region := range o.GetRegions() {
 
-		sess = session.New(config)
-		svc := cloudtrail.New(sess)
+		svc, err := o.clients.CloudTrail(accountid, region)
+		if err != nil {
+			return nil, fmt.Errorf("could not assume role: %s", err)
+		}
 
 		params := &cloudtrail.DescribeTrailsInput{
 			IncludeShadowTrails: aws.Bool(true),
 			TrailNameList:       []*string{},
 		}
-		resp, err := svc.DescribeTrails(params)
-
+		resp, err := svc.DescribeTrailsWithContext(ctx, params)
 		if err != nil {
-			// Print the error, cast err to awserr.Error to get the Code and
-			// Message from an error.
-			fmt.Println(err.Error())
-			return nil, err
+			return nil, fmt.Errorf("could not describe trails in region %s: %s", region, err)
 		}
 
-		// Pretty-print the response data.
-		// fmt.Println(resp)
-		if len(resp.TrailList) == 0 {
-			fmt.Printf("warning: no trails defined in account %s in region %s\n", accountid, region)
-			continue
-		} else {
-			for _, trail := range resp.TrailList {
-				trailmap[*trail.TrailARN] = true
+		for _, trail := range resp.TrailList {
+			if _, ok := trailmap[*trail.TrailARN]; !ok || stringValue(trail.HomeRegion) == region {
+				trailmap[*trail.TrailARN] = trail
 			}
 		}
 
 	}
-	trails := make([]string, len(trailmap))
-	i := 0
-	for trail, _ := range trailmap {
-		trails[i] = trail
-		i++
-	}
 
+	trails := make([]*cloudtrail.Trail, 0, len(trailmap))
+	for _, trail := range trailmap {
+		trails = append(trails, trail)
+	}
+	sort.Slice(trails, func(i, j int) bool { return *trails[i].TrailARN < *trails[j].TrailARN })
 	return trails, nil
 
 }
 
-func (o *Organization) PurgeTrailsForAccount(accountid string) ([]string, error) {
-
-	regions := o.GetRegions()
-	role := fmt.Sprintf("arn:aws:iam::%v:role/OrganizationAccountAccessRole", accountid)
+func (o *Organization) GetTrailArnsForAccount(ctx context.Context, accountid string) ([]string, error) {
 
-	sess := session.Must(session.NewSession())
-	stssvc := sts.New(sess)
-
-	params := &sts.AssumeRoleInput{
-		RoleArn:         aws.String(role),                     // Required
-		RoleSessionName: aws.String("organizer-cloudtrailer"), // Required
-		DurationSeconds: aws.Int64(900),
-	}
-	resp, err := stssvc.AssumeRole(params)
+	trails, err := o.GetTrailsForAccount(ctx, accountid)
 	if err != nil {
-		fmt.Printf("error: could not assume role %s\n", err.Error())
 		return nil, err
 	}
+	arns := make([]string, 0, len(trails))
+	for _, trail := range trails {
+		arns = append(arns, *trail.TrailARN)
+	}
+	if len(arns) == 0 {
+		o.logf("warning: no trails defined in account %s\n", accountid)
+	}
+	return arns, nil
 
-	trailmap := make(map[string]bool, 100)
-
-	for _, region := range regions {
+}
 
-		config := aws.NewConfig().WithCredentials(
-			credentials.NewStaticCredentials(
-				*resp.Credentials.AccessKeyId,
-				*resp.Credentials.SecretAccessKey,
-				*resp.Credentials.SessionToken,
-			),
-		).WithRegion(region)
+func (o *Organization) GetTrails(ctx context.Context, accounts []*organizations.Account) (TrailsPerAccount, AccountErrors) {
 
-		sess = session.New(config)
-		svc := cloudtrail.New(sess)
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		return o.GetTrailArnsForAccount(ctx, *account.Id)
+	})
 
-		params := &cloudtrail.DescribeTrailsInput{
-			IncludeShadowTrails: aws.Bool(true),
-			TrailNameList:       []*string{},
+	trails := make(TrailsPerAccount)
+	for _, result := range results {
+		if result.Err == nil {
+			trails[*result.Account.Id] = result.Value.([]string)
 		}
-		resp, err := svc.DescribeTrails(params)
+	}
+	return trails, Errors(results)
+
+}
+
+// PlanTrailPurge works out which trails a purge of the accounts would
+// delete. Nothing is deleted.
+func (o *Organization) PlanTrailPurge(ctx context.Context, accounts []*organizations.Account, opts *TrailPurgeOptions) ([]*TrailDeletion, AccountErrors) {
 
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		trails, err := o.GetTrailsForAccount(ctx, *account.Id)
 		if err != nil {
-			// Print the error, cast err to awserr.Error to get the Code and
-			// Message from an error.
-			fmt.Println(err.Error())
 			return nil, err
 		}
+		return planTrailDeletions(account, trails, opts), nil
+	})
 
-		// Pretty-print the response data.
-		// fmt.Println(resp)
-		if len(resp.TrailList) == 0 {
-			fmt.Printf("warning: no trails defined in account %s in region %s\n", accountid, region)
-			continue
-		} else {
-			for _, trail := range resp.TrailList {
-				trailmap[*trail.TrailARN] = true
-				params := &cloudtrail.DeleteTrailInput{
-					Name: trail.TrailARN,
-				}
-				_, err := svc.DeleteTrail(params)
-				if err != nil {
-					// Print the error, cast err to awserr.Error to get the Code and
-					// Message from an error.
-					fmt.Println(err.Error())
-					return nil, err
-				}
-			}
+	deletions := make([]*TrailDeletion, 0, 100)
+	for _, result := range results {
+		if result.Err == nil {
+			deletions = append(deletions, result.Value.([]*TrailDeletion)...)
+		}
+	}
+	return deletions, Errors(results)
+
+}
+
+func planTrailDeletions(account *organizations.Account, trails []*cloudtrail.Trail, opts *TrailPurgeOptions) []*TrailDeletion {
+
+	deletions := make([]*TrailDeletion, 0, len(trails))
+	for _, trail := range trails {
+		deletion := &TrailDeletion{Account: account, Trail: trail}
+		name := stringValue(trail.Name)
+		switch {
+		case trail.IsOrganizationTrail != nil && *trail.IsOrganizationTrail:
+			// owned by the master account and would only fail anyway
+			deletion.Skip = "organization trail"
+		case trail.HomeRegion == nil:
+			deletion.Skip = "unknown home region"
+		case trailOwner(trail) != *account.Id:
+			deletion.Skip = "owned by another account"
+		case len(opts.Names) > 0 && !matchAny(opts.Names, name):
+			deletion.Skip = "does not match -name"
+		case matchAny(opts.Exclude, name):
+			deletion.Skip = "matches -exclude"
 		}
+		deletions = append(deletions, deletion)
+	}
+	return deletions
 
+}
+
+// trailOwner returns the account id from a trail arn.
+func trailOwner(trail *cloudtrail.Trail) string {
+	parts := strings.SplitN(stringValue(trail.TrailARN), ":", 6)
+	if len(parts) < 6 {
+		return ""
 	}
-	trails := make([]string, len(trailmap))
-	i := 0
-	for trail, _ := range trailmap {
-		trails[i] = trail
-		i++
+	return parts[4]
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
 	}
+	return false
+}
 
-	return trails, nil
+// trailAuditRecord is one line of the purge audit log.
+type trailAuditRecord struct {
+	Time      time.Time `json:"time"`
+	Action    string    `json:"action"`
+	AccountId string    `json:"account_id"`
+	Region    string    `json:"region"`
+	TrailArn  string    `json:"trail_arn"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// DeleteTrails deletes the trails of a purge plan that are not skipped,
+// each from its home region. Every attempt is written to audit as a line
+// of json.
+func (o *Organization) DeleteTrails(ctx context.Context, deletions []*TrailDeletion, audit io.Writer) AccountErrors {
+
+	byAccount := make(map[string][]*TrailDeletion)
+	accounts := make([]*organizations.Account, 0, 20)
+	for _, deletion := range deletions {
+		if len(deletion.Skip) > 0 {
+			continue
+		}
+		id := *deletion.Account.Id
+		if _, ok := byAccount[id]; !ok {
+			accounts = append(accounts, deletion.Account)
+		}
+		byAccount[id] = append(byAccount[id], deletion)
+	}
+
+	var mu sync.Mutex
+	logDeletion := func(deletion *TrailDeletion, err error) {
+		record := trailAuditRecord{
+			Time:      time.Now().UTC(),
+			Action:    "DeleteTrail",
+			AccountId: *deletion.Account.Id,
+			Region:    stringValue(deletion.Trail.HomeRegion),
+			TrailArn:  *deletion.Trail.TrailARN,
+		}
+		if err != nil {
+			record.Error = err.Error()
+		}
+		line, _ := json.Marshal(record)
+		mu.Lock()
+		fmt.Fprintf(audit, "%s\n", line)
+		mu.Unlock()
+	}
+
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		for _, deletion := range byAccount[*account.Id] {
+			svc, err := o.clients.CloudTrail(*account.Id, *deletion.Trail.HomeRegion)
+			if err != nil {
+				return nil, fmt.Errorf("could not assume role: %s", err)
+			}
+			_, err = svc.DeleteTrailWithContext(ctx, &cloudtrail.DeleteTrailInput{
+				Name: deletion.Trail.TrailARN,
+			})
+			logDeletion(deletion, err)
+			if err != nil {
+				return nil, fmt.Errorf("could not delete trail %s: %s", *deletion.Trail.TrailARN, err)
+			}
+		}
+		return nil, nil
+	})
+	return Errors(results)
 
 }
@@ -0,0 +1,274 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+func TestPlanTrailDeletions(t *testing.T) {
+
+	account := &organizations.Account{Id: aws.String("222222222222"), Name: aws.String("prod-app")}
+	trail := func(name string, org bool) *cloudtrail.Trail {
+		return &cloudtrail.Trail{
+			Name:                aws.String(name),
+			TrailARN:            aws.String("arn:aws:cloudtrail:us-east-1:222222222222:trail/" + name),
+			HomeRegion:          aws.String("us-east-1"),
+			IsOrganizationTrail: aws.Bool(org),
+		}
+	}
+	trails := []*cloudtrail.Trail{
+		trail("org-trail", true),
+		trail("legacy-audit", false),
+		trail("legacy-keep", false),
+		trail("security", false),
+		{
+			Name:       aws.String("shared"),
+			TrailARN:   aws.String("arn:aws:cloudtrail:us-east-1:111111111111:trail/shared"),
+			HomeRegion: aws.String("us-east-1"),
+		},
+	}
+
+	tests := []struct {
+		opts *TrailPurgeOptions
+		want []string
+	}{
+		{&TrailPurgeOptions{}, []string{"organization trail", "", "", "", "owned by another account"}},
+		{&TrailPurgeOptions{Names: []string{"legacy-*"}}, []string{"organization trail", "", "", "does not match -name", "owned by another account"}},
+		{&TrailPurgeOptions{Names: []string{"legacy-*"}, Exclude: []string{"*-keep"}}, []string{"organization trail", "", "matches -exclude", "does not match -name", "owned by another account"}},
+	}
+	for i, test := range tests {
+		deletions := planTrailDeletions(account, trails, test.opts)
+		if len(deletions) != len(trails) {
+			t.Fatalf("test %d: planTrailDeletions returned %d deletions, expected %d", i, len(deletions), len(trails))
+		}
+		for j, deletion := range deletions {
+			if deletion.Skip != test.want[j] {
+				t.Errorf("test %d: trail %s skip is %q, expected %q", i, *deletion.Trail.Name, deletion.Skip, test.want[j])
+			}
+		}
+	}
+
+}
+
+func TestDeleteTrailsSkipped(t *testing.T) {
+
+	org, err := NewMockOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+
+	// a plan with nothing to delete must not touch any account
+	account := &organizations.Account{Id: aws.String("222222222222"), Name: aws.String("prod-app")}
+	deletions := []*TrailDeletion{{
+		Account: account,
+		Trail:   &cloudtrail.Trail{Name: aws.String("org-trail"), TrailARN: aws.String("arn"), HomeRegion: aws.String("us-east-1")},
+		Skip:    "organization trail",
+	}}
+	var audit bytes.Buffer
+	errs := org.DeleteTrails(context.Background(), deletions, &audit)
+	if len(errs) != 0 || audit.Len() != 0 {
+		t.Errorf("DeleteTrails should skip every trail, got %d errors and audit %q", len(errs), audit.String())
+	}
+
+}
+
+func TestTrailDrift(t *testing.T) {
+
+	standard := &StandardTrail{Name: "organizer", Bucket: "org-trails", LogFileValidation: true}
+	good := &cloudtrail.Trail{
+		Name:                     aws.String("organizer"),
+		S3BucketName:             aws.String("org-trails"),
+		LogFileValidationEnabled: aws.Bool(true),
+		IsMultiRegionTrail:       aws.Bool(true),
+	}
+	bad := &cloudtrail.Trail{
+		Name:                     aws.String("legacy"),
+		S3BucketName:             aws.String("old-bucket"),
+		LogFileValidationEnabled: aws.Bool(false),
+		IsMultiRegionTrail:       aws.Bool(false),
+	}
+	keyId := "1234abcd-12ab-34cd-56ef-1234567890ab"
+	keyArn := "arn:aws:kms:us-east-1:111111111111:key/" + keyId
+	keyed := &cloudtrail.Trail{
+		Name:                     aws.String("organizer"),
+		S3BucketName:             aws.String("org-trails"),
+		KmsKeyId:                 aws.String(keyArn),
+		LogFileValidationEnabled: aws.Bool(true),
+		IsMultiRegionTrail:       aws.Bool(true),
+	}
+
+	tests := []struct {
+		trail    *cloudtrail.Trail
+		logging  bool
+		standard *StandardTrail
+		want     int
+	}{
+		{good, true, standard, 0},
+		{good, false, standard, 1},
+		{bad, true, nil, 2},
+		{bad, false, nil, 3},
+		// log file validation, single region and the bucket
+		{bad, true, standard, 3},
+		// cloudtrail reports the key arn of a key given by id
+		{keyed, true, &StandardTrail{Name: "organizer", Bucket: "org-trails", LogFileValidation: true, KmsKeyId: keyId}, 0},
+		{keyed, true, &StandardTrail{Name: "organizer", Bucket: "org-trails", LogFileValidation: true, KmsKeyId: keyArn}, 0},
+		{keyed, true, standard, 1},
+		{good, true, &StandardTrail{Name: "organizer", Bucket: "org-trails", LogFileValidation: true, KmsKeyId: keyId}, 1},
+	}
+	for i, test := range tests {
+		issues := trailDrift(test.trail, test.logging, test.standard)
+		if len(issues) != test.want {
+			t.Errorf("test %d: trailDrift returned %d issues %v, expected %d", i, len(issues), issues, test.want)
+		}
+	}
+
+}
+
+func TestEnsureTrailsHomeRegion(t *testing.T) {
+
+	org, clients, err := newMockClientOrganization()
+	if err != nil {
+		t.Fatalf("could not create mock organization: %s", err)
+	}
+	moved := newMockTrail("222222222222", "organizer", "eu-west-1", true, false)
+	moved.S3BucketName = aws.String("old-bucket")
+	moved.LogFileValidationEnabled = aws.Bool(true)
+	clients.cloudtrail["222222222222"].trails = append(clients.cloudtrail["222222222222"].trails, moved)
+
+	// the standard trail of prod-app lives in another home region, dev-app
+	// has none
+	standard := &StandardTrail{Name: "organizer", Region: "us-east-1", Bucket: "org-trails", LogFileValidation: true}
+	accounts := mockAccounts(t, org, "222222222222", "333333333333")
+	drift, errs := org.EnsureTrails(context.Background(), accounts, standard, true)
+	if len(errs) != 0 {
+		t.Fatalf("EnsureTrails error: %s", errs)
+	}
+
+	issues := make(map[string]bool)
+	for _, d := range drift {
+		if d.Standard {
+			issues[fmt.Sprintf("%s %s fixed=%t", *d.Account.Id, d.Issue, d.Fixed)] = true
+		}
+	}
+	for _, issue := range []string{
+		`222222222222 home region is "eu-west-1", expected "us-east-1" fixed=false`,
+		`222222222222 s3 bucket is "old-bucket", expected "org-trails" fixed=true`,
+		`333333333333 standard trail missing fixed=true`,
+	} {
+		if !issues[issue] {
+			t.Errorf("EnsureTrails did not report %s, reported %v", issue, issues)
+		}
+	}
+
+	prod, dev := clients.cloudtrail["222222222222"], clients.cloudtrail["333333333333"]
+	if len(prod.created) != 0 || len(prod.updated) != 1 || prod.updated[0] != *moved.TrailARN {
+		t.Errorf("EnsureTrails created %v and updated %v in prod-app, expected only an update of %s", prod.created, prod.updated, *moved.TrailARN)
+	}
+	if len(dev.created) != 1 || dev.created[0] != "arn:aws:cloudtrail:us-east-1:333333333333:trail/organizer" {
+		t.Errorf("EnsureTrails created %v in dev-app, expected the standard trail in us-east-1", dev.created)
+	}
+
+}
+
+func TestSortTrailEvents(t *testing.T) {
+
+	at := func(id string, minutes int) *TrailEvent {
+		when := time.Date(2019, 5, 1, 12, minutes, 0, 0, time.UTC)
+		return &TrailEvent{Event: &cloudtrail.Event{EventId: aws.String(id), EventTime: &when}}
+	}
+	events := []*TrailEvent{at("c", 30), at("b", 10), at("d", 30), at("a", 5)}
+	sortTrailEvents(events)
+
+	ids := ""
+	for _, event := range events {
+		ids += *event.Event.EventId
+	}
+	if ids != "abcd" {
+		t.Errorf("sortTrailEvents ordered events %s, expected abcd", ids)
+	}
+
+}
+
+func TestStandardTrailValidate(t *testing.T) {
+
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"", true},
+		{"1234abcd-12ab-34cd-56ef-1234567890ab", true},
+		{"arn:aws:kms:us-east-1:111111111111:key/1234abcd-12ab-34cd-56ef-1234567890ab", true},
+		{"alias/org-trails", false},
+		{"arn:aws:kms:us-east-1:111111111111:alias/org-trails", false},
+	}
+	for _, test := range tests {
+		standard := &StandardTrail{Name: "organizer", Bucket: "org-trails", KmsKeyId: test.key}
+		if err := standard.Validate(); (err == nil) != test.valid {
+			t.Errorf("StandardTrail Validate with kms key %q returned %v", test.key, err)
+		}
+	}
+
+}
+
+func TestSummarizeEventSelectors(t *testing.T) {
+
+	advanced := func(fields ...string) *cloudtrail.AdvancedEventSelector {
+		selector := &cloudtrail.AdvancedEventSelector{}
+		for i := 0; i < len(fields); i += 2 {
+			selector.FieldSelectors = append(selector.FieldSelectors, &cloudtrail.AdvancedFieldSelector{
+				Field:  aws.String(fields[i]),
+				Equals: aws.StringSlice([]string{fields[i+1]}),
+			})
+		}
+		return selector
+	}
+
+	tests := []struct {
+		selectors  *cloudtrail.GetEventSelectorsOutput
+		management string
+		data       int
+	}{
+		{&cloudtrail.GetEventSelectorsOutput{}, "none", 0},
+		{&cloudtrail.GetEventSelectorsOutput{EventSelectors: []*cloudtrail.EventSelector{{
+			IncludeManagementEvents: aws.Bool(true),
+			ReadWriteType:           aws.String("WriteOnly"),
+			DataResources:           []*cloudtrail.DataResource{{Type: aws.String("AWS::S3::Object")}, {Type: aws.String("AWS::Lambda::Function")}},
+		}}}, "WriteOnly", 2},
+		{&cloudtrail.GetEventSelectorsOutput{EventSelectors: []*cloudtrail.EventSelector{
+			{IncludeManagementEvents: aws.Bool(true), ReadWriteType: aws.String("ReadOnly")},
+			{IncludeManagementEvents: aws.Bool(true), ReadWriteType: aws.String("WriteOnly")},
+		}}, "All", 0},
+		{&cloudtrail.GetEventSelectorsOutput{EventSelectors: []*cloudtrail.EventSelector{
+			{IncludeManagementEvents: aws.Bool(true), ReadWriteType: aws.String("ReadOnly")},
+			{IncludeManagementEvents: aws.Bool(false), ReadWriteType: aws.String("WriteOnly")},
+		}}, "ReadOnly", 0},
+		{&cloudtrail.GetEventSelectorsOutput{AdvancedEventSelectors: []*cloudtrail.AdvancedEventSelector{
+			advanced("eventCategory", "Management"),
+			advanced("eventCategory", "Data", "resources.type", "AWS::S3::Object"),
+		}}, "All", 1},
+		{&cloudtrail.GetEventSelectorsOutput{AdvancedEventSelectors: []*cloudtrail.AdvancedEventSelector{
+			advanced("eventCategory", "Management", "readOnly", "true"),
+		}}, "ReadOnly", 0},
+		{&cloudtrail.GetEventSelectorsOutput{AdvancedEventSelectors: []*cloudtrail.AdvancedEventSelector{
+			advanced("eventCategory", "Management", "readOnly", "true"),
+			advanced("eventCategory", "Management", "readOnly", "false"),
+		}}, "All", 0},
+		{&cloudtrail.GetEventSelectorsOutput{AdvancedEventSelectors: []*cloudtrail.AdvancedEventSelector{
+			advanced("eventCategory", "Data", "resources.type", "AWS::DynamoDB::Table"),
+		}}, "none", 1},
+	}
+	for i, test := range tests {
+		management, data := summarizeEventSelectors(test.selectors)
+		if management != test.management || data != test.data {
+			t.Errorf("test %d: summarizeEventSelectors returned %s and %d, expected %s and %d", i, management, data, test.management, test.data)
+		}
+	}
+
+}
@@ -0,0 +1,206 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/organizations"
+)
+
+// TrailStatus is the delivery status and event selection of a trail as
+// seen from one account.
+type TrailStatus struct {
+	Account             *organizations.Account
+	Trail               *cloudtrail.Trail
+	IsLogging           bool
+	LatestDeliveryTime  *time.Time
+	LatestDeliveryError string
+	ManagementEvents    string
+	DataResources       int
+	Err                 error
+}
+
+// TrailEvent is a cloudtrail event found in an account and region.
+type TrailEvent struct {
+	AccountId string
+	Region    string
+	Event     *cloudtrail.Event
+}
+
+func (o *Organization) GetTrailStatusesForAccount(ctx context.Context, account *organizations.Account) ([]*TrailStatus, error) {
+
+	trails, err := o.GetTrailsForAccount(ctx, *account.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*TrailStatus, 0, len(trails))
+	for _, trail := range trails {
+		status := &TrailStatus{Account: account, Trail: trail, ManagementEvents: "none"}
+		statuses = append(statuses, status)
+		if trail.HomeRegion == nil {
+			status.Err = fmt.Errorf("unknown home region")
+			continue
+		}
+
+		svc, err := o.clients.CloudTrail(*account.Id, *trail.HomeRegion)
+		if err != nil {
+			return nil, fmt.Errorf("could not assume role: %s", err)
+		}
+
+		// a member account may not be allowed to read an organization
+		// trail, so failures are kept per trail
+		resp, err := svc.GetTrailStatusWithContext(ctx, &cloudtrail.GetTrailStatusInput{Name: trail.TrailARN})
+		if err != nil {
+			status.Err = err
+			continue
+		}
+		status.IsLogging = aws.BoolValue(resp.IsLogging)
+		status.LatestDeliveryTime = resp.LatestDeliveryTime
+		status.LatestDeliveryError = aws.StringValue(resp.LatestDeliveryError)
+
+		selectors, err := svc.GetEventSelectorsWithContext(ctx, &cloudtrail.GetEventSelectorsInput{TrailName: trail.TrailARN})
+		if err != nil {
+			status.Err = err
+			continue
+		}
+		status.ManagementEvents, status.DataResources = summarizeEventSelectors(selectors)
+	}
+	return statuses, nil
+
+}
+
+// summarizeEventSelectors returns the management events a trail records,
+// none, ReadOnly, WriteOnly or All, and how many data resources or data
+// event selectors it has. A trail has either basic or advanced selectors.
+func summarizeEventSelectors(selectors *cloudtrail.GetEventSelectorsOutput) (string, int) {
+
+	management, data := "none", 0
+	for _, selector := range selectors.EventSelectors {
+		if aws.BoolValue(selector.IncludeManagementEvents) {
+			management = combineReadWrite(management, aws.StringValue(selector.ReadWriteType))
+		}
+		data += len(selector.DataResources)
+	}
+
+	for _, selector := range selectors.AdvancedEventSelectors {
+		category, readWrite := "", "All"
+		for _, field := range selector.FieldSelectors {
+			if len(field.Equals) != 1 {
+				continue
+			}
+			switch aws.StringValue(field.Field) {
+			case "eventCategory":
+				category = aws.StringValue(field.Equals[0])
+			case "readOnly":
+				readWrite = map[string]string{"true": "ReadOnly", "false": "WriteOnly"}[aws.StringValue(field.Equals[0])]
+			}
+		}
+		if category == "Data" {
+			data++
+			continue
+		}
+		if category != "Management" {
+			continue
+		}
+		management = combineReadWrite(management, readWrite)
+	}
+	return management, data
+
+}
+
+// combineReadWrite adds the read write type of one selector to those
+// already recorded, separate read only and write only selectors record both.
+func combineReadWrite(management string, readWrite string) string {
+	if management == "none" || management == readWrite {
+		return readWrite
+	}
+	return "All"
+}
+
+// GetTrailStatuses returns the status of every trail in the accounts.
+func (o *Organization) GetTrailStatuses(ctx context.Context, accounts []*organizations.Account) ([]*TrailStatus, AccountErrors) {
+
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		return o.GetTrailStatusesForAccount(ctx, account)
+	})
+
+	statuses := make([]*TrailStatus, 0, 100)
+	for _, result := range results {
+		if result.Err == nil {
+			statuses = append(statuses, result.Value.([]*TrailStatus)...)
+		}
+	}
+	return statuses, Errors(results)
+
+}
+
+// LookupEventsForAccount returns the management events recorded in every
+// region of an account since a time, optionally only those with a name.
+func (o *Organization) LookupEventsForAccount(ctx context.Context, accountid string, eventName string, since time.Time) ([]*TrailEvent, error) {
+
+	events := make([]*TrailEvent, 0, 100)
+	for _, region := range o.GetRegions() {
+
+		svc, err := o.clients.CloudTrail(accountid, region)
+		if err != nil {
+			return nil, fmt.Errorf("could not assume role: %s", err)
+		}
+
+		input := &cloudtrail.LookupEventsInput{
+			StartTime: aws.Time(since),
+			EndTime:   aws.Time(time.Now()),
+		}
+		if len(eventName) > 0 {
+			input.LookupAttributes = []*cloudtrail.LookupAttribute{{
+				AttributeKey:   aws.String(cloudtrail.LookupAttributeKeyEventName),
+				AttributeValue: aws.String(eventName),
+			}}
+		}
+		limit := o.newPageLimit(fmt.Sprintf("events in account %s region %s", accountid, region))
+		err = svc.LookupEventsPagesWithContext(ctx, input, func(page *cloudtrail.LookupEventsOutput, last bool) bool {
+			for _, event := range page.Events {
+				events = append(events, &TrailEvent{AccountId: accountid, Region: region, Event: event})
+			}
+			return limit.next(last)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not look up events in region %s: %s", region, err)
+		}
+	}
+	return events, nil
+
+}
+
+// LookupEvents looks up events in every account and region and merges
+// them into one stream, oldest first.
+func (o *Organization) LookupEvents(ctx context.Context, accounts []*organizations.Account, eventName string, since time.Time) ([]*TrailEvent, AccountErrors) {
+
+	results := o.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		return o.LookupEventsForAccount(ctx, *account.Id, eventName, since)
+	})
+
+	events := make([]*TrailEvent, 0, 1000)
+	for _, result := range results {
+		if result.Err == nil {
+			events = append(events, result.Value.([]*TrailEvent)...)
+		}
+	}
+	sortTrailEvents(events)
+	return events, Errors(results)
+
+}
+
+func sortTrailEvents(events []*TrailEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		ti, tj := aws.TimeValue(events[i].Event.EventTime), aws.TimeValue(events[j].Event.EventTime)
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return aws.StringValue(events[i].Event.EventId) < aws.StringValue(events[j].Event.EventId)
+	})
+}
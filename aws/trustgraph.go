@@ -0,0 +1,132 @@
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TrustEdge is a principal allowed to assume a role.
+type TrustEdge struct {
+	Principal        string
+	PrincipalType    string
+	PrincipalAccount string
+	RoleAccount      string
+	RoleName         string
+	RoleArn          string
+	Scope            string
+}
+
+// External returns true if the principal is outside the organization, or
+// cannot be placed.
+func (e *TrustEdge) External() bool {
+	return e.Scope == PrincipalExternal || e.Scope == PrincipalPublic || e.Scope == PrincipalUnknown
+}
+
+// TrustEdges classifies the trust policy principals of roles. members holds
+// the ids of the accounts in the organization. Service and federated
+// principals and those in the same account are left out unless all is set.
+func TrustEdges(roles []*Role, members map[string]bool, all bool) []*TrustEdge {
+
+	edges := make([]*TrustEdge, 0, len(roles))
+	for _, role := range roles {
+		for _, principal := range role.Principals {
+			scope := principal.Scope(role.AccountId, members)
+			switch scope {
+			case PrincipalSameAccount, PrincipalService, PrincipalFederated:
+				if !all {
+					continue
+				}
+			}
+			edges = append(edges, &TrustEdge{
+				Principal:        principal.Value,
+				PrincipalType:    principal.Type,
+				PrincipalAccount: principal.AccountId,
+				RoleAccount:      role.AccountId,
+				RoleName:         role.RoleName,
+				RoleArn:          role.Arn,
+				Scope:            scope,
+			})
+		}
+	}
+	return edges
+
+}
+
+// TrustGraphDot draws trust edges as a graphviz graph between accounts.
+// Each edge points from the trusted account, or principal if it has no
+// account, to the account holding the roles. names labels the accounts of
+// the organization.
+func TrustGraphDot(edges []*TrustEdge, names map[string]string) string {
+
+	type key struct{ from, to string }
+	labels := make(map[key][]string)
+	nodes := make(map[string]string)
+	keys := make([]key, 0, len(edges))
+
+	node := func(id string, scope string) {
+		if _, ok := nodes[id]; ok {
+			return
+		}
+		if name, ok := names[id]; ok {
+			nodes[id] = fmt.Sprintf("label=%q", name+"\n"+id)
+			return
+		}
+		style := "shape=box, style=filled, fillcolor=lightgrey"
+		if scope == PrincipalExternal || scope == PrincipalPublic || scope == PrincipalUnknown {
+			style = "shape=box, style=filled, fillcolor=salmon"
+		}
+		nodes[id] = fmt.Sprintf("label=%q, %s", scope+"\n"+id, style)
+	}
+
+	for _, edge := range edges {
+		from := edge.PrincipalAccount
+		if len(from) == 0 {
+			from = edge.Principal
+		}
+		node(from, edge.Scope)
+		node(edge.RoleAccount, PrincipalOrganization)
+		k := key{from, edge.RoleAccount}
+		if _, ok := labels[k]; !ok {
+			keys = append(keys, k)
+		}
+		labels[k] = append(labels[k], edge.RoleName)
+	}
+
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].from != keys[j].from {
+			return keys[i].from < keys[j].from
+		}
+		return keys[i].to < keys[j].to
+	})
+
+	lines := []string{"digraph trust {", "\trankdir=LR;", "\tnode [shape=ellipse];"}
+	for _, id := range ids {
+		lines = append(lines, fmt.Sprintf("\t%q [%s];", id, nodes[id]))
+	}
+	for _, k := range keys {
+		roles := dedupStrings(labels[k])
+		lines = append(lines, fmt.Sprintf("\t%q -> %q [label=%q];", k.from, k.to, strings.Join(roles, "\n")))
+	}
+	lines = append(lines, "}")
+	return strings.Join(lines, "\n") + "\n"
+
+}
+
+func dedupStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,55 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrustEdges(t *testing.T) {
+
+	roles := []*Role{
+		{
+			AccountId: "222222222222",
+			RoleName:  "deploy",
+			Principals: []*TrustPrincipal{
+				{Type: "AWS", Value: "arn:aws:iam::111111111111:root", AccountId: "111111111111"},
+				{Type: "Service", Value: "ec2.amazonaws.com"},
+			},
+		},
+		{
+			AccountId: "222222222222",
+			RoleName:  "vendor",
+			Principals: []*TrustPrincipal{
+				{Type: "AWS", Value: "999999999999", AccountId: "999999999999"},
+				{Type: "AWS", Value: "arn:aws:iam::222222222222:root", AccountId: "222222222222"},
+			},
+		},
+	}
+	members := map[string]bool{"111111111111": true, "222222222222": true}
+
+	edges := TrustEdges(roles, members, false)
+	if len(edges) != 2 {
+		t.Fatalf("TrustEdges returned %d edges, expected 2", len(edges))
+	}
+	if edges[0].Scope != PrincipalOrganization || edges[0].External() {
+		t.Errorf("edge to deploy should be within the organization, got %s", edges[0].Scope)
+	}
+	if edges[1].Scope != PrincipalExternal || !edges[1].External() {
+		t.Errorf("edge to vendor should be external, got %s", edges[1].Scope)
+	}
+	if all := TrustEdges(roles, members, true); len(all) != 4 {
+		t.Errorf("TrustEdges with all returned %d edges, expected 4", len(all))
+	}
+
+	dot := TrustGraphDot(edges, map[string]string{"111111111111": "shared", "222222222222": "prod"})
+	for _, want := range []string{
+		`"111111111111" -> "222222222222" [label="deploy"];`,
+		`"999999999999" -> "222222222222" [label="vendor"];`,
+		`"999999999999" [label="external\n999999999999", shape=box, style=filled, fillcolor=salmon];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("TrustGraphDot is missing %s in\n%s", want, dot)
+		}
+	}
+
+}
@@ -1,24 +1,8 @@
 package aws
 
-import (
-	"os"
-)
-
-func getRegion() string {
-
-	// set the default to ap-southeast-2 if no other is set
-	regenv := os.Getenv("AWS_REGION")
-	if regenv != "" {
-		return regenv
-	}
-	regenv = os.Getenv("AWS_DEFAULT_REGION")
-	if regenv != "" {
-		return regenv
+func stringValue(s *string) string {
+	if s == nil {
+		return "unknown"
 	}
-	return "us-east-1"
-
-}
-
-func isNilOrEmpty(s *string) bool {
-	return s == nil || *s == ""
+	return *s
 }
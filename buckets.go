@@ -7,13 +7,18 @@ import (
 	"strings"
 
 	"github.com/mitchellh/cli"
-	"github.com/pr8kerl/organizer/aws"
 )
 
+type bucketRow struct {
+	AccountId string `json:"account_id" yaml:"account_id"`
+	Bucket    string `json:"bucket" yaml:"bucket"`
+}
+
 // List Account
 type ListBucketsCommand struct {
 	AccountId string
 	Ui        cli.Ui
+	orgOptions
 }
 
 func listBucketsCmdFactory() (cli.Command, error) {
@@ -34,31 +39,44 @@ func (c *ListBucketsCommand) Run(args []string) int {
 
 	cmdFlags := flag.NewFlagSet("list buckets", flag.ContinueOnError)
 	cmdFlags.StringVar(&c.AccountId, "accountid", "", "list s3 buckets for a specific account")
+	c.addFlags(cmdFlags)
 	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
 
-	org, err := aws.NewOrganization()
+	org, err := c.newOrganization()
 	if err != nil {
-		fmt.Printf("error: could not initialize organization: %s\n", err)
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
 		return 1
 	}
 
+	ctx, cancel := interruptContext()
+	defer cancel()
+
 	if len(c.AccountId) > 0 {
-		err = org.PrintBucketsForAccount(c.AccountId)
-		if err != nil {
-			fmt.Printf("error: could not list s3 buckets for account: %s\n", err)
-			return 1
-		}
-	} else {
-		err = org.PrintBuckets()
-		if err != nil {
-			fmt.Printf("error: could not list all s3 buckets: %s\n", err)
-			return 1
+		c.accounts = c.AccountId
+	}
+	accounts, err := c.selectAccounts(ctx, org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
+	}
+	buckets, errs := org.GetBuckets(ctx, accounts)
+	printAccountErrors(c.Ui, "could not list buckets", errs)
+
+	rows := make([]bucketRow, 0, 200)
+	for _, accountid := range buckets.Accounts() {
+		for _, bucket := range buckets[accountid] {
+			rows = append(rows, bucketRow{AccountId: accountid, Bucket: bucket})
 		}
 	}
 
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print s3 buckets: %s", err))
+		return 1
+	}
+
 	return 0
 }
 
@@ -68,9 +86,9 @@ func (c *ListBucketsCommand) Help() string {
 List all s3 buckets within accounts for an organization
 
 Options:
-	    -accountid		list all s3 buckets for a specific account only
+	    -accountid		list all s3 buckets for a specific account only, same as -accounts <id>
 	`
-	return strings.TrimSpace(helpText)
+	return strings.TrimSpace(helpText + orgOptionsHelp)
 }
 
 func (c *ListBucketsCommand) Synopsis() string {
@@ -7,13 +7,22 @@ import (
 	"strings"
 
 	"github.com/mitchellh/cli"
-	"github.com/pr8kerl/organizer/aws"
 )
 
+type distributionRow struct {
+	AccountId  string   `json:"account_id" yaml:"account_id"`
+	Id         string   `json:"id" yaml:"id"`
+	DomainName string   `json:"domain_name" yaml:"domain_name"`
+	Status     string   `json:"status" yaml:"status"`
+	Enabled    bool     `json:"enabled" yaml:"enabled"`
+	Aliases    []string `json:"aliases" yaml:"aliases"`
+}
+
 // List Account
 type ListCloudfrontsCommand struct {
 	All bool
 	Ui  cli.Ui
+	orgOptions
 }
 
 func listCloudfrontsCmdFactory() (cli.Command, error) {
@@ -33,20 +42,51 @@ func (c *ListCloudfrontsCommand) Run(args []string) int {
 
 	cmdFlags := flag.NewFlagSet("list cloudfront", flag.ContinueOnError)
 	cmdFlags.BoolVar(&c.All, "all", false, "show all cloudfront distributions")
+	c.addFlags(cmdFlags)
 	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
 
-	org, err := aws.NewOrganization()
+	org, err := c.newOrganization()
 	if err != nil {
-		fmt.Printf("error: could not initialize organization: %s\n", err)
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
 		return 1
 	}
 
-	err = org.PrintCloudfronts()
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	accounts, err := c.selectAccounts(ctx, org)
 	if err != nil {
-		fmt.Printf("error: could not list cloudfront distributions: %s\n", err)
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
+	}
+	distros, errs := org.GetCloudfronts(ctx, accounts)
+	printAccountErrors(c.Ui, "could not list cloudfront distributions", errs)
+
+	rows := make([]distributionRow, 0, 200)
+	for _, accountid := range distros.Accounts() {
+		for _, distro := range distros[accountid] {
+			row := distributionRow{
+				AccountId:  accountid,
+				Id:         stringValue(distro.Id),
+				DomainName: stringValue(distro.DomainName),
+				Status:     stringValue(distro.Status),
+				Enabled:    distro.Enabled != nil && *distro.Enabled,
+				Aliases:    []string{},
+			}
+			if distro.Aliases != nil {
+				for _, alias := range distro.Aliases.Items {
+					row.Aliases = append(row.Aliases, stringValue(alias))
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print cloudfront distributions: %s", err))
 		return 1
 	}
 
@@ -59,7 +99,7 @@ func (c *ListCloudfrontsCommand) Help() string {
 List cloudfront distributions per account
 
 	`
-	return strings.TrimSpace(helpText)
+	return strings.TrimSpace(helpText + orgOptionsHelp)
 }
 
 func (c *ListCloudfrontsCommand) Synopsis() string {
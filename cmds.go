@@ -93,3 +93,413 @@ create organization objects
 func (c *CreateCommand) Synopsis() string {
 	return "create objects for an organization"
 }
+
+// Move Command
+type MoveCommand struct {
+	Ui cli.Ui
+}
+
+func moveCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &MoveCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *MoveCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("move", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	cmdFlags.Parse(args)
+
+	return RunResultHelp
+}
+
+func (c *MoveCommand) Help() string {
+	helpText := `usage: organizer move <subcommand> [<args>]
+
+move organization objects
+
+	`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *MoveCommand) Synopsis() string {
+	return "move objects within an organization"
+}
+
+// Rename Command
+type RenameCommand struct {
+	Ui cli.Ui
+}
+
+func renameCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &RenameCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *RenameCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("rename", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	cmdFlags.Parse(args)
+
+	return RunResultHelp
+}
+
+func (c *RenameCommand) Help() string {
+	helpText := `usage: organizer rename <subcommand> [<args>]
+
+rename organization objects
+
+	`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *RenameCommand) Synopsis() string {
+	return "rename objects within an organization"
+}
+
+// Delete Command
+type DeleteCommand struct {
+	Ui cli.Ui
+}
+
+func deleteCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &DeleteCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *DeleteCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("delete", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	cmdFlags.Parse(args)
+
+	return RunResultHelp
+}
+
+func (c *DeleteCommand) Help() string {
+	helpText := `usage: organizer delete <subcommand> [<args>]
+
+delete organization objects
+
+	`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DeleteCommand) Synopsis() string {
+	return "delete objects within an organization"
+}
+
+// Show Command
+type ShowCommand struct {
+	Ui cli.Ui
+}
+
+func showCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &ShowCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *ShowCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("show", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	cmdFlags.Parse(args)
+
+	return RunResultHelp
+}
+
+func (c *ShowCommand) Help() string {
+	helpText := `usage: organizer show <subcommand> [<args>]
+
+show organization objects
+
+	`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ShowCommand) Synopsis() string {
+	return "show objects within an organization"
+}
+
+// Attach Command
+type AttachCommand struct {
+	Ui cli.Ui
+}
+
+func attachCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &AttachCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *AttachCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("attach", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	cmdFlags.Parse(args)
+
+	return RunResultHelp
+}
+
+func (c *AttachCommand) Help() string {
+	helpText := `usage: organizer attach <subcommand> [<args>]
+
+attach organization objects
+
+	`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AttachCommand) Synopsis() string {
+	return "attach objects within an organization"
+}
+
+// Detach Command
+type DetachCommand struct {
+	Ui cli.Ui
+}
+
+func detachCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &DetachCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *DetachCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("detach", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	cmdFlags.Parse(args)
+
+	return RunResultHelp
+}
+
+func (c *DetachCommand) Help() string {
+	helpText := `usage: organizer detach <subcommand> [<args>]
+
+detach organization objects
+
+	`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DetachCommand) Synopsis() string {
+	return "detach objects within an organization"
+}
+
+// Tag Command
+type TagCommand struct {
+	Ui cli.Ui
+}
+
+func tagCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &TagCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *TagCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("tag", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	cmdFlags.Parse(args)
+
+	return RunResultHelp
+}
+
+func (c *TagCommand) Help() string {
+	helpText := `usage: organizer tag <subcommand> [<args>]
+
+tag organization objects
+
+	`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *TagCommand) Synopsis() string {
+	return "tag objects within an organization"
+}
+
+// Untag Command
+type UntagCommand struct {
+	Ui cli.Ui
+}
+
+func untagCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &UntagCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *UntagCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("untag", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	cmdFlags.Parse(args)
+
+	return RunResultHelp
+}
+
+func (c *UntagCommand) Help() string {
+	helpText := `usage: organizer untag <subcommand> [<args>]
+
+remove tags from organization objects
+
+	`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *UntagCommand) Synopsis() string {
+	return "remove tags from objects within an organization"
+}
+
+// Generate Command
+type GenerateCommand struct {
+	Ui cli.Ui
+}
+
+func generateCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &GenerateCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *GenerateCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	cmdFlags.Parse(args)
+
+	return RunResultHelp
+}
+
+func (c *GenerateCommand) Help() string {
+	helpText := `usage: organizer generate <subcommand> [<args>]
+
+generate files from the organization
+
+	`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *GenerateCommand) Synopsis() string {
+	return "generate files from an organization"
+}
+
+// IAM Command
+type IamCommand struct {
+	Ui cli.Ui
+}
+
+func iamCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &IamCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *IamCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("iam", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	cmdFlags.Parse(args)
+
+	return RunResultHelp
+}
+
+func (c *IamCommand) Help() string {
+	helpText := `usage: organizer iam <subcommand> [<args>]
+
+manage iam within the accounts of an organization
+
+	`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *IamCommand) Synopsis() string {
+	return "manage iam across the accounts of an organization"
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+// runCommand runs a command against the fake aws endpoint, answering any
+// question with input. It returns the exit code, everything written to
+// stdout and everything written to stderr.
+func runCommand(t *testing.T, command func(ui cli.Ui) cli.Command, input string, args ...string) (int, string, string) {
+
+	ui := &cli.MockUi{InputReader: strings.NewReader(input)}
+
+	out, err := ioutil.TempFile("", "organizer-stdout")
+	if err != nil {
+		t.Fatalf("could not capture stdout: %s", err)
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	stdout := os.Stdout
+	os.Stdout = out
+	code := command(ui).Run(args)
+	os.Stdout = stdout
+
+	printed, err := ioutil.ReadFile(out.Name())
+	if err != nil {
+		t.Fatalf("could not read stdout: %s", err)
+	}
+	// the mock ui only creates its buffers once it is written to
+	output, errors := "", ""
+	if ui.OutputWriter != nil {
+		output, errors = ui.OutputWriter.String(), ui.ErrorWriter.String()
+	}
+	return code, string(printed) + output, errors
+
+}
+
+func listAccounts(ui cli.Ui) cli.Command  { return &ListAccountsCommand{Ui: ui} }
+func createAccount(ui cli.Ui) cli.Command { return &CreateAccountCommand{Tags: tagFlags{}, Ui: ui} }
+func trails(ui cli.Ui) cli.Command        { return &TrailsCommand{Ui: ui} }
+func generate(ui cli.Ui) cli.Command      { return &GenerateAwsConfigCommand{Ui: ui} }
+func execute(ui cli.Ui) cli.Command       { return &ExecCommand{Ui: ui} }
+
+func TestListAccountsCommand(t *testing.T) {
+
+	fake := startFakeAWS(t, "testdata/org.yml")
+	defer fake.Close()
+
+	tests := []struct {
+		args []string
+		ids  []string
+	}{
+		{[]string{}, []string{"111111111111", "222222222222", "333333333333", "444444444444"}},
+		{[]string{"-all"}, []string{"111111111111", "222222222222", "333333333333", "444444444444", "555555555555"}},
+		{[]string{"-filter", "tag:env=prod"}, []string{"222222222222", "444444444444"}},
+		{[]string{"-filter", "tag:owner=payments,tag:env=dev"}, []string{"333333333333"}},
+	}
+
+	for _, test := range tests {
+		code, stdout, stderr := runCommand(t, listAccounts, "", append(test.args, "-output", "json")...)
+		if code != 0 {
+			t.Errorf("list accounts %v exited %d: %s", test.args, code, stderr)
+			continue
+		}
+		var rows []accountRow
+		if err := json.Unmarshal([]byte(stdout), &rows); err != nil {
+			t.Errorf("list accounts %v printed invalid json: %s\n%s", test.args, err, stdout)
+			continue
+		}
+		ids := make([]string, 0, len(rows))
+		for _, row := range rows {
+			ids = append(ids, row.Id)
+		}
+		sort.Strings(ids)
+		if strings.Join(ids, ",") != strings.Join(test.ids, ",") {
+			t.Errorf("list accounts %v listed %v, expected %v", test.args, ids, test.ids)
+		}
+	}
+
+	// accounts are listed a page at a time
+	if n := fake.called("organizations.ListAccounts"); n < 3*len(tests) {
+		t.Errorf("list accounts made %d ListAccounts calls, expected at least 3 pages per run", n)
+	}
+
+}
+
+func TestCreateAccountCommand(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		code   int
+		checks int
+		output string
+	}{
+		{"quick-app", 0, 1, "ACCOUNT_ID=900000000001"},
+		{"slow-app", 0, 3, "ACCOUNT_ID=900000000001"},
+		{"taken-app", 1, 2, "EMAIL_ALREADY_EXISTS"},
+	}
+
+	for _, test := range tests {
+		fake := startFakeAWS(t, "testdata/org.yml")
+
+		code, stdout, stderr := runCommand(t, createAccount, "", "-name", test.name, "-email", test.name+"@example.com", "-tag", "env=test")
+		if code != test.code {
+			t.Errorf("create account %s exited %d, expected %d: %s%s", test.name, code, test.code, stdout, stderr)
+		}
+		if !strings.Contains(stdout, test.output) {
+			t.Errorf("create account %s did not print %s:\n%s", test.name, test.output, stdout)
+		}
+		if n := fake.called("organizations.DescribeCreateAccountStatus"); n != test.checks {
+			t.Errorf("create account %s checked the account status %d times, expected %d", test.name, n, test.checks)
+		}
+
+		// a created account is listed with its tags
+		if test.code == 0 {
+			_, stdout, _ := runCommand(t, listAccounts, "", "-filter", "tag:env=test", "-output", "json")
+			var rows []accountRow
+			json.Unmarshal([]byte(stdout), &rows)
+			if len(rows) != 1 || rows[0].Name != test.name {
+				t.Errorf("create account %s is not listed with its tag: %s", test.name, stdout)
+			}
+		}
+
+		fake.Close()
+	}
+
+}
+
+func TestTrailsPurgeCommand(t *testing.T) {
+
+	prodTrail := func(region string, name string) string {
+		return "arn:aws:cloudtrail:" + region + ":222222222222:trail/" + name
+	}
+
+	tests := []struct {
+		args    []string
+		input   string
+		code    int
+		deleted []string
+		audit   int
+	}{
+		// nothing is deleted without confirmation
+		{[]string{"-accounts", "222222222222", "-purge", "-dry-run"}, "", 0, nil, 0},
+		{[]string{"-accounts", "222222222222", "-purge"}, "no\n", 1, nil, 0},
+		{[]string{"-accounts", "222222222222", "-purge"}, "yes\n", 0, []string{prodTrail("eu-west-1", "legacy-eu"), prodTrail("us-east-1", "legacy-audit")}, 2},
+		{[]string{"-accounts", "222222222222", "-purge", "-auto-approve", "-name", "*-eu"}, "", 0, []string{prodTrail("eu-west-1", "legacy-eu")}, 1},
+		{[]string{"-accounts", "ou:/Workloads", "-purge", "-auto-approve", "-exclude", "legacy-audit"}, "", 0, []string{
+			prodTrail("eu-west-1", "legacy-eu"), "arn:aws:cloudtrail:us-west-2:333333333333:trail/legacy-dev",
+		}, 2},
+		// the audit account role cannot be assumed, the other accounts are still purged
+		{[]string{"-accounts", "333333333333,444444444444", "-purge", "-auto-approve"}, "", 0, []string{"arn:aws:cloudtrail:us-west-2:333333333333:trail/legacy-dev"}, 1},
+		{[]string{"-accounts", "444444444444", "-purge", "-auto-approve"}, "", 0, nil, 0},
+	}
+
+	for _, test := range tests {
+		fake := startFakeAWS(t, "testdata/org.yml")
+		auditLog := filepath.Join(fake.home, "audit.log")
+
+		args := append(test.args, "-audit-log", auditLog)
+		code, stdout, stderr := runCommand(t, trails, test.input, args...)
+		if code != test.code {
+			t.Errorf("trails %v exited %d, expected %d:\n%s%s", test.args, code, test.code, stdout, stderr)
+		}
+
+		deleted := fake.deletedTrails()
+		if strings.Join(deleted, ",") != strings.Join(test.deleted, ",") {
+			t.Errorf("trails %v deleted %v, expected %v", test.args, deleted, test.deleted)
+		}
+		if fake.called("cloudtrail.DeleteTrail") != len(test.deleted) {
+			t.Errorf("trails %v called DeleteTrail %d times, expected %d", test.args, fake.called("cloudtrail.DeleteTrail"), len(test.deleted))
+		}
+
+		audit, _ := ioutil.ReadFile(auditLog)
+		if lines := strings.Count(string(audit), "\n"); lines != test.audit {
+			t.Errorf("trails %v wrote %d audit records, expected %d", test.args, lines, test.audit)
+		}
+		if strings.Contains(strings.Join(test.args, " "), "444444444444") && !strings.Contains(stderr, "AccessDenied") {
+			t.Errorf("trails %v did not warn that the role could not be assumed: %s", test.args, stderr)
+		}
+
+		fake.Close()
+	}
+
+}
+
+func TestGenerateAwsConfigMerge(t *testing.T) {
+
+	profile := func(name string, accountid string, role string) string {
+		return "[profile " + name + "]\n# managed by organizer " + accountid + "\nrole_arn = arn:aws:iam::" + accountid + ":role/" + role + "\n"
+	}
+	existing := profile("prod-app", "222222222222", "OldRole") + "\n" +
+		profile("dev-app", "333333333333", "OldRole") + "\n" +
+		profile("audit", "444444444444", "OldRole") + "\n" +
+		profile("closed", "999999999999", "OldRole")
+
+	tests := []struct {
+		args     []string
+		want     []string
+		unwanted []string
+	}{
+		// only the selected account is updated
+		{[]string{"-accounts", "222222222222"},
+			[]string{profile("dev-app", "333333333333", "OldRole"), profile("audit", "444444444444", "OldRole"), profile("closed", "999999999999", "OldRole")},
+			[]string{profile("prod-app", "222222222222", "OldRole")}},
+		// every account is selected, the audit account alias cannot be read
+		{[]string{},
+			[]string{"[profile master]\n", profile("audit", "444444444444", "OldRole")},
+			[]string{profile("prod-app", "222222222222", "OldRole"), profile("dev-app", "333333333333", "OldRole"), "[profile closed]"}},
+	}
+
+	for _, test := range tests {
+		fake := startFakeAWS(t, "testdata/org.yml")
+		config := filepath.Join(fake.home, "config")
+		ioutil.WriteFile(config, []byte(existing), 0600)
+
+		args := append(test.args, "-source-profile", "master", "-merge", config, "-write")
+		code, stdout, stderr := runCommand(t, generate, "", args...)
+		if code != 0 {
+			t.Errorf("generate aws-config %v exited %d:\n%s%s", test.args, code, stdout, stderr)
+		}
+		merged, _ := ioutil.ReadFile(config)
+		for _, want := range test.want {
+			if !strings.Contains(string(merged), want) {
+				t.Errorf("generate aws-config %v is missing %q:\n%s", test.args, want, merged)
+			}
+		}
+		for _, unwanted := range test.unwanted {
+			if strings.Contains(string(merged), unwanted) {
+				t.Errorf("generate aws-config %v should not contain %q:\n%s", test.args, unwanted, merged)
+			}
+		}
+
+		fake.Close()
+	}
+
+}
+
+func TestEndpointAndPartitionOptions(t *testing.T) {
+
+	fake := startFakeAWS(t, "testdata/org.yml")
+	defer fake.Close()
+	os.Setenv("ORGANIZER_ENDPOINT", "")
+
+	// only the services a command uses need to be sent to the fake
+	for _, endpoint := range []string{fake.URL, "organizations=" + fake.URL} {
+		code, stdout, stderr := runCommand(t, listAccounts, "", "-endpoint-url", endpoint, "-output", "json")
+		var rows []accountRow
+		if err := json.Unmarshal([]byte(stdout), &rows); code != 0 || err != nil || len(rows) != 4 {
+			t.Errorf("list accounts -endpoint-url %s exited %d with %d accounts: %s", endpoint, code, len(rows), stderr)
+		}
+	}
+
+	code, stdout, stderr := runCommand(t, trails, "", "-accounts", "222222222222", "-purge", "-dry-run", "-partition", "aws-us-gov",
+		"-endpoint-url", "sts="+fake.URL, "-endpoint-url", "organizations="+fake.URL, "-endpoint-url", "cloudtrail="+fake.URL)
+	if code != 0 {
+		t.Errorf("trails -partition aws-us-gov exited %d:\n%s%s", code, stdout, stderr)
+	}
+	if fake.called("sts.AssumeRole arn:aws-us-gov:iam::222222222222:role/OrganizationAccountAccessRole") == 0 {
+		t.Errorf("trails -partition aws-us-gov did not assume the member role by its aws-us-gov arn")
+	}
+	if fake.called("cloudtrail.DescribeTrails us-gov-west-1") != 1 || fake.called("cloudtrail.DescribeTrails us-east-1") != 0 {
+		t.Errorf("trails -partition aws-us-gov did not list trails in the aws-us-gov regions only")
+	}
+
+	// without AWS_REGION commands default to the region of the partition
+	gov := []string{"-accounts", "222222222222", "-partition", "aws-us-gov", "-endpoint-url", fake.URL}
+	code, stdout, stderr = runCommand(t, trails, "", append(gov, "-ensure", "-dry-run", "-bucket", "org-trails", "-output", "json")...)
+	if code != 0 || !strings.Contains(stdout, `"region": "us-gov-west-1"`) {
+		t.Errorf("trails -ensure -partition aws-us-gov exited %d without a standard trail in us-gov-west-1:\n%s%s", code, stdout, stderr)
+	}
+	code, stdout, stderr = runCommand(t, generate, "", append(gov, "-sso-start-url", "https://example.awsapps.com/start", "-sso-role-name", "Admin")...)
+	if code != 0 || !strings.Contains(stdout, "sso_region = us-gov-west-1") {
+		t.Errorf("generate aws-config -partition aws-us-gov exited %d without sso_region us-gov-west-1:\n%s%s", code, stdout, stderr)
+	}
+	code, stdout, stderr = runCommand(t, execute, "", append(gov, "--", "sh", "-c", "echo region=$AWS_REGION")...)
+	if code != 0 || !strings.Contains(stdout, "region=us-gov-west-1") {
+		t.Errorf("exec -partition aws-us-gov exited %d without AWS_REGION us-gov-west-1:\n%s%s", code, stdout, stderr)
+	}
+
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+// interruptContext returns a context that is cancelled when the user hits
+// ctrl-c, so that work in flight across accounts is abandoned cleanly.
+func interruptContext() (context.Context, context.CancelFunc) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+
+	go func() {
+		select {
+		case <-sigs:
+			fmt.Fprintln(os.Stderr, "interrupted, cancelling...")
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(sigs)
+	}()
+
+	return ctx, cancel
+
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+)
+
+// Creds
+type CredsCommand struct {
+	AccountId string
+	Format    string
+	Profile   string
+	Ui        cli.Ui
+	orgOptions
+}
+
+func credsCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &CredsCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *CredsCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("creds", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.AccountId, "accountid", "", "the account to get credentials for")
+	cmdFlags.StringVar(&c.Format, "format", "export", "export, credential_process or profile")
+	cmdFlags.StringVar(&c.Profile, "profile", "", "the profile name used with -format profile")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.AccountId) == 0 {
+		c.Ui.Error("error: missing creds --accountid parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+	if c.Format != "export" && c.Format != "credential_process" && c.Format != "profile" {
+		c.Ui.Error(fmt.Sprintf("error: unknown creds format %s, expected export, credential_process or profile", c.Format))
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	creds, expires, err := org.GetCredentialsForAccount(c.AccountId)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not get credentials for account %s: %s", c.AccountId, err))
+		return 1
+	}
+
+	switch c.Format {
+	case "export":
+		fmt.Printf("export AWS_ACCESS_KEY_ID=%s\n", creds.AccessKeyID)
+		fmt.Printf("export AWS_SECRET_ACCESS_KEY=%s\n", creds.SecretAccessKey)
+		fmt.Printf("export AWS_SESSION_TOKEN=%s\n", creds.SessionToken)
+	case "credential_process":
+		// https://docs.aws.amazon.com/cli/latest/topic/config-vars.html#sourcing-credentials-from-external-processes
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"Version":         1,
+			"AccessKeyId":     creds.AccessKeyID,
+			"SecretAccessKey": creds.SecretAccessKey,
+			"SessionToken":    creds.SessionToken,
+			"Expiration":      expires.UTC().Format(time.RFC3339),
+		}, "", "  ")
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("error: could not print credentials: %s", err))
+			return 1
+		}
+		fmt.Printf("%s\n", data)
+	case "profile":
+		profile := c.Profile
+		if len(profile) == 0 {
+			profile = c.AccountId
+		}
+		fmt.Printf("[%s]\n", profile)
+		fmt.Printf("aws_access_key_id = %s\n", creds.AccessKeyID)
+		fmt.Printf("aws_secret_access_key = %s\n", creds.SecretAccessKey)
+		fmt.Printf("aws_session_token = %s\n", creds.SessionToken)
+	}
+
+	return 0
+}
+
+func (c *CredsCommand) Help() string {
+	helpText := `
+usage: organizer creds --accountid <account id> [--format export|credential_process|profile]
+
+print temporary credentials for the member account role of an account.
+
+	eval $(organizer creds -accountid 123456789012)
+
+or in ~/.aws/config:
+
+	[profile payments-prod]
+	credential_process = organizer creds -accountid 123456789012 -format credential_process
+
+options:
+
+	-accountid=<account id>	the account to get credentials for
+	-format=<format>	export prints shell export lines. default
+				credential_process prints json for the aws cli credential_process setting
+				profile prints a ~/.aws/credentials profile
+	-profile=<name>		the profile name used with -format profile. default is the account id
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *CredsCommand) Synopsis() string {
+	return "print temporary credentials for an account"
+}
+
+// Console
+type ConsoleCommand struct {
+	AccountId   string
+	Destination string
+	Ui          cli.Ui
+	orgOptions
+}
+
+func consoleCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &ConsoleCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *ConsoleCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("console", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.AccountId, "accountid", "", "the account to sign in to")
+	cmdFlags.StringVar(&c.Destination, "destination", "", "the console page to open after signing in")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.AccountId) == 0 {
+		c.Ui.Error("error: missing console --accountid parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	identity, err := org.GetCallerIdentity(ctx, c.AccountId)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not get caller identity in account %s: %s", c.AccountId, err))
+		return 1
+	}
+	c.Ui.Warn(fmt.Sprintf("signing in to %s as %s", c.AccountId, identity))
+
+	signin, err := org.GetConsoleURL(ctx, c.AccountId, c.Destination)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not get console sign in url for account %s: %s", c.AccountId, err))
+		return 1
+	}
+
+	fmt.Println(signin)
+
+	return 0
+}
+
+func (c *ConsoleCommand) Help() string {
+	helpText := `
+usage: organizer console --accountid <account id>
+
+print a url that signs in to the aws console of an account as the member
+account role. the url must be used within 15 minutes. the arn of the role is
+written to stderr.
+
+options:
+
+	-accountid=<account id>		the account to sign in to
+	-destination=<url>		the console page to open. default https://console.aws.amazon.com/
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *ConsoleCommand) Synopsis() string {
+	return "print a console sign in url for an account"
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/mitchellh/cli"
+	"github.com/pr8kerl/organizer/aws"
+)
+
+// environment variables that would override the injected credentials
+var awsCredentialEnv = []string{
+	"AWS_ACCESS_KEY_ID",
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN",
+	"AWS_SECURITY_TOKEN",
+	"AWS_PROFILE",
+	"AWS_DEFAULT_PROFILE",
+	"AWS_REGION",
+	"AWS_DEFAULT_REGION",
+}
+
+type execRow struct {
+	AccountId string `json:"account_id" yaml:"account_id"`
+	Name      string `json:"name" yaml:"name"`
+	ExitCode  int    `json:"exit_code" yaml:"exit_code"`
+	Error     string `json:"error" yaml:"error"`
+}
+
+// credentialsEnv returns the environment for a program running against
+// an account, with any inherited aws credentials replaced.
+func credentialsEnv(environ []string, creds credentials.Value, region string) []string {
+
+	env := make([]string, 0, len(environ)+6)
+	for _, kv := range environ {
+		keep := true
+		for _, name := range awsCredentialEnv {
+			if strings.HasPrefix(kv, name+"=") {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			env = append(env, kv)
+		}
+	}
+	return append(env,
+		"AWS_ACCESS_KEY_ID="+creds.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY="+creds.SecretAccessKey,
+		"AWS_SESSION_TOKEN="+creds.SessionToken,
+		"AWS_REGION="+region,
+		"AWS_DEFAULT_REGION="+region,
+	)
+
+}
+
+// prefixWriter writes each complete line to w with a prefix. Writers
+// sharing a mutex never interleave their lines.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		p.mu.Lock()
+		fmt.Fprintf(p.w, "%s%s", p.prefix, p.buf[:i+1])
+		p.mu.Unlock()
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush writes any trailing output without a newline.
+func (p *prefixWriter) Flush() {
+	if len(p.buf) > 0 {
+		p.mu.Lock()
+		fmt.Fprintf(p.w, "%s%s\n", p.prefix, p.buf)
+		p.mu.Unlock()
+		p.buf = nil
+	}
+}
+
+// Exec
+type ExecCommand struct {
+	Region   string
+	NoPrefix bool
+	Ui       cli.Ui
+	orgOptions
+}
+
+func execCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &ExecCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *ExecCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("exec", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.Region, "region", "", "the AWS_REGION given to the program")
+	cmdFlags.BoolVar(&c.NoPrefix, "no-prefix", false, "do not prefix output lines with the account")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	command := cmdFlags.Args()
+	if len(command) == 0 {
+		c.Ui.Error("error: missing exec command, eg. organizer exec -- aws s3 ls")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+	if len(c.Region) == 0 {
+		c.Region = org.Config().DefaultRegion()
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	accounts, err := c.selectAccounts(ctx, org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
+	}
+
+	var mu sync.Mutex
+	results := org.ForEachAccount(ctx, accounts, func(ctx context.Context, account *organizations.Account) (interface{}, error) {
+		return c.execForAccount(ctx, org, account, command, &mu)
+	})
+
+	rows, failed := execSummary(results)
+	if err := render(os.Stderr, c.output, rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print exec summary: %s", err))
+		return 1
+	}
+	if failed > 0 {
+		c.Ui.Error(fmt.Sprintf("error: %s failed in %d of %d accounts", command[0], failed, len(rows)))
+		return 1
+	}
+
+	return 0
+}
+
+// execSummary returns the exit code of the program in each account and
+// the number of accounts it failed in.
+func execSummary(results []*aws.AccountResult) ([]execRow, int) {
+
+	failed := 0
+	rows := make([]execRow, 0, len(results))
+	for _, result := range results {
+		row := execRow{AccountId: *result.Account.Id, Name: stringValue(result.Account.Name), ExitCode: -1}
+		if result.Err != nil {
+			row.Error = result.Err.Error()
+		} else {
+			row.ExitCode = result.Value.(int)
+		}
+		if row.ExitCode != 0 {
+			failed++
+		}
+		rows = append(rows, row)
+	}
+	return rows, failed
+
+}
+
+// execForAccount runs the command with credentials for one account and
+// returns its exit code.
+func (c *ExecCommand) execForAccount(ctx context.Context, org *aws.Organization, account *organizations.Account, command []string, mu *sync.Mutex) (int, error) {
+
+	creds, _, err := org.GetCredentialsForAccount(*account.Id)
+	if err != nil {
+		return -1, err
+	}
+
+	prefix := ""
+	if !c.NoPrefix {
+		prefix = fmt.Sprintf("[%s %s] ", *account.Id, stringValue(account.Name))
+	}
+	stdout := &prefixWriter{mu: mu, w: os.Stdout, prefix: prefix}
+	stderr := &prefixWriter{mu: mu, w: os.Stderr, prefix: prefix}
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Env = append(credentialsEnv(os.Environ(), creds, c.Region),
+		"ORGANIZER_ACCOUNT_ID="+*account.Id,
+		"ORGANIZER_ACCOUNT_NAME="+stringValue(account.Name),
+	)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = cmd.Run()
+	stdout.Flush()
+	stderr.Flush()
+
+	if ctx.Err() != nil {
+		return -1, ctx.Err()
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus(), nil
+		}
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+
+}
+
+func (c *ExecCommand) Help() string {
+	helpText := `
+usage: organizer exec [options] -- <command> [<args>]
+
+run a local program once for each selected account, eg.
+
+	organizer exec -accounts ou:/Workloads -- aws s3 ls
+
+the member account role is assumed for each account and the program is given
+AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION,
+ORGANIZER_ACCOUNT_ID and ORGANIZER_ACCOUNT_NAME in its environment. up to
+-parallel programs run at once and each line of output is prefixed with the
+account. a summary of exit codes is written to stderr once every program
+has finished.
+
+the credentials last for -session-duration, raise it for long running programs.
+
+options:
+
+	-region=<region>	the AWS_REGION given to the program. default is AWS_REGION, AWS_DEFAULT_REGION or the partition region
+	-no-prefix		do not prefix output lines with the account
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *ExecCommand) Synopsis() string {
+	return "run a program against each account in an organization"
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/pr8kerl/organizer/aws"
+)
+
+func TestCredentialsEnv(t *testing.T) {
+
+	environ := []string{
+		"PATH=/usr/bin:/bin",
+		"AWS_ACCESS_KEY_ID=AKIAINHERITED",
+		"AWS_SECRET_ACCESS_KEY=inherited",
+		"AWS_SESSION_TOKEN=inherited",
+		"AWS_SECURITY_TOKEN=inherited",
+		"AWS_PROFILE=admin",
+		"AWS_DEFAULT_PROFILE=admin",
+		"AWS_REGION=us-west-2",
+		"AWS_DEFAULT_REGION=us-west-2",
+		"AWS_CONFIG_FILE=/home/ops/.aws/config",
+		"AWS_PROFILE_NAME=kept",
+	}
+	creds := credentials.Value{AccessKeyID: "ASIAMEMBER", SecretAccessKey: "secret", SessionToken: "token"}
+
+	expected := []string{
+		"PATH=/usr/bin:/bin",
+		"AWS_CONFIG_FILE=/home/ops/.aws/config",
+		"AWS_PROFILE_NAME=kept",
+		"AWS_ACCESS_KEY_ID=ASIAMEMBER",
+		"AWS_SECRET_ACCESS_KEY=secret",
+		"AWS_SESSION_TOKEN=token",
+		"AWS_REGION=eu-west-1",
+		"AWS_DEFAULT_REGION=eu-west-1",
+	}
+	env := credentialsEnv(environ, creds, "eu-west-1")
+	if !reflect.DeepEqual(env, expected) {
+		t.Errorf("credentialsEnv returned\n%s\nexpected\n%s", strings.Join(env, "\n"), strings.Join(expected, "\n"))
+	}
+
+}
+
+func TestPrefixWriter(t *testing.T) {
+
+	tests := []struct {
+		writes  []string
+		written string
+		flushed string
+	}{
+		{[]string{"one\ntw", "o\nthree"}, "[a] one\n[a] two\n", "[a] one\n[a] two\n[a] three\n"},
+		{[]string{"one\n", "two\n"}, "[a] one\n[a] two\n", "[a] one\n[a] two\n"},
+		{[]string{"no newline"}, "", "[a] no newline\n"},
+		{[]string{"\n"}, "[a] \n", "[a] \n"},
+		{[]string{}, "", ""},
+	}
+	for i, test := range tests {
+		var out bytes.Buffer
+		w := &prefixWriter{mu: &sync.Mutex{}, w: &out, prefix: "[a] "}
+		for _, s := range test.writes {
+			if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+				t.Errorf("test %d: Write %q returned %d, %v", i, s, n, err)
+			}
+		}
+		if out.String() != test.written {
+			t.Errorf("test %d: wrote %q, expected %q", i, out.String(), test.written)
+		}
+		w.Flush()
+		if out.String() != test.flushed {
+			t.Errorf("test %d: wrote %q after Flush, expected %q", i, out.String(), test.flushed)
+		}
+	}
+
+}
+
+func TestPrefixWriterConcurrent(t *testing.T) {
+
+	var mu sync.Mutex
+	var out bytes.Buffer
+	var wg sync.WaitGroup
+	writers, lines := 8, 200
+	for n := 0; n < writers; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			w := &prefixWriter{mu: &mu, w: &out, prefix: fmt.Sprintf("[%d] ", n)}
+			// each line arrives in pieces so a line is only whole once
+			// the writer has buffered all of it
+			for i := 0; i < lines; i++ {
+				line := fmt.Sprintf("writer %d line %d\n", n, i)
+				w.Write([]byte(line[:5]))
+				w.Write([]byte(line[5:]))
+			}
+			w.Flush()
+		}(n)
+	}
+	wg.Wait()
+
+	printed := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(printed) != writers*lines {
+		t.Fatalf("wrote %d lines, expected %d", len(printed), writers*lines)
+	}
+	next := make([]int, writers)
+	for _, line := range printed {
+		var n, m, i int
+		if _, err := fmt.Sscanf(line, "[%d] writer %d line %d", &n, &m, &i); err != nil || n != m || i != next[n] {
+			t.Fatalf("line %q is interleaved with another writer", line)
+		}
+		next[n]++
+	}
+
+}
+
+func TestExecSummary(t *testing.T) {
+
+	account := func(id, name string) *organizations.Account {
+		return &organizations.Account{Id: &id, Name: &name}
+	}
+	results := []*aws.AccountResult{
+		{Account: account("222222222222", "prod-app"), Value: 0},
+		{Account: account("333333333333", "dev-app"), Value: 3},
+		{Account: account("444444444444", "audit"), Err: errors.New("AccessDenied: not authorized to perform sts:AssumeRole")},
+	}
+
+	expected := []execRow{
+		{AccountId: "222222222222", Name: "prod-app", ExitCode: 0},
+		{AccountId: "333333333333", Name: "dev-app", ExitCode: 3},
+		{AccountId: "444444444444", Name: "audit", ExitCode: -1, Error: "AccessDenied: not authorized to perform sts:AssumeRole"},
+	}
+	rows, failed := execSummary(results)
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("execSummary returned rows %+v, expected %+v", rows, expected)
+	}
+	if failed != 2 {
+		t.Errorf("execSummary counted %d failed accounts, expected 2", failed)
+	}
+
+}
@@ -0,0 +1,681 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/private/protocol/json/jsonutil"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// list calls return pages this size so that callers must follow tokens
+	fakePageSize = 2
+	// assumed role access keys are this prefix and the account id
+	fakeKeyPrefix = "ASIAFAKE"
+	fakeRootId    = "r-root"
+)
+
+// credentialScope picks the access key, region and service out of a
+// signature version 4 authorization header.
+var credentialScope = regexp.MustCompile(`Credential=([^/]+)/\d+/([^/]+)/([^/]+)/aws4_request`)
+
+// fakeFixture is an organization loaded from testdata.
+type fakeFixture struct {
+	Master   string                 `yaml:"master"`
+	OUs      []*fakeOU              `yaml:"ous"`
+	Accounts []*fakeAccount         `yaml:"accounts"`
+	Creates  map[string]*fakeCreate `yaml:"create_account"`
+}
+
+type fakeOU struct {
+	Id     string `yaml:"id"`
+	Name   string `yaml:"name"`
+	Parent string `yaml:"parent"`
+}
+
+type fakeAccount struct {
+	Id      string            `yaml:"id"`
+	Name    string            `yaml:"name"`
+	Email   string            `yaml:"email"`
+	Status  string            `yaml:"status"`
+	Parent  string            `yaml:"parent"`
+	NoRole  bool              `yaml:"no_role"`
+	Tags    map[string]string `yaml:"tags"`
+	Users   []string          `yaml:"users"`
+	Buckets []string          `yaml:"buckets"`
+	Trails  []*fakeTrail      `yaml:"trails"`
+}
+
+// fakeTrail is a trail seen from an account. Owner is the account the
+// trail belongs to when it is not the account it is seen from, eg. an
+// organization trail.
+type fakeTrail struct {
+	Name         string `yaml:"name"`
+	Owner        string `yaml:"owner"`
+	Region       string `yaml:"region"`
+	MultiRegion  bool   `yaml:"multi_region"`
+	Organization bool   `yaml:"organization"`
+}
+
+type fakeCreate struct {
+	States        []string `yaml:"states"`
+	FailureReason string   `yaml:"failure_reason"`
+}
+
+// fakeCreating is a CreateAccount request in progress.
+type fakeCreating struct {
+	id      string
+	account *fakeAccount
+	states  []string
+	reason  string
+}
+
+// fakeAWS emulates the parts of the organizations, sts, iam, s3, cloudfront
+// and cloudtrail apis organizer uses. Requests are told apart by the
+// service they are signed for, and member account requests by the access
+// key handed out by AssumeRole.
+type fakeAWS struct {
+	*httptest.Server
+	mu       sync.Mutex
+	fixture  *fakeFixture
+	creating map[string]*fakeCreating
+	deleted  []string
+	calls    map[string]int
+	home     string
+	env      map[string]string
+}
+
+// startFakeAWS serves the fixture and points organizer at it through the
+// environment. Close restores the environment.
+func startFakeAWS(t *testing.T, fixture string) *fakeAWS {
+
+	data, err := ioutil.ReadFile(fixture)
+	if err != nil {
+		t.Fatalf("could not read fixture: %s", err)
+	}
+	f := &fakeAWS{
+		fixture:  &fakeFixture{},
+		creating: make(map[string]*fakeCreating),
+		calls:    make(map[string]int),
+	}
+	if err := yaml.Unmarshal(data, f.fixture); err != nil {
+		t.Fatalf("could not parse fixture %s: %s", fixture, err)
+	}
+	f.Server = httptest.NewServer(f)
+
+	home, err := ioutil.TempDir("", "organizer-test")
+	if err != nil {
+		t.Fatalf("could not create home directory: %s", err)
+	}
+	env := map[string]string{
+		"HOME":                              home,
+		"AWS_ACCESS_KEY_ID":                 "AKIDMASTER",
+		"AWS_SECRET_ACCESS_KEY":             "secret",
+		"AWS_SESSION_TOKEN":                 "",
+		"AWS_PROFILE":                       "",
+		"AWS_REGION":                        "",
+		"AWS_DEFAULT_REGION":                "",
+		"AWS_SDK_LOAD_CONFIG":               "",
+		"AWS_CONFIG_FILE":                   home + "/config",
+		"AWS_SHARED_CREDENTIALS_FILE":       home + "/credentials",
+		"ORGANIZER_CONFIG":                  "",
+		"ORGANIZER_ENDPOINT":                f.URL,
+		"ORGANIZER_ACCOUNT_STATUS_INTERVAL": "1ms",
+	}
+	f.home = home
+	f.env = make(map[string]string, len(env))
+	for key, value := range env {
+		f.env[key] = os.Getenv(key)
+		os.Setenv(key, value)
+	}
+	return f
+
+}
+
+// Close stops the server and restores the environment.
+func (f *fakeAWS) Close() {
+	f.Server.Close()
+	for key, value := range f.env {
+		os.Setenv(key, value)
+	}
+	os.RemoveAll(f.home)
+}
+
+func (f *fakeAWS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	scope := credentialScope.FindStringSubmatch(r.Header.Get("Authorization"))
+	if scope == nil {
+		http.Error(w, "request is not signed", http.StatusForbidden)
+		return
+	}
+	key, region, service := scope[1], scope[2], scope[3]
+	accountid := f.fixture.Master
+	if strings.HasPrefix(key, fakeKeyPrefix) {
+		accountid = strings.TrimPrefix(key, fakeKeyPrefix)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch service {
+	case "organizations":
+		f.organizations(w, r)
+	case "cloudtrail":
+		f.cloudtrail(w, r, f.account(accountid), region)
+	case "sts":
+		f.sts(w, r, accountid)
+	case "iam":
+		f.iam(w, r, f.account(accountid))
+	case "s3":
+		f.s3(w, r, f.account(accountid))
+	case "cloudfront":
+		f.cloudfront(w, r)
+	default:
+		http.Error(w, "unknown service "+service, http.StatusBadRequest)
+	}
+
+}
+
+func (f *fakeAWS) account(id string) *fakeAccount {
+	for _, account := range f.fixture.Accounts {
+		if account.Id == id {
+			return account
+		}
+	}
+	return nil
+}
+
+// called counts how often an api action was called.
+func (f *fakeAWS) called(action string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[action]
+}
+
+// deletedTrails returns the arns of the trails deleted so far, sorted.
+func (f *fakeAWS) deletedTrails() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	deleted := append([]string{}, f.deleted...)
+	sort.Strings(deleted)
+	return deleted
+}
+
+// fakePage returns the slice bounds for the page starting at token.
+func fakePage(token *string, n int) (int, int, *string) {
+	start := 0
+	if token != nil {
+		fmt.Sscanf(*token, "%d", &start)
+	}
+	end := start + fakePageSize
+	if end >= n {
+		return start, n, nil
+	}
+	return start, end, aws.String(fmt.Sprintf("%d", end))
+}
+
+// json protocol services
+
+func jsonAction(r *http.Request) string {
+	target := r.Header.Get("X-Amz-Target")
+	return target[strings.LastIndex(target, ".")+1:]
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	body, err := jsonutil.BuildJSON(v)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "InternalFailure", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.Write(body)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"__type": code, "message": message})
+}
+
+func (f *fakeAWS) organizationsAccount(account *fakeAccount) *organizations.Account {
+	return &organizations.Account{
+		Id:     aws.String(account.Id),
+		Arn:    aws.String(fmt.Sprintf("arn:aws:organizations::%s:account/o-fake/%s", f.fixture.Master, account.Id)),
+		Name:   aws.String(account.Name),
+		Email:  aws.String(account.Email),
+		Status: aws.String(account.Status),
+	}
+}
+
+func (f *fakeAWS) organizations(w http.ResponseWriter, r *http.Request) {
+
+	action := jsonAction(r)
+	f.calls["organizations."+action]++
+	decode := func(input interface{}) bool {
+		if err := json.NewDecoder(r.Body).Decode(input); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "SerializationException", err.Error())
+			return false
+		}
+		return true
+	}
+
+	switch action {
+	case "ListAccounts":
+		var input organizations.ListAccountsInput
+		if !decode(&input) {
+			return
+		}
+		start, end, next := fakePage(input.NextToken, len(f.fixture.Accounts))
+		output := &organizations.ListAccountsOutput{NextToken: next}
+		for _, account := range f.fixture.Accounts[start:end] {
+			output.Accounts = append(output.Accounts, f.organizationsAccount(account))
+		}
+		writeJSON(w, output)
+
+	case "ListTagsForResource":
+		var input organizations.ListTagsForResourceInput
+		if !decode(&input) {
+			return
+		}
+		account := f.account(aws.StringValue(input.ResourceId))
+		if account == nil {
+			writeJSONError(w, http.StatusBadRequest, "TargetNotFoundException", "no account "+aws.StringValue(input.ResourceId))
+			return
+		}
+		keys := make([]string, 0, len(account.Tags))
+		for key := range account.Tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		start, end, next := fakePage(input.NextToken, len(keys))
+		output := &organizations.ListTagsForResourceOutput{NextToken: next}
+		for _, key := range keys[start:end] {
+			output.Tags = append(output.Tags, &organizations.Tag{Key: aws.String(key), Value: aws.String(account.Tags[key])})
+		}
+		writeJSON(w, output)
+
+	case "ListRoots":
+		writeJSON(w, &organizations.ListRootsOutput{Roots: []*organizations.Root{
+			{Id: aws.String(fakeRootId), Name: aws.String("Root")},
+		}})
+
+	case "ListOrganizationalUnitsForParent":
+		var input organizations.ListOrganizationalUnitsForParentInput
+		if !decode(&input) {
+			return
+		}
+		ous := make([]*organizations.OrganizationalUnit, 0)
+		for _, ou := range f.fixture.OUs {
+			if ou.Parent == aws.StringValue(input.ParentId) {
+				ous = append(ous, &organizations.OrganizationalUnit{Id: aws.String(ou.Id), Name: aws.String(ou.Name)})
+			}
+		}
+		start, end, next := fakePage(input.NextToken, len(ous))
+		writeJSON(w, &organizations.ListOrganizationalUnitsForParentOutput{OrganizationalUnits: ous[start:end], NextToken: next})
+
+	case "ListAccountsForParent":
+		var input organizations.ListAccountsForParentInput
+		if !decode(&input) {
+			return
+		}
+		accounts := make([]*organizations.Account, 0)
+		for _, account := range f.fixture.Accounts {
+			if account.Parent == aws.StringValue(input.ParentId) {
+				accounts = append(accounts, f.organizationsAccount(account))
+			}
+		}
+		start, end, next := fakePage(input.NextToken, len(accounts))
+		writeJSON(w, &organizations.ListAccountsForParentOutput{Accounts: accounts[start:end], NextToken: next})
+
+	case "CreateAccount":
+		var input organizations.CreateAccountInput
+		if !decode(&input) {
+			return
+		}
+		name := aws.StringValue(input.AccountName)
+		creating := &fakeCreating{
+			id:     fmt.Sprintf("car-%d", len(f.creating)+1),
+			states: []string{"SUCCEEDED"},
+			account: &fakeAccount{
+				Id:     fmt.Sprintf("%012d", 900000000000+len(f.creating)+1),
+				Name:   name,
+				Email:  aws.StringValue(input.Email),
+				Status: "ACTIVE",
+				Parent: fakeRootId,
+				Tags:   make(map[string]string),
+			},
+		}
+		if create, ok := f.fixture.Creates[name]; ok {
+			creating.states = append([]string{}, create.States...)
+			creating.reason = create.FailureReason
+		}
+		for _, tag := range input.Tags {
+			creating.account.Tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+		f.creating[creating.id] = creating
+		writeJSON(w, &organizations.CreateAccountOutput{CreateAccountStatus: &organizations.CreateAccountStatus{
+			Id:          aws.String(creating.id),
+			AccountName: aws.String(name),
+			State:       aws.String("IN_PROGRESS"),
+		}})
+
+	case "DescribeCreateAccountStatus":
+		var input organizations.DescribeCreateAccountStatusInput
+		if !decode(&input) {
+			return
+		}
+		creating, ok := f.creating[aws.StringValue(input.CreateAccountRequestId)]
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "CreateAccountStatusNotFoundException", "no request "+aws.StringValue(input.CreateAccountRequestId))
+			return
+		}
+		// each check moves the request on to its next state
+		state := creating.states[0]
+		if len(creating.states) > 1 {
+			creating.states = creating.states[1:]
+		}
+		status := &organizations.CreateAccountStatus{
+			Id:          aws.String(creating.id),
+			AccountName: aws.String(creating.account.Name),
+			State:       aws.String(state),
+		}
+		switch state {
+		case "SUCCEEDED":
+			status.AccountId = aws.String(creating.account.Id)
+			if f.account(creating.account.Id) == nil {
+				f.fixture.Accounts = append(f.fixture.Accounts, creating.account)
+			}
+		case "FAILED":
+			status.FailureReason = aws.String(creating.reason)
+		}
+		writeJSON(w, &organizations.DescribeCreateAccountStatusOutput{CreateAccountStatus: status})
+
+	default:
+		writeJSONError(w, http.StatusBadRequest, "InvalidAction", "unsupported organizations action "+action)
+	}
+
+}
+
+func (f *fakeAWS) cloudtrail(w http.ResponseWriter, r *http.Request, account *fakeAccount, region string) {
+
+	action := jsonAction(r)
+	f.calls["cloudtrail."+action]++
+	f.calls["cloudtrail."+action+" "+region]++
+	if account == nil {
+		writeJSONError(w, http.StatusForbidden, "AccessDeniedException", "unknown account")
+		return
+	}
+	arn := func(trail *fakeTrail) string {
+		owner := trail.Owner
+		if len(owner) == 0 {
+			owner = account.Id
+		}
+		return fmt.Sprintf("arn:aws:cloudtrail:%s:%s:trail/%s", trail.Region, owner, trail.Name)
+	}
+	var input struct {
+		Name *string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "SerializationException", err.Error())
+		return
+	}
+
+	switch action {
+	case "DescribeTrails":
+		output := &cloudtrail.DescribeTrailsOutput{TrailList: []*cloudtrail.Trail{}}
+		for _, trail := range account.Trails {
+			if trail.Region != region && !trail.MultiRegion {
+				continue
+			}
+			output.TrailList = append(output.TrailList, &cloudtrail.Trail{
+				Name:                aws.String(trail.Name),
+				TrailARN:            aws.String(arn(trail)),
+				HomeRegion:          aws.String(trail.Region),
+				IsMultiRegionTrail:  aws.Bool(trail.MultiRegion),
+				IsOrganizationTrail: aws.Bool(trail.Organization),
+			})
+		}
+		writeJSON(w, output)
+
+	case "GetTrailStatus":
+		writeJSON(w, &cloudtrail.GetTrailStatusOutput{IsLogging: aws.Bool(true)})
+
+	case "DeleteTrail":
+		for i, trail := range account.Trails {
+			if arn(trail) != aws.StringValue(input.Name) && trail.Name != aws.StringValue(input.Name) {
+				continue
+			}
+			if trail.Region != region {
+				writeJSONError(w, http.StatusBadRequest, "InvalidHomeRegionException", "trail must be deleted in its home region")
+				return
+			}
+			if len(trail.Owner) > 0 && trail.Owner != account.Id {
+				writeJSONError(w, http.StatusBadRequest, "NotOrganizationMasterAccountException", "trail belongs to another account")
+				return
+			}
+			f.deleted = append(f.deleted, arn(trail))
+			account.Trails = append(account.Trails[:i], account.Trails[i+1:]...)
+			writeJSON(w, &cloudtrail.DeleteTrailOutput{})
+			return
+		}
+		writeJSONError(w, http.StatusBadRequest, "TrailNotFoundException", "no trail "+aws.StringValue(input.Name))
+
+	default:
+		writeJSONError(w, http.StatusBadRequest, "InvalidAction", "unsupported cloudtrail action "+action)
+	}
+
+}
+
+// query protocol services
+
+type fakeQueryError struct {
+	XMLName   xml.Name `xml:"ErrorResponse"`
+	Type      string   `xml:"Error>Type"`
+	Code      string   `xml:"Error>Code"`
+	Message   string   `xml:"Error>Message"`
+	RequestId string   `xml:"RequestId"`
+}
+
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(v)
+}
+
+func writeQueryError(w http.ResponseWriter, status int, code string, message string) {
+	writeXML(w, status, &fakeQueryError{Type: "Sender", Code: code, Message: message, RequestId: "fake"})
+}
+
+type fakeAssumeRoleResponse struct {
+	XMLName         xml.Name `xml:"AssumeRoleResponse"`
+	AccessKeyId     string   `xml:"AssumeRoleResult>Credentials>AccessKeyId"`
+	SecretAccessKey string   `xml:"AssumeRoleResult>Credentials>SecretAccessKey"`
+	SessionToken    string   `xml:"AssumeRoleResult>Credentials>SessionToken"`
+	Expiration      string   `xml:"AssumeRoleResult>Credentials>Expiration"`
+	Arn             string   `xml:"AssumeRoleResult>AssumedRoleUser>Arn"`
+	AssumedRoleId   string   `xml:"AssumeRoleResult>AssumedRoleUser>AssumedRoleId"`
+}
+
+type fakeGetCallerIdentityResponse struct {
+	XMLName xml.Name `xml:"GetCallerIdentityResponse"`
+	Account string   `xml:"GetCallerIdentityResult>Account"`
+	Arn     string   `xml:"GetCallerIdentityResult>Arn"`
+	UserId  string   `xml:"GetCallerIdentityResult>UserId"`
+}
+
+func (f *fakeAWS) sts(w http.ResponseWriter, r *http.Request, accountid string) {
+
+	r.ParseForm()
+	action := r.Form.Get("Action")
+	f.calls["sts."+action]++
+
+	switch action {
+	case "AssumeRole":
+		role := r.Form.Get("RoleArn")
+		f.calls["sts.AssumeRole "+role]++
+		parts := strings.SplitN(role, ":", 6)
+		if len(parts) != 6 {
+			writeQueryError(w, http.StatusBadRequest, "ValidationError", "invalid role arn "+role)
+			return
+		}
+		account := f.account(parts[4])
+		if account == nil || account.NoRole {
+			writeQueryError(w, http.StatusForbidden, "AccessDenied", fmt.Sprintf("%s is not authorized to perform sts:AssumeRole on %s", accountid, role))
+			return
+		}
+		writeXML(w, http.StatusOK, &fakeAssumeRoleResponse{
+			AccessKeyId:     fakeKeyPrefix + account.Id,
+			SecretAccessKey: "secret",
+			SessionToken:    "token",
+			Expiration:      time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+			Arn:             fmt.Sprintf("arn:aws:sts::%s:assumed-role/%s/%s", account.Id, parts[5], r.Form.Get("RoleSessionName")),
+			AssumedRoleId:   "AROAFAKE:" + r.Form.Get("RoleSessionName"),
+		})
+
+	case "GetCallerIdentity":
+		writeXML(w, http.StatusOK, &fakeGetCallerIdentityResponse{
+			Account: accountid,
+			Arn:     fmt.Sprintf("arn:aws:iam::%s:root", accountid),
+			UserId:  accountid,
+		})
+
+	default:
+		writeQueryError(w, http.StatusBadRequest, "InvalidAction", "unsupported sts action "+action)
+	}
+
+}
+
+type fakeIamUser struct {
+	UserName   string `xml:"UserName"`
+	UserId     string `xml:"UserId"`
+	Arn        string `xml:"Arn"`
+	Path       string `xml:"Path"`
+	CreateDate string `xml:"CreateDate"`
+}
+
+type fakeListUsersResponse struct {
+	XMLName     xml.Name      `xml:"ListUsersResponse"`
+	Users       []fakeIamUser `xml:"ListUsersResult>Users>member"`
+	IsTruncated bool          `xml:"ListUsersResult>IsTruncated"`
+	Marker      string        `xml:"ListUsersResult>Marker,omitempty"`
+}
+
+type fakeListAccountAliasesResponse struct {
+	XMLName     xml.Name `xml:"ListAccountAliasesResponse"`
+	Aliases     []string `xml:"ListAccountAliasesResult>AccountAliases>member"`
+	IsTruncated bool     `xml:"ListAccountAliasesResult>IsTruncated"`
+}
+
+func (f *fakeAWS) iam(w http.ResponseWriter, r *http.Request, account *fakeAccount) {
+
+	r.ParseForm()
+	action := r.Form.Get("Action")
+	f.calls["iam."+action]++
+	if account == nil {
+		writeQueryError(w, http.StatusForbidden, "AccessDenied", "unknown account")
+		return
+	}
+
+	switch action {
+	case "ListUsers":
+		var marker *string
+		if m := r.Form.Get("Marker"); len(m) > 0 {
+			marker = aws.String(m)
+		}
+		start, end, next := fakePage(marker, len(account.Users))
+		output := &fakeListUsersResponse{IsTruncated: next != nil, Marker: aws.StringValue(next)}
+		for _, name := range account.Users[start:end] {
+			output.Users = append(output.Users, fakeIamUser{
+				UserName:   name,
+				UserId:     "AIDAFAKE" + strings.ToUpper(name),
+				Arn:        fmt.Sprintf("arn:aws:iam::%s:user/%s", account.Id, name),
+				Path:       "/",
+				CreateDate: "2018-01-01T00:00:00Z",
+			})
+		}
+		writeXML(w, http.StatusOK, output)
+
+	case "ListAccountAliases":
+		output := &fakeListAccountAliasesResponse{}
+		output.Aliases = append(output.Aliases, account.Name)
+		writeXML(w, http.StatusOK, output)
+
+	default:
+		writeQueryError(w, http.StatusBadRequest, "InvalidAction", "unsupported iam action "+action)
+	}
+
+}
+
+// rest protocol services
+
+type fakeS3Error struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+type fakeBucket struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+type fakeListBucketsResult struct {
+	XMLName xml.Name     `xml:"ListAllMyBucketsResult"`
+	OwnerId string       `xml:"Owner>ID"`
+	Buckets []fakeBucket `xml:"Buckets>Bucket"`
+}
+
+func (f *fakeAWS) s3(w http.ResponseWriter, r *http.Request, account *fakeAccount) {
+
+	f.calls["s3."+r.Method+" "+r.URL.Path]++
+	if account == nil {
+		writeXML(w, http.StatusForbidden, &fakeS3Error{Code: "AccessDenied", Message: "unknown account"})
+		return
+	}
+	if r.Method != "GET" || r.URL.Path != "/" {
+		writeXML(w, http.StatusNotImplemented, &fakeS3Error{Code: "NotImplemented", Message: "unsupported s3 request " + r.Method + " " + r.URL.Path})
+		return
+	}
+	output := &fakeListBucketsResult{OwnerId: account.Id}
+	for _, name := range account.Buckets {
+		output.Buckets = append(output.Buckets, fakeBucket{Name: name, CreationDate: "2018-01-01T00:00:00.000Z"})
+	}
+	writeXML(w, http.StatusOK, output)
+
+}
+
+type fakeDistributionList struct {
+	XMLName     xml.Name `xml:"DistributionList"`
+	Marker      string   `xml:"Marker"`
+	MaxItems    int      `xml:"MaxItems"`
+	IsTruncated bool     `xml:"IsTruncated"`
+	Quantity    int      `xml:"Quantity"`
+}
+
+func (f *fakeAWS) cloudfront(w http.ResponseWriter, r *http.Request) {
+
+	f.calls["cloudfront."+r.Method+" "+r.URL.Path]++
+	if r.Method != "GET" || !strings.HasSuffix(r.URL.Path, "/distribution") {
+		writeXML(w, http.StatusNotImplemented, &fakeS3Error{Code: "NotImplemented", Message: "unsupported cloudfront request " + r.Method + " " + r.URL.Path})
+		return
+	}
+	// no account in the fixtures has a distribution
+	writeXML(w, http.StatusOK, &fakeDistributionList{MaxItems: 100})
+
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/pr8kerl/organizer/aws"
+)
+
+// Generate AWS Config
+type GenerateAwsConfigCommand struct {
+	SourceProfile string
+	SSOStartURL   string
+	SSORegion     string
+	SSORoleName   string
+	Region        string
+	Merge         string
+	Write         bool
+	Ui            cli.Ui
+	orgOptions
+}
+
+func generateAwsConfigCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &GenerateAwsConfigCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *GenerateAwsConfigCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("generate aws-config", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.SourceProfile, "source-profile", "default", "the profile used to assume the member account role")
+	cmdFlags.StringVar(&c.SSOStartURL, "sso-start-url", "", "write aws sso profiles using this start url")
+	cmdFlags.StringVar(&c.SSORegion, "sso-region", "", "the aws sso region")
+	cmdFlags.StringVar(&c.SSORoleName, "sso-role-name", "", "the aws sso permission set name")
+	cmdFlags.StringVar(&c.Region, "region", "", "the default region of each profile")
+	cmdFlags.StringVar(&c.Merge, "merge", "", "merge the profiles into an existing config file")
+	cmdFlags.BoolVar(&c.Write, "write", false, "write the merged config back to the -merge file")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.SSOStartURL) > 0 && len(c.SSORoleName) == 0 {
+		c.Ui.Error("error: missing generate aws-config --sso-role-name parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+	if c.Write && len(c.Merge) == 0 {
+		c.Ui.Error("error: -write needs the config file given with -merge.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	existing := ""
+	if len(c.Merge) > 0 {
+		data, err := ioutil.ReadFile(c.Merge)
+		if err != nil && !os.IsNotExist(err) {
+			c.Ui.Error(fmt.Sprintf("error: could not read %s: %s", c.Merge, err))
+			return 1
+		}
+		existing = string(data)
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+	if len(c.SSORegion) == 0 {
+		c.SSORegion = org.Config().DefaultRegion()
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	accounts, err := c.selectAccounts(ctx, org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
+	}
+	aliases, errs := org.GetAliases(ctx, accounts)
+	printAccountErrors(c.Ui, "could not get aliases, keeping any existing profile", errs)
+
+	// accounts without an alias keep the profile merged before, and only a
+	// selection of every account may remove the profiles of other accounts
+	keep := make(map[string]bool)
+	for _, e := range errs {
+		keep[e.AccountId] = true
+	}
+
+	profiles := org.AccountProfiles(accounts, aliases, &aws.ProfileOptions{
+		SourceProfile: c.SourceProfile,
+		SSOStartURL:   c.SSOStartURL,
+		SSORegion:     c.SSORegion,
+		SSORoleName:   c.SSORoleName,
+		Region:        c.Region,
+	})
+
+	config, skipped := aws.MergeAwsConfig(existing, profiles, &aws.MergeOptions{Keep: keep, Prune: c.allAccounts()})
+	for _, name := range skipped {
+		c.Ui.Warn(fmt.Sprintf("warning: kept the hand written profile %s", name))
+	}
+
+	if !c.Write {
+		fmt.Print(config)
+		return 0
+	}
+
+	// keep a copy of the original in case the merge is not wanted
+	if len(existing) > 0 {
+		if err := ioutil.WriteFile(c.Merge+".bak", []byte(existing), 0600); err != nil {
+			c.Ui.Error(fmt.Sprintf("error: could not back up %s: %s", c.Merge, err))
+			return 1
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Merge), 0700); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not write %s: %s", c.Merge, err))
+		return 1
+	}
+	if err := ioutil.WriteFile(c.Merge, []byte(config), 0600); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not write %s: %s", c.Merge, err))
+		return 1
+	}
+	c.Ui.Warn(fmt.Sprintf("wrote %d profiles to %s", len(profiles)-len(skipped), c.Merge))
+
+	return 0
+}
+
+func (c *GenerateAwsConfigCommand) Help() string {
+	helpText := `
+usage: organizer generate aws-config [options]
+
+print an aws cli config profile for every active account, named after the
+account alias or the account name. by default each profile assumes the member
+account role from -source-profile. with -sso-start-url aws sso profiles are
+written instead.
+
+with -merge the profiles are merged into an existing config file. profiles
+written by organizer before for the selected accounts are updated. those of
+other accounts are only removed when every account is selected, as when an
+account has left the organization. hand written profiles are always kept.
+
+	organizer generate aws-config -merge ~/.aws/config -write
+
+options:
+
+	-source-profile=<name>	the profile used to assume the member account role. default default
+	-sso-start-url=<url>	write aws sso profiles using this start url
+	-sso-region=<region>	the aws sso region. default is AWS_REGION, AWS_DEFAULT_REGION or the partition region
+	-sso-role-name=<name>	the aws sso permission set name
+	-region=<region>	the default region of each profile
+	-merge=<file>		merge the profiles into an existing config file
+	-write			write the merged config back to the -merge file, keeping a .bak copy
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *GenerateAwsConfigCommand) Synopsis() string {
+	return "generate an aws cli config with a profile for every account"
+}
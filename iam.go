@@ -0,0 +1,731 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/mitchellh/cli"
+	"github.com/pr8kerl/organizer/aws"
+)
+
+// dayFlag is a duration flag that also accepts a number of days, eg. 90d.
+type dayFlag time.Duration
+
+func (d *dayFlag) String() string {
+	return time.Duration(*d).String()
+}
+
+func (d *dayFlag) Set(s string) error {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days < 0 {
+			return fmt.Errorf("invalid number of days %s", s)
+		}
+		*d = dayFlag(time.Duration(days) * 24 * time.Hour)
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = dayFlag(v)
+	return nil
+}
+
+type accessKeyRow struct {
+	AccountId       string     `json:"account_id" yaml:"account_id"`
+	UserName        string     `json:"user_name" yaml:"user_name"`
+	AccessKeyId     string     `json:"access_key_id" yaml:"access_key_id"`
+	Status          string     `json:"status" yaml:"status"`
+	CreateDate      *time.Time `json:"create_date" yaml:"create_date"`
+	AgeDays         int        `json:"age_days" yaml:"age_days"`
+	LastUsed        *time.Time `json:"last_used" yaml:"last_used"`
+	LastUsedService string     `json:"last_used_service" yaml:"last_used_service"`
+}
+
+type accessKeyActionRow struct {
+	AccountId   string `json:"account_id" yaml:"account_id"`
+	UserName    string `json:"user_name" yaml:"user_name"`
+	AccessKeyId string `json:"access_key_id" yaml:"access_key_id"`
+	Action      string `json:"action" yaml:"action"`
+	Reason      string `json:"reason" yaml:"reason"`
+}
+
+// IAM Keys
+type IamKeysCommand struct {
+	AccountId           string
+	DeactivateOlderThan dayFlag
+	DeleteInactive      bool
+	DryRun              bool
+	AutoApprove         bool
+	AuditLog            string
+	Ui                  cli.Ui
+	orgOptions
+}
+
+func iamKeysCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &IamKeysCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *IamKeysCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("iam keys", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.AccountId, "accountid", "", "list access keys for a specific account")
+	cmdFlags.Var(&c.DeactivateOlderThan, "deactivate-older-than", "deactivate active access keys older than this, eg. 90d")
+	cmdFlags.BoolVar(&c.DeleteInactive, "delete-inactive", false, "delete access keys that are inactive")
+	cmdFlags.BoolVar(&c.DryRun, "dry-run", false, "show the access keys a cleanup would change")
+	cmdFlags.BoolVar(&c.AutoApprove, "auto-approve", false, "clean up without asking for confirmation")
+	cmdFlags.StringVar(&c.AuditLog, "audit-log", "organizer-audit.log", "file every access key change is appended to")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	if len(c.AccountId) > 0 {
+		c.accounts = c.AccountId
+	}
+	accounts, err := c.selectAccounts(ctx, org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
+	}
+
+	keys, errs := org.GetAccessKeys(ctx, accounts)
+	printAccountErrors(c.Ui, "could not list access keys", errs)
+
+	if c.DeactivateOlderThan > 0 || c.DeleteInactive {
+		return c.runCleanup(ctx, org, keys)
+	}
+
+	now := time.Now()
+	rows := make([]accessKeyRow, 0, len(keys))
+	for _, key := range keys {
+		row := accessKeyRow{
+			AccountId:       key.AccountId,
+			UserName:        key.UserName,
+			AccessKeyId:     key.AccessKeyId,
+			Status:          key.Status,
+			CreateDate:      key.CreateDate,
+			LastUsed:        key.LastUsed,
+			LastUsedService: key.LastUsedService,
+		}
+		if key.CreateDate != nil {
+			row.AgeDays = int(now.Sub(*key.CreateDate).Hours() / 24)
+		}
+		rows = append(rows, row)
+	}
+
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print access keys: %s", err))
+		return 1
+	}
+
+	return 0
+}
+
+// runCleanup shows which access keys will be deactivated or deleted and
+// changes them once confirmed.
+func (c *IamKeysCommand) runCleanup(ctx context.Context, org *aws.Organization, keys []*aws.AccessKey) int {
+
+	actions := aws.PlanAccessKeyCleanup(keys, &aws.AccessKeyCleanupOptions{
+		DeactivateOlderThan: time.Duration(c.DeactivateOlderThan),
+		DeleteInactive:      c.DeleteInactive,
+	}, time.Now())
+
+	rows := make([]accessKeyActionRow, 0, len(actions))
+	for _, action := range actions {
+		rows = append(rows, accessKeyActionRow{
+			AccountId:   action.Key.AccountId,
+			UserName:    action.Key.UserName,
+			AccessKeyId: action.Key.AccessKeyId,
+			Action:      action.Action,
+			Reason:      action.Reason,
+		})
+	}
+
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print access keys: %s", err))
+		return 1
+	}
+	if len(actions) == 0 {
+		c.Ui.Warn("no access keys to clean up.")
+		return 0
+	}
+	if c.DryRun {
+		c.Ui.Warn(fmt.Sprintf("dry run, %d access keys would be changed.", len(actions)))
+		return 0
+	}
+
+	if !c.AutoApprove {
+		answer, err := c.Ui.Ask(fmt.Sprintf("change %d access keys? only 'yes' will be accepted:", len(actions)))
+		if err != nil || answer != "yes" {
+			c.Ui.Error("cleanup cancelled.")
+			return 1
+		}
+	}
+
+	audit, err := os.OpenFile(c.AuditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not open audit log: %s", err))
+		return 1
+	}
+	defer audit.Close()
+
+	errs := org.ApplyAccessKeyActions(ctx, actions, audit)
+	printAccountErrors(c.Ui, "could not clean up access keys", errs)
+	if len(errs) > 0 {
+		c.Ui.Error(fmt.Sprintf("error: cleanup failed in %d accounts, see %s", len(errs), c.AuditLog))
+		return 1
+	}
+
+	c.Ui.Warn(fmt.Sprintf("changed %d access keys, see %s", len(actions), c.AuditLog))
+
+	return 0
+}
+
+func (c *IamKeysCommand) Help() string {
+	helpText := `usage: organizer iam keys [<args>]
+
+List the access keys of every iam user in the accounts of an organization, with
+their age, status and when they were last used.
+
+with -deactivate-older-than or -delete-inactive the keys to change are shown and
+confirmation is asked for first. every change is appended to the audit log as a
+line of json. to enforce the policy on a schedule run, eg.
+
+	organizer iam keys -deactivate-older-than 90d -delete-inactive -auto-approve
+
+Options:
+	    -accountid			list access keys for a specific account only, same as -accounts <id>
+	    -deactivate-older-than	deactivate active access keys created longer ago than this, eg. 90d or 2160h
+	    -delete-inactive		delete access keys that are already inactive. keys deactivated in
+	    				the same run are kept until the next run
+	    -dry-run			show the access keys a cleanup would change without changing them
+	    -auto-approve		clean up without asking for confirmation
+	    -audit-log			file every access key change is appended to. default organizer-audit.log
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *IamKeysCommand) Synopsis() string {
+	return "list, deactivate and delete iam access keys across accounts"
+}
+
+type trustEdgeRow struct {
+	RoleAccount      string `json:"role_account" yaml:"role_account"`
+	RoleName         string `json:"role_name" yaml:"role_name"`
+	Principal        string `json:"principal" yaml:"principal"`
+	PrincipalType    string `json:"principal_type" yaml:"principal_type"`
+	PrincipalAccount string `json:"principal_account" yaml:"principal_account"`
+	Scope            string `json:"scope" yaml:"scope"`
+}
+
+// IAM Trust Graph
+type IamTrustGraphCommand struct {
+	AccountId string
+	Dot       bool
+	External  bool
+	All       bool
+	Ui        cli.Ui
+	orgOptions
+}
+
+func iamTrustGraphCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &IamTrustGraphCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *IamTrustGraphCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("iam trust-graph", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.AccountId, "accountid", "", "read the roles of a specific account")
+	cmdFlags.BoolVar(&c.Dot, "dot", false, "print the trust graph in graphviz dot format")
+	cmdFlags.BoolVar(&c.External, "external", false, "show only trusts of principals outside the organization")
+	cmdFlags.BoolVar(&c.All, "all", false, "include same account, service and federated principals")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	if len(c.AccountId) > 0 {
+		c.accounts = c.AccountId
+	}
+	accounts, err := c.selectAccounts(ctx, org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
+	}
+	members, names, err := organizationMembers(org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not list accounts: %s", err))
+		return 1
+	}
+
+	roles, errs := org.GetRoles(ctx, accounts)
+	printAccountErrors(c.Ui, "could not list roles", errs)
+
+	edges := make([]*aws.TrustEdge, 0, len(roles))
+	for _, edge := range aws.TrustEdges(roles, members, c.All) {
+		if c.External && !edge.External() {
+			continue
+		}
+		edges = append(edges, edge)
+	}
+
+	if c.Dot {
+		fmt.Print(aws.TrustGraphDot(edges, names))
+		// the external trusts go to stderr to keep the graph usable
+		for _, edge := range edges {
+			if edge.External() {
+				c.Ui.Warn(fmt.Sprintf("%s trust: %s may assume %s", edge.Scope, edge.Principal, edge.RoleArn))
+			}
+		}
+		return 0
+	}
+
+	rows := make([]trustEdgeRow, 0, len(edges))
+	for _, edge := range edges {
+		rows = append(rows, trustEdgeRow{
+			RoleAccount:      edge.RoleAccount,
+			RoleName:         edge.RoleName,
+			Principal:        edge.Principal,
+			PrincipalType:    edge.PrincipalType,
+			PrincipalAccount: edge.PrincipalAccount,
+			Scope:            edge.Scope,
+		})
+	}
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print trusts: %s", err))
+		return 1
+	}
+
+	return 0
+}
+
+func (c *IamTrustGraphCommand) Help() string {
+	helpText := `usage: organizer iam trust-graph [<args>]
+
+Read the trust policy of every role in the selected accounts and show which
+principals may assume them. each principal is classified as organization when
+it is another account of the organization, external when it is not, public for
+a * principal and unknown for unique ids left behind by deleted principals.
+
+by default the trusts are printed as a table, or json or yaml with -output. with
+-dot a graphviz graph between accounts is printed and the external trusts are
+listed on stderr:
+
+	organizer iam trust-graph -dot | dot -Tsvg > trust.svg
+
+Options:
+	    -accountid		read the roles of a specific account only, same as -accounts <id>
+	    -dot		print the trust graph in graphviz dot format
+	    -external		show only trusts of principals outside the organization
+	    -all		include same account, service and federated principals
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *IamTrustGraphCommand) Synopsis() string {
+	return "show which principals may assume roles across accounts"
+}
+
+type passwordPolicyRow struct {
+	AccountId string `json:"account_id" yaml:"account_id"`
+	Setting   string `json:"setting" yaml:"setting"`
+	Actual    string `json:"actual" yaml:"actual"`
+	Expected  string `json:"expected" yaml:"expected"`
+}
+
+// IAM Password Policy
+type IamPasswordPolicyCommand struct {
+	AccountId   string
+	Check       bool
+	Apply       bool
+	File        string
+	AutoApprove bool
+	Ui          cli.Ui
+	orgOptions
+}
+
+func iamPasswordPolicyCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &IamPasswordPolicyCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *IamPasswordPolicyCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("iam password-policy", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.AccountId, "accountid", "", "check the password policy of a specific account")
+	cmdFlags.BoolVar(&c.Check, "check", false, "compare each account password policy with the baseline")
+	cmdFlags.BoolVar(&c.Apply, "apply", false, "set the baseline password policy in accounts that differ")
+	cmdFlags.StringVar(&c.File, "f", "", "the json file holding the baseline password policy")
+	cmdFlags.BoolVar(&c.AutoApprove, "auto-approve", false, "apply without asking for confirmation")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if c.Check == c.Apply {
+		c.Ui.Error("error: iam password-policy needs one of -check or -apply.")
+		cmdFlags.Usage()
+		return 1
+	}
+	if len(c.File) == 0 {
+		c.Ui.Error("error: missing iam password-policy -f parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+	data, err := ioutil.ReadFile(c.File)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not read %s: %s", c.File, err))
+		return 1
+	}
+	baseline, err := aws.ParsePasswordPolicy(data)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: %s", err))
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	if len(c.AccountId) > 0 {
+		c.accounts = c.AccountId
+	}
+	accounts, err := c.selectAccounts(ctx, org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
+	}
+
+	drift, errs := org.CheckPasswordPolicies(ctx, accounts, baseline)
+	printAccountErrors(c.Ui, "could not get password policy", errs)
+
+	differ := make(map[string]bool)
+	rows := make([]passwordPolicyRow, 0, len(drift))
+	for _, d := range drift {
+		differ[d.AccountId] = true
+		rows = append(rows, passwordPolicyRow{AccountId: d.AccountId, Setting: d.Setting, Actual: d.Actual, Expected: d.Expected})
+	}
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print password policies: %s", err))
+		return 1
+	}
+
+	if len(differ) == 0 {
+		c.Ui.Warn("every account matches the baseline password policy.")
+		return 0
+	}
+	if c.Check {
+		c.Ui.Warn(fmt.Sprintf("%d accounts differ from the baseline password policy.", len(differ)))
+		return 2
+	}
+
+	if !c.AutoApprove {
+		answer, err := c.Ui.Ask(fmt.Sprintf("replace the password policy in %d accounts? only 'yes' will be accepted:", len(differ)))
+		if err != nil || answer != "yes" {
+			c.Ui.Error("apply cancelled.")
+			return 1
+		}
+	}
+
+	selected := make([]*organizations.Account, 0, len(differ))
+	for _, account := range accounts {
+		if differ[*account.Id] {
+			selected = append(selected, account)
+		}
+	}
+	errs = org.ApplyPasswordPolicy(ctx, selected, baseline)
+	printAccountErrors(c.Ui, "could not set password policy", errs)
+	if len(errs) > 0 {
+		return 1
+	}
+
+	c.Ui.Warn(fmt.Sprintf("set the baseline password policy in %d accounts.", len(selected)))
+
+	return 0
+}
+
+func (c *IamPasswordPolicyCommand) Help() string {
+	helpText := `usage: organizer iam password-policy -check|-apply -f <policy.json> [<args>]
+
+Compare the iam password policy of every account with a baseline, and set the
+baseline where it differs. the baseline is json using the iam setting names:
+
+	{
+	  "MinimumPasswordLength": 14,
+	  "RequireSymbols": true,
+	  "RequireNumbers": true,
+	  "RequireUppercaseCharacters": true,
+	  "RequireLowercaseCharacters": true,
+	  "AllowUsersToChangePassword": true,
+	  "MaxPasswordAge": 90,
+	  "PasswordReusePrevention": 24
+	}
+
+settings left out of the baseline are not checked, but -apply replaces the whole
+policy so they go back to their iam defaults. -check exits 2 if any account
+differs.
+
+Options:
+	    -accountid		check a specific account only, same as -accounts <id>
+	    -check		compare each account password policy with the baseline
+	    -apply		set the baseline password policy in accounts that differ, after confirmation
+	    -f			the json file holding the baseline password policy
+	    -auto-approve	apply without asking for confirmation
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *IamPasswordPolicyCommand) Synopsis() string {
+	return "check and apply a baseline iam password policy across accounts"
+}
+
+type offboardUserRow struct {
+	AccountId           string   `json:"account_id" yaml:"account_id"`
+	UserName            string   `json:"user_name" yaml:"user_name"`
+	LoginProfile        bool     `json:"login_profile" yaml:"login_profile"`
+	AccessKeys          []string `json:"access_keys" yaml:"access_keys"`
+	MFADevices          []string `json:"mfa_devices" yaml:"mfa_devices"`
+	Groups              []string `json:"groups" yaml:"groups"`
+	AttachedPolicies    []string `json:"attached_policies" yaml:"attached_policies"`
+	InlinePolicies      []string `json:"inline_policies" yaml:"inline_policies"`
+	SigningCertificates []string `json:"signing_certificates" yaml:"signing_certificates"`
+	SSHPublicKeys       []string `json:"ssh_public_keys" yaml:"ssh_public_keys"`
+	ServiceCredentials  []string `json:"service_credentials" yaml:"service_credentials"`
+}
+
+type offboardRecordRow struct {
+	AccountId string `json:"account_id" yaml:"account_id"`
+	UserName  string `json:"user_name" yaml:"user_name"`
+	Action    string `json:"action" yaml:"action"`
+	Target    string `json:"target" yaml:"target"`
+	Error     string `json:"error" yaml:"error"`
+}
+
+// IAM Offboard
+type IamOffboardCommand struct {
+	AccountId   string
+	Users       listFlags
+	Delete      bool
+	DryRun      bool
+	AutoApprove bool
+	Report      string
+	Ui          cli.Ui
+	orgOptions
+}
+
+func iamOffboardCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &IamOffboardCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *IamOffboardCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("iam offboard", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.AccountId, "accountid", "", "offboard users in a specific account")
+	cmdFlags.Var(&c.Users, "user", "the user name or pattern to offboard, may be repeated")
+	cmdFlags.BoolVar(&c.Delete, "delete", false, "delete the users instead of disabling them")
+	cmdFlags.BoolVar(&c.DryRun, "dry-run", false, "show the users that would be offboarded")
+	cmdFlags.BoolVar(&c.AutoApprove, "auto-approve", false, "offboard without asking for confirmation")
+	cmdFlags.StringVar(&c.Report, "report", "organizer-offboard.log", "file every offboard action is appended to")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.Users) == 0 {
+		c.Ui.Error("error: missing iam offboard -user parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	if len(c.AccountId) > 0 {
+		c.accounts = c.AccountId
+	}
+	accounts, err := c.selectAccounts(ctx, org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
+	}
+
+	users, errs := org.FindUsers(ctx, accounts, c.Users)
+	printAccountErrors(c.Ui, "could not look for users", errs)
+
+	rows := make([]offboardUserRow, 0, len(users))
+	for _, u := range users {
+		rows = append(rows, offboardUserRow{
+			AccountId:           u.AccountId,
+			UserName:            u.UserName,
+			LoginProfile:        u.LoginProfile,
+			AccessKeys:          u.AccessKeys,
+			MFADevices:          u.MFADevices,
+			Groups:              u.Groups,
+			AttachedPolicies:    u.AttachedPolicies,
+			InlinePolicies:      u.InlinePolicies,
+			SigningCertificates: u.SigningCertificates,
+			SSHPublicKeys:       u.SSHPublicKeys,
+			ServiceCredentials:  u.ServiceCredentials,
+		})
+	}
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print users: %s", err))
+		return 1
+	}
+
+	verb := "disable"
+	if c.Delete {
+		verb = "delete"
+	}
+	if len(users) == 0 {
+		c.Ui.Warn("no matching users found.")
+		return 0
+	}
+	if c.DryRun {
+		c.Ui.Warn(fmt.Sprintf("dry run, %d users would be %sd.", len(users), verb))
+		return 0
+	}
+	// errors finding users are likely to leave some unseen
+	if len(errs) > 0 {
+		c.Ui.Warn(fmt.Sprintf("warning: %d accounts could not be searched.", len(errs)))
+	}
+
+	if !c.AutoApprove {
+		answer, err := c.Ui.Ask(fmt.Sprintf("%s %d users? only 'yes' will be accepted:", verb, len(users)))
+		if err != nil || answer != "yes" {
+			c.Ui.Error("offboard cancelled.")
+			return 1
+		}
+	}
+
+	report, err := os.OpenFile(c.Report, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not open report: %s", err))
+		return 1
+	}
+	defer report.Close()
+
+	records, errs := org.OffboardUsers(ctx, users, c.Delete, report)
+	actions := make([]offboardRecordRow, 0, len(records))
+	for _, r := range records {
+		actions = append(actions, offboardRecordRow{AccountId: r.AccountId, UserName: r.UserName, Action: r.Action, Target: r.Target, Error: r.Error})
+	}
+	if err := c.render(actions); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print offboard actions: %s", err))
+		return 1
+	}
+	printAccountErrors(c.Ui, "could not offboard users", errs)
+	if len(errs) > 0 {
+		c.Ui.Error(fmt.Sprintf("error: offboard failed in %d accounts, see %s", len(errs), c.Report))
+		return 1
+	}
+
+	c.Ui.Warn(fmt.Sprintf("%sd %d users, see %s", verb, len(users), c.Report))
+
+	return 0
+}
+
+func (c *IamOffboardCommand) Help() string {
+	helpText := `usage: organizer iam offboard -user <name or pattern> [<args>]
+
+Find the iam users matching a name or pattern in every selected account and
+show their console login, access keys, mfa devices, groups and policies. once
+confirmed the users are disabled, removing their console login and deactivating
+their keys and credentials. with -delete everything is removed in the order iam
+needs and the users are deleted. user names are matched case insensitively.
+
+every action is appended to the report as a line of json.
+
+	organizer iam offboard -user 'jdoe*' -delete
+
+Options:
+	    -accountid		offboard users in a specific account only, same as -accounts <id>
+	    -user		the user name or pattern to offboard, eg. jdoe*. may be repeated
+	    -delete		delete the users instead of disabling them
+	    -dry-run		show the users that would be offboarded without changing them
+	    -auto-approve	offboard without asking for confirmation
+	    -report		file every offboard action is appended to. default organizer-offboard.log
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *IamOffboardCommand) Synopsis() string {
+	return "disable or delete a person's iam users across accounts"
+}
@@ -13,15 +13,51 @@ func main() {
 	c.Args = os.Args[1:]
 
 	c.Commands = map[string]cli.CommandFactory{
-		"list":            listCmdFactory,
-		"list accounts":   listAccountsCmdFactory,
-		"list aliases":    listAliasesCmdFactory,
-		"list buckets":    listBucketsCmdFactory,
-		"list cloudfront": listCloudfrontsCmdFactory,
-		"list users":      listUsersCmdFactory,
-		"create":          createCmdFactory,
-		"create account":  createAccountCmdFactory,
-		"trails":          trailsCmdFactory,
+		"list":                listCmdFactory,
+		"list accounts":       listAccountsCmdFactory,
+		"list aliases":        listAliasesCmdFactory,
+		"list buckets":        listBucketsCmdFactory,
+		"list cloudfront":     listCloudfrontsCmdFactory,
+		"list ous":            listOUsCmdFactory,
+		"list policies":       listPoliciesCmdFactory,
+		"list roles":          listRolesCmdFactory,
+		"list users":          listUsersCmdFactory,
+		"create":              createCmdFactory,
+		"create account":      createAccountCmdFactory,
+		"create ou":           createOUCmdFactory,
+		"create policy":       createPolicyCmdFactory,
+		"show":                showCmdFactory,
+		"show policy":         showPolicyCmdFactory,
+		"attach":              attachCmdFactory,
+		"attach policy":       attachPolicyCmdFactory,
+		"detach":              detachCmdFactory,
+		"detach policy":       detachPolicyCmdFactory,
+		"rename":              renameCmdFactory,
+		"rename ou":           renameOUCmdFactory,
+		"delete":              deleteCmdFactory,
+		"delete ou":           deleteOUCmdFactory,
+		"move":                moveCmdFactory,
+		"move account":        moveAccountCmdFactory,
+		"generate":            generateCmdFactory,
+		"generate aws-config": generateAwsConfigCmdFactory,
+		"plan":                planCmdFactory,
+		"apply":               applyCmdFactory,
+		"tag":                 tagCmdFactory,
+		"tag account":         tagAccountCmdFactory,
+		"untag":               untagCmdFactory,
+		"untag account":       untagAccountCmdFactory,
+		"console":             consoleCmdFactory,
+		"creds":               credsCmdFactory,
+		"exec":                execCmdFactory,
+		"trails":              trailsCmdFactory,
+		"trails lookup":       trailsLookupCmdFactory,
+		"trails status":       trailsStatusCmdFactory,
+		"iam":                 iamCmdFactory,
+		"iam keys":            iamKeysCmdFactory,
+		"iam trust-graph":     iamTrustGraphCmdFactory,
+		"iam password-policy": iamPasswordPolicyCmdFactory,
+		"iam offboard":        iamOffboardCmdFactory,
+		"aliases":             aliasesCmdFactory,
 	}
 
 	exitStatus, err := c.Run()
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/mitchellh/cli"
+	"github.com/pr8kerl/organizer/aws"
+)
+
+const orgOptionsHelp = `
+
+Organization options:
+	    -config		yaml config file. default is $ORGANIZER_CONFIG or ~/.organizer.yml
+	    -role-name		member account role name. default OrganizationAccountAccessRole
+	    -role-path		member account role path. default /
+	    -external-id	external id used when assuming the member account role
+	    -session-prefix	assumed role session name prefix. default organizer
+	    -session-duration	assumed role session duration, 15m to 12h or at most 1h with -role-chain. default 15m
+	    -role-chain		comma separated role arns to assume before reaching the organization master
+	    -parallel		number of accounts to work on at once. default 10
+	    -timeout		time limit for the work on each account, eg. 2m. default no limit
+	    -output		output format: table, csv, json, ndjson or yaml. default table
+	    -accounts		accounts to work on: ids, name globs, ou:/path, tag:key=value, !exclusions or @file
+	    -filter		only work on accounts with matching tags, eg. tag:env=prod,tag:owner=payments
+	    -partition		aws partition of the organization: aws, aws-us-gov or aws-cn. default aws
+	    -endpoint-url	send aws api calls to this url, or service=url for one service. may be repeated
+`
+
+// orgOptions are the settings shared by every command that talks to the
+// organization. Settings are taken from the config file, then ORGANIZER_*
+// environment variables, then command line flags.
+type orgOptions struct {
+	configFile      string
+	roleName        string
+	rolePath        string
+	externalId      string
+	sessionPrefix   string
+	sessionDuration time.Duration
+	roleChain       string
+	parallel        int
+	accountTimeout  time.Duration
+	output          string
+	accounts        string
+	filter          string
+	partition       string
+	endpoints       endpointFlags
+}
+
+func (o *orgOptions) addFlags(f *flag.FlagSet) {
+	f.StringVar(&o.configFile, "config", "", "yaml config file")
+	f.StringVar(&o.roleName, "role-name", "", "member account role name")
+	f.StringVar(&o.rolePath, "role-path", "", "member account role path")
+	f.StringVar(&o.externalId, "external-id", "", "external id used when assuming the member account role")
+	f.StringVar(&o.sessionPrefix, "session-prefix", "", "assumed role session name prefix")
+	f.DurationVar(&o.sessionDuration, "session-duration", 0, "assumed role session duration")
+	f.StringVar(&o.roleChain, "role-chain", "", "comma separated role arns to assume before reaching the organization master")
+	f.IntVar(&o.parallel, "parallel", 0, "number of accounts to work on at once")
+	f.DurationVar(&o.accountTimeout, "timeout", 0, "time limit for the work on each account")
+	f.StringVar(&o.output, "output", "", "output format")
+	f.StringVar(&o.accounts, "accounts", "", "accounts to work on")
+	f.StringVar(&o.filter, "filter", "", "only work on accounts with matching tags")
+	f.StringVar(&o.partition, "partition", "", "aws partition of the organization")
+	f.Var(&o.endpoints, "endpoint-url", "send aws api calls to this url, or service=url for one service")
+}
+
+func (o *orgOptions) config() (*aws.Config, error) {
+
+	config := aws.DefaultConfig()
+
+	path := o.configFile
+	if len(path) == 0 {
+		path = os.Getenv("ORGANIZER_CONFIG")
+	}
+	if len(path) == 0 {
+		if home := os.Getenv("HOME"); len(home) > 0 {
+			path = filepath.Join(home, ".organizer.yml")
+			if _, err := os.Stat(path); err != nil {
+				path = ""
+			}
+		}
+	}
+	if len(path) > 0 {
+		if err := config.LoadConfigFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.ApplyEnv(); err != nil {
+		return nil, err
+	}
+
+	if len(o.roleName) > 0 {
+		config.RoleName = o.roleName
+	}
+	if len(o.rolePath) > 0 {
+		config.RolePath = o.rolePath
+	}
+	if len(o.externalId) > 0 {
+		config.ExternalId = o.externalId
+	}
+	if len(o.sessionPrefix) > 0 {
+		config.SessionPrefix = o.sessionPrefix
+	}
+	if o.sessionDuration > 0 {
+		config.SessionDuration = o.sessionDuration
+	}
+	if len(o.roleChain) > 0 {
+		config.RoleChain = aws.SplitList(o.roleChain)
+	}
+	if o.parallel > 0 {
+		config.Parallel = o.parallel
+	}
+	if o.accountTimeout > 0 {
+		config.AccountTimeout = o.accountTimeout
+	}
+	if len(o.output) > 0 {
+		config.Output = o.output
+	}
+	if len(o.partition) > 0 {
+		config.Partition = o.partition
+	}
+	for service, url := range o.endpoints {
+		if len(service) == 0 {
+			config.Endpoint = url
+		} else {
+			if config.Endpoints == nil {
+				config.Endpoints = make(map[string]string)
+			}
+			config.Endpoints[service] = url
+		}
+	}
+	if !validOutputFormat(config.Output) {
+		return nil, fmt.Errorf("unknown output format %s, expected one of %s", config.Output, strings.Join(outputFormats, ", "))
+	}
+	o.output = config.Output
+
+	return config, nil
+
+}
+
+// endpointFlags are -endpoint-url values by service. A url given without
+// a service is kept under the empty name and applies to every service.
+type endpointFlags map[string]string
+
+func (e *endpointFlags) String() string {
+	pairs := make([]string, 0, len(*e))
+	for service, url := range *e {
+		if len(service) == 0 {
+			pairs = append(pairs, url)
+		} else {
+			pairs = append(pairs, service+"="+url)
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (e *endpointFlags) Set(s string) error {
+	if *e == nil {
+		*e = make(endpointFlags)
+	}
+	service, url := "", s
+	if i := strings.Index(s, "="); i > 0 && !strings.Contains(s[:i], "/") {
+		service, url = s[:i], s[i+1:]
+	}
+	(*e)[service] = url
+	return nil
+}
+
+func (o *orgOptions) newOrganization() (*aws.Organization, error) {
+
+	config, err := o.config()
+	if err != nil {
+		return nil, err
+	}
+	return aws.NewOrganizationWithConfig(config)
+
+}
+
+// selectAccounts returns the active accounts chosen by the -accounts
+// and -filter options.
+func (o *orgOptions) selectAccounts(ctx context.Context, org *aws.Organization) ([]*organizations.Account, error) {
+
+	selector, err := aws.ParseSelector(o.accounts)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := aws.ParseAccountFilter(o.filter)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := org.SelectAccounts(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	return org.FilterAccounts(ctx, accounts, filter)
+
+}
+
+// allAccounts reports whether every active account is selected.
+func (o *orgOptions) allAccounts() bool {
+	return len(o.accounts) == 0 && len(o.filter) == 0
+}
+
+// render prints rows to stdout in the selected output format.
+func (o *orgOptions) render(rows interface{}) error {
+	return render(os.Stdout, o.output, rows)
+}
+
+// printAccountErrors reports per account failures on stderr.
+func printAccountErrors(ui cli.Ui, msg string, errs aws.AccountErrors) {
+	for _, e := range errs {
+		ui.Warn(fmt.Sprintf("warning: %s for account %s (%s): %s", msg, e.AccountId, e.AccountName, e.Err))
+	}
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/pr8kerl/organizer/aws"
+)
+
+type ouRow struct {
+	Path   string `json:"path" yaml:"path"`
+	Type   string `json:"type" yaml:"type"`
+	Id     string `json:"id" yaml:"id"`
+	Name   string `json:"name" yaml:"name"`
+	Status string `json:"status" yaml:"status"`
+}
+
+// List OUs
+type ListOUsCommand struct {
+	Ui cli.Ui
+	orgOptions
+}
+
+func listOUsCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &ListOUsCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *ListOUsCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("list ous", flag.ContinueOnError)
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	tree, err := org.GetOUTree()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not list organizational units: %s", err))
+		return 1
+	}
+
+	// the table format shows the tree itself, other formats get one row
+	// per organizational unit and account
+	if c.output == "table" {
+		printOUTree(tree, "")
+		return 0
+	}
+
+	rows := make([]ouRow, 0, 200)
+	rows = appendOURows(rows, tree)
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print organizational units: %s", err))
+		return 1
+	}
+
+	return 0
+}
+
+func printOUTree(node *aws.OrganizationalUnit, indent string) {
+
+	fmt.Printf("%s%s (%s)\n", indent, node.Name, node.Id)
+	for _, account := range node.Accounts {
+		fmt.Printf("%s    %s %s (%s)\n", indent, stringValue(account.Id), stringValue(account.Name), stringValue(account.Status))
+	}
+	for _, child := range node.Children {
+		printOUTree(child, indent+"    ")
+	}
+
+}
+
+func appendOURows(rows []ouRow, node *aws.OrganizationalUnit) []ouRow {
+
+	kind := "ou"
+	if node.Path == "/" {
+		kind = "root"
+	}
+	rows = append(rows, ouRow{Path: node.Path, Type: kind, Id: node.Id, Name: node.Name})
+	for _, account := range node.Accounts {
+		rows = append(rows, ouRow{
+			Path:   node.Path,
+			Type:   "account",
+			Id:     stringValue(account.Id),
+			Name:   stringValue(account.Name),
+			Status: stringValue(account.Status),
+		})
+	}
+	for _, child := range node.Children {
+		rows = appendOURows(rows, child)
+	}
+	return rows
+
+}
+
+func (c *ListOUsCommand) Help() string {
+	helpText := `usage: organizer list ous [<args>]
+
+List the organization root and organizational unit tree with the accounts
+under each organizational unit.
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *ListOUsCommand) Synopsis() string {
+	return "list the organizational unit tree for an organization"
+}
+
+// Create OU
+type CreateOUCommand struct {
+	Path string
+	Ui   cli.Ui
+	orgOptions
+}
+
+func createOUCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &CreateOUCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *CreateOUCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("create ou", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.Path, "path", "", "the path of the organizational unit to create")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.Path) == 0 {
+		c.Ui.Error("error: missing create ou --path parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	ou, err := org.CreateOU(c.Path)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not create organizational unit: %s", err))
+		return 1
+	}
+
+	fmt.Printf("created organizational unit %s successfully id: %s\n", c.Path, *ou.Id)
+	fmt.Printf("OU_ID=%s\n", *ou.Id)
+
+	return 0
+}
+
+func (c *CreateOUCommand) Help() string {
+	helpText := `
+usage: organizer create ou --path <ou path>
+
+create an organizational unit. the parent organizational unit must exist.
+
+options:
+
+	-path=<ou path>		the path of the new organizational unit, eg. /Workloads/Prod
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *CreateOUCommand) Synopsis() string {
+	return "create an organizational unit"
+}
+
+// Rename OU
+type RenameOUCommand struct {
+	Path string
+	Name string
+	Ui   cli.Ui
+	orgOptions
+}
+
+func renameOUCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &RenameOUCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *RenameOUCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("rename ou", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.Path, "path", "", "the path of the organizational unit to rename")
+	cmdFlags.StringVar(&c.Name, "name", "", "the new organizational unit name")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.Path) == 0 {
+		c.Ui.Error("error: missing rename ou --path parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+	if len(c.Name) == 0 {
+		c.Ui.Error("error: missing rename ou --name parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	ou, err := org.RenameOU(c.Path, c.Name)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not rename organizational unit: %s", err))
+		return 1
+	}
+
+	fmt.Printf("renamed organizational unit %s to %s id: %s\n", c.Path, c.Name, *ou.Id)
+
+	return 0
+}
+
+func (c *RenameOUCommand) Help() string {
+	helpText := `
+usage: organizer rename ou --path <ou path> --name <new name>
+
+rename an organizational unit.
+
+options:
+
+	-path=<ou path>		the path of the organizational unit, eg. /Workloads/Prod
+	-name=<new name>	the new organizational unit name
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *RenameOUCommand) Synopsis() string {
+	return "rename an organizational unit"
+}
+
+// Delete OU
+type DeleteOUCommand struct {
+	Path string
+	Ui   cli.Ui
+	orgOptions
+}
+
+func deleteOUCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &DeleteOUCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *DeleteOUCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("delete ou", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.Path, "path", "", "the path of the organizational unit to delete")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.Path) == 0 {
+		c.Ui.Error("error: missing delete ou --path parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	err = org.DeleteOU(c.Path)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not delete organizational unit: %s", err))
+		return 1
+	}
+
+	fmt.Printf("deleted organizational unit %s\n", c.Path)
+
+	return 0
+}
+
+func (c *DeleteOUCommand) Help() string {
+	helpText := `
+usage: organizer delete ou --path <ou path>
+
+delete an empty organizational unit.
+
+options:
+
+	-path=<ou path>		the path of the organizational unit, eg. /Workloads/Prod
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *DeleteOUCommand) Synopsis() string {
+	return "delete an empty organizational unit"
+}
+
+// Move Account
+type MoveAccountCommand struct {
+	AccountId string
+	To        string
+	Ui        cli.Ui
+	orgOptions
+}
+
+func moveAccountCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &MoveAccountCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *MoveAccountCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("move account", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.AccountId, "accountid", "", "the account to move")
+	cmdFlags.StringVar(&c.To, "to", "", "the path of the destination organizational unit")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.AccountId) == 0 {
+		c.Ui.Error("error: missing move account --accountid parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+	if len(c.To) == 0 {
+		c.Ui.Error("error: missing move account --to parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	err = org.MoveAccount(c.AccountId, c.To)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not move account: %s", err))
+		return 1
+	}
+
+	fmt.Printf("moved account %s to %s\n", c.AccountId, c.To)
+
+	return 0
+}
+
+func (c *MoveAccountCommand) Help() string {
+	helpText := `
+usage: organizer move account --accountid <account id> --to <ou path>
+
+move an account to another organizational unit.
+
+options:
+
+	-accountid=<account id>	the account to move
+	-to=<ou path>		the destination organizational unit, eg. /Workloads/Prod. use / for the root
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *MoveAccountCommand) Synopsis() string {
+	return "move an account to another organizational unit"
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// output formats understood by the -output flag
+var outputFormats = []string{"table", "csv", "json", "ndjson", "yaml"}
+
+func validOutputFormat(format string) bool {
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// render writes rows, a slice of structs, in the requested format. Column
+// names come from the json tag of each struct field.
+func render(w io.Writer, format string, rows interface{}) error {
+
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("cannot render %s, expected a slice", v.Kind())
+	}
+
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		for i := 0; i < v.Len(); i++ {
+			if err := enc.Encode(v.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "yaml":
+		data, err := yaml.Marshal(rows)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(columns(v.Type().Elem()))
+		for i := 0; i < v.Len(); i++ {
+			cw.Write(fields(v.Index(i)))
+		}
+		cw.Flush()
+		return cw.Error()
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		header := columns(v.Type().Elem())
+		for i := range header {
+			header[i] = strings.ToUpper(header[i])
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for i := 0; i < v.Len(); i++ {
+			fmt.Fprintln(tw, strings.Join(fields(v.Index(i)), "\t"))
+		}
+		return tw.Flush()
+	}
+
+	return fmt.Errorf("unknown output format %s, expected one of %s", format, strings.Join(outputFormats, ", "))
+
+}
+
+func columns(t reflect.Type) []string {
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	cols := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		cols = append(cols, columnName(t.Field(i)))
+	}
+	return cols
+
+}
+
+func columnName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if len(name) == 0 {
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
+
+func fields(v reflect.Value) []string {
+
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	values := make([]string, 0, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		values = append(values, formatValue(v.Field(i)))
+	}
+	return values
+
+}
+
+// formatValue turns a single field into text for the csv and table formats.
+func formatValue(v reflect.Value) string {
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Slice:
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, formatValue(v.Index(i)))
+		}
+		return strings.Join(items, ";")
+	case reflect.Map:
+		items := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			items = append(items, formatValue(k)+"="+formatValue(v.MapIndex(k)))
+		}
+		sort.Strings(items)
+		return strings.Join(items, ";")
+	}
+	return fmt.Sprint(v.Interface())
+
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+type outputRow struct {
+	Id      string            `json:"id" yaml:"id"`
+	Name    *string           `json:"name" yaml:"name"`
+	Joined  *time.Time        `json:"joined" yaml:"joined"`
+	Enabled bool              `json:"enabled" yaml:"enabled"`
+	Regions []string          `json:"regions" yaml:"regions"`
+	Tags    map[string]string `json:"tags" yaml:"tags"`
+}
+
+func outputRows() []outputRow {
+	name := "master, billing"
+	joined := time.Date(2017, 11, 29, 10, 59, 37, 0, time.UTC)
+	return []outputRow{
+		{
+			Id:      "111111111111",
+			Name:    &name,
+			Joined:  &joined,
+			Enabled: true,
+			Regions: []string{"us-east-1", "eu-west-1"},
+			Tags:    map[string]string{"team": "ops", "env": "prod"},
+		},
+		{Id: "222222222222"},
+	}
+}
+
+func TestRender(t *testing.T) {
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"table", "" +
+			"ID            NAME             JOINED                ENABLED  REGIONS              TAGS\n" +
+			"111111111111  master, billing  2017-11-29T10:59:37Z  true     us-east-1;eu-west-1  env=prod;team=ops\n" +
+			"222222222222" + strings.Repeat(" ", 41) + "false" + strings.Repeat(" ", 25) + "\n"},
+		{"csv", "" +
+			"id,name,joined,enabled,regions,tags\n" +
+			"111111111111,\"master, billing\",2017-11-29T10:59:37Z,true,us-east-1;eu-west-1,env=prod;team=ops\n" +
+			"222222222222,,,false,,\n"},
+		{"ndjson", "" +
+			`{"id":"111111111111","name":"master, billing","joined":"2017-11-29T10:59:37Z","enabled":true,"regions":["us-east-1","eu-west-1"],"tags":{"env":"prod","team":"ops"}}` + "\n" +
+			`{"id":"222222222222","name":null,"joined":null,"enabled":false,"regions":null,"tags":null}` + "\n"},
+		{"yaml", `- id: "111111111111"
+  name: master, billing
+  joined: 2017-11-29T10:59:37Z
+  enabled: true
+  regions:
+  - us-east-1
+  - eu-west-1
+  tags:
+    env: prod
+    team: ops
+- id: "222222222222"
+  name: null
+  joined: null
+  enabled: false
+  regions: []
+  tags: {}
+`},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := render(&out, test.format, outputRows()); err != nil {
+			t.Errorf("render %s error: %s", test.format, err)
+			continue
+		}
+		if out.String() != test.want {
+			t.Errorf("render %s wrote\n%s\nexpected\n%s", test.format, out.String(), test.want)
+		}
+	}
+
+}
+
+func TestRenderErrors(t *testing.T) {
+
+	tests := []struct {
+		format string
+		rows   interface{}
+		want   string
+	}{
+		{"xml", outputRows(), "unknown output format xml, expected one of table, csv, json, ndjson, yaml"},
+		{"table", outputRows()[0], "cannot render struct, expected a slice"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		err := render(&out, test.format, test.rows)
+		if err == nil || err.Error() != test.want {
+			t.Errorf("render %s returned error %v, expected %q", test.format, err, test.want)
+		}
+		if out.Len() != 0 {
+			t.Errorf("render %s wrote %q after an error", test.format, out.String())
+		}
+	}
+
+}
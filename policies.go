@@ -0,0 +1,551 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/mitchellh/cli"
+	"github.com/pr8kerl/organizer/aws"
+)
+
+type policyRow struct {
+	Id          string `json:"id" yaml:"id"`
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	AwsManaged  bool   `json:"aws_managed" yaml:"aws_managed"`
+}
+
+type iamPolicyRow struct {
+	AccountId                     string     `json:"account_id" yaml:"account_id"`
+	PolicyName                    string     `json:"policy_name" yaml:"policy_name"`
+	Arn                           string     `json:"arn" yaml:"arn"`
+	DefaultVersionId              string     `json:"default_version_id" yaml:"default_version_id"`
+	AttachmentCount               int64      `json:"attachment_count" yaml:"attachment_count"`
+	PermissionsBoundaryUsageCount int64      `json:"permissions_boundary_usage_count" yaml:"permissions_boundary_usage_count"`
+	UpdateDate                    *time.Time `json:"update_date" yaml:"update_date"`
+}
+
+type policyDocumentRow struct {
+	Id          string `json:"id" yaml:"id"`
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	AwsManaged  bool   `json:"aws_managed" yaml:"aws_managed"`
+	Content     string `json:"content" yaml:"content"`
+}
+
+type effectivePolicyRow struct {
+	AccountId  string `json:"account_id" yaml:"account_id"`
+	PolicyId   string `json:"policy_id" yaml:"policy_id"`
+	PolicyName string `json:"policy_name" yaml:"policy_name"`
+	TargetType string `json:"target_type" yaml:"target_type"`
+	TargetId   string `json:"target_id" yaml:"target_id"`
+	TargetName string `json:"target_name" yaml:"target_name"`
+}
+
+func newPolicyRow(p *organizations.PolicySummary) policyRow {
+	return policyRow{
+		Id:          stringValue(p.Id),
+		Name:        stringValue(p.Name),
+		Description: stringValue(p.Description),
+		AwsManaged:  p.AwsManaged != nil && *p.AwsManaged,
+	}
+}
+
+// List Policies
+type ListPoliciesCommand struct {
+	Target          string
+	Effective       bool
+	CustomerManaged bool
+	Ui              cli.Ui
+	orgOptions
+}
+
+func listPoliciesCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &ListPoliciesCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *ListPoliciesCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("list policies", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.Target, "target", "", "list policies attached to an account, ou id or ou path")
+	cmdFlags.BoolVar(&c.Effective, "effective", false, "list every policy that applies to the target account")
+	cmdFlags.BoolVar(&c.CustomerManaged, "customer-managed", false, "list customer managed iam policies in the selected accounts")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if c.Effective && len(c.Target) == 0 {
+		c.Ui.Error("error: list policies -effective requires a --target account id.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	if c.CustomerManaged && (c.Effective || len(c.Target) > 0) {
+		c.Ui.Error("error: list policies -customer-managed cannot be used with -target or -effective.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	if c.CustomerManaged {
+		return c.runCustomerManaged(org)
+	}
+
+	if c.Effective {
+		policies, err := org.GetEffectivePolicies(c.Target)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("error: could not list effective policies: %s", err))
+			return 1
+		}
+		rows := make([]effectivePolicyRow, 0, len(policies))
+		for _, p := range policies {
+			rows = append(rows, effectivePolicyRow{
+				AccountId:  c.Target,
+				PolicyId:   stringValue(p.Policy.Id),
+				PolicyName: stringValue(p.Policy.Name),
+				TargetType: p.TargetType,
+				TargetId:   p.TargetId,
+				TargetName: p.TargetName,
+			})
+		}
+		if err := c.render(rows); err != nil {
+			c.Ui.Error(fmt.Sprintf("error: could not print policies: %s", err))
+			return 1
+		}
+		return 0
+	}
+
+	var policies []*organizations.PolicySummary
+	if len(c.Target) > 0 {
+		policies, err = org.GetPoliciesForTarget(c.Target)
+	} else {
+		policies, err = org.GetPolicies()
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not list policies: %s", err))
+		return 1
+	}
+
+	rows := make([]policyRow, 0, len(policies))
+	for _, p := range policies {
+		rows = append(rows, newPolicyRow(p))
+	}
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print policies: %s", err))
+		return 1
+	}
+
+	return 0
+}
+
+// runCustomerManaged lists the customer managed iam policies of the
+// selected accounts.
+func (c *ListPoliciesCommand) runCustomerManaged(org *aws.Organization) int {
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	accounts, err := c.selectAccounts(ctx, org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
+	}
+
+	policies, errs := org.GetCustomerManagedPolicies(ctx, accounts)
+	printAccountErrors(c.Ui, "could not list iam policies", errs)
+
+	rows := make([]iamPolicyRow, 0, len(policies))
+	for _, p := range policies {
+		rows = append(rows, iamPolicyRow{
+			AccountId:                     p.AccountId,
+			PolicyName:                    p.PolicyName,
+			Arn:                           p.Arn,
+			DefaultVersionId:              p.DefaultVersionId,
+			AttachmentCount:               p.AttachmentCount,
+			PermissionsBoundaryUsageCount: p.PermissionsBoundaryUsageCount,
+			UpdateDate:                    p.UpdateDate,
+		})
+	}
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print iam policies: %s", err))
+		return 1
+	}
+
+	return 0
+}
+
+func (c *ListPoliciesCommand) Help() string {
+	helpText := `usage: organizer list policies [<args>]
+
+List service control policies, or the customer managed iam policies of the
+selected accounts
+
+Options:
+	    -target		list only the policies attached to this account id, ou id or ou path
+	    -effective		list every policy that applies to the -target account, walking up
+				through its organizational units to the root
+	    -customer-managed	list the customer managed iam policies in each selected account with
+				their attachment and permissions boundary counts
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *ListPoliciesCommand) Synopsis() string {
+	return "list service control policies for an organization"
+}
+
+// Show Policy
+type ShowPolicyCommand struct {
+	Policy string
+	Ui     cli.Ui
+	orgOptions
+}
+
+func showPolicyCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &ShowPolicyCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *ShowPolicyCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("show policy", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.Policy, "policy", "", "the policy name or id")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.Policy) == 0 {
+		c.Ui.Error("error: missing show policy --policy parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	policy, err := org.GetPolicy(c.Policy)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not get policy: %s", err))
+		return 1
+	}
+
+	if c.output != "table" {
+		summary := newPolicyRow(policy.PolicySummary)
+		rows := []policyDocumentRow{{
+			Id:          summary.Id,
+			Name:        summary.Name,
+			Description: summary.Description,
+			AwsManaged:  summary.AwsManaged,
+			Content:     stringValue(policy.Content),
+		}}
+		if err := c.render(rows); err != nil {
+			c.Ui.Error(fmt.Sprintf("error: could not print policy: %s", err))
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Printf("id: %s\n", stringValue(policy.PolicySummary.Id))
+	fmt.Printf("name: %s\n", stringValue(policy.PolicySummary.Name))
+	fmt.Printf("description: %s\n", stringValue(policy.PolicySummary.Description))
+	var content bytes.Buffer
+	if err := json.Indent(&content, []byte(stringValue(policy.Content)), "", "  "); err != nil {
+		content.Reset()
+		content.WriteString(stringValue(policy.Content))
+	}
+	fmt.Printf("%s\n", content.String())
+
+	return 0
+}
+
+func (c *ShowPolicyCommand) Help() string {
+	helpText := `
+usage: organizer show policy --policy <name or id>
+
+show a service control policy and its policy document.
+
+options:
+
+	-policy=<name or id>	the policy name or policy id
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *ShowPolicyCommand) Synopsis() string {
+	return "show a service control policy"
+}
+
+// Create Policy
+type CreatePolicyCommand struct {
+	Name        string
+	Description string
+	File        string
+	Ui          cli.Ui
+	orgOptions
+}
+
+func createPolicyCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &CreatePolicyCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *CreatePolicyCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("create policy", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.Name, "name", "", "the policy name")
+	cmdFlags.StringVar(&c.Description, "description", "", "the policy description")
+	cmdFlags.StringVar(&c.File, "file", "", "the policy document file")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.Name) == 0 {
+		c.Ui.Error("error: missing create policy --name parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+	if len(c.File) == 0 {
+		c.Ui.Error("error: missing create policy --file parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	content, err := ioutil.ReadFile(c.File)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not read policy file: %s", err))
+		return 1
+	}
+	var document interface{}
+	if err := json.Unmarshal(content, &document); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: policy file %s is not valid json: %s", c.File, err))
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	policy, err := org.CreatePolicy(c.Name, c.Description, string(content))
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not create policy: %s", err))
+		return 1
+	}
+
+	fmt.Printf("created policy %s successfully id: %s\n", c.Name, *policy.PolicySummary.Id)
+	fmt.Printf("POLICY_ID=%s\n", *policy.PolicySummary.Id)
+
+	return 0
+}
+
+func (c *CreatePolicyCommand) Help() string {
+	helpText := `
+usage: organizer create policy --name <policy name> --file <policy document>
+
+create a service control policy.
+
+options:
+
+	-name=<policy name>		the policy name
+	-description=<description>	the policy description
+	-file=<file>			the json policy document
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *CreatePolicyCommand) Synopsis() string {
+	return "create a service control policy"
+}
+
+// Attach Policy
+type AttachPolicyCommand struct {
+	Policy string
+	Target string
+	Ui     cli.Ui
+	orgOptions
+}
+
+func attachPolicyCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &AttachPolicyCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *AttachPolicyCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("attach policy", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.Policy, "policy", "", "the policy name or id")
+	cmdFlags.StringVar(&c.Target, "target", "", "the account id, ou id or ou path")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.Policy) == 0 || len(c.Target) == 0 {
+		c.Ui.Error("error: attach policy requires --policy and --target parameters.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	err = org.AttachPolicy(c.Policy, c.Target)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not attach policy: %s", err))
+		return 1
+	}
+
+	fmt.Printf("attached policy %s to %s\n", c.Policy, c.Target)
+
+	return 0
+}
+
+func (c *AttachPolicyCommand) Help() string {
+	helpText := `
+usage: organizer attach policy --policy <name or id> --target <ou|account>
+
+attach a service control policy to an account, organizational unit or the root.
+
+options:
+
+	-policy=<name or id>	the policy name or policy id
+	-target=<target>	an account id, ou id, or ou path such as /Workloads/Prod. use / for the root
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *AttachPolicyCommand) Synopsis() string {
+	return "attach a service control policy"
+}
+
+// Detach Policy
+type DetachPolicyCommand struct {
+	Policy string
+	Target string
+	Ui     cli.Ui
+	orgOptions
+}
+
+func detachPolicyCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &DetachPolicyCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *DetachPolicyCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("detach policy", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.Policy, "policy", "", "the policy name or id")
+	cmdFlags.StringVar(&c.Target, "target", "", "the account id, ou id or ou path")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.Policy) == 0 || len(c.Target) == 0 {
+		c.Ui.Error("error: detach policy requires --policy and --target parameters.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	err = org.DetachPolicy(c.Policy, c.Target)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not detach policy: %s", err))
+		return 1
+	}
+
+	fmt.Printf("detached policy %s from %s\n", c.Policy, c.Target)
+
+	return 0
+}
+
+func (c *DetachPolicyCommand) Help() string {
+	helpText := `
+usage: organizer detach policy --policy <name or id> --target <ou|account>
+
+detach a service control policy from an account, organizational unit or the root.
+
+options:
+
+	-policy=<name or id>	the policy name or policy id
+	-target=<target>	an account id, ou id, or ou path such as /Workloads/Prod. use / for the root
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *DetachPolicyCommand) Synopsis() string {
+	return "detach a service control policy"
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/pr8kerl/organizer/aws"
+)
+
+type roleRow struct {
+	AccountId        string     `json:"account_id" yaml:"account_id"`
+	RoleName         string     `json:"role_name" yaml:"role_name"`
+	Arn              string     `json:"arn" yaml:"arn"`
+	Principals       []string   `json:"principals" yaml:"principals"`
+	CrossAccount     bool       `json:"cross_account" yaml:"cross_account"`
+	External         bool       `json:"external" yaml:"external"`
+	AttachedPolicies []string   `json:"attached_policies" yaml:"attached_policies"`
+	InlinePolicies   []string   `json:"inline_policies" yaml:"inline_policies"`
+	LastUsed         *time.Time `json:"last_used" yaml:"last_used"`
+	LastUsedRegion   string     `json:"last_used_region" yaml:"last_used_region"`
+}
+
+// List Roles
+type ListRolesCommand struct {
+	AccountId string
+	External  bool
+	Ui        cli.Ui
+	orgOptions
+}
+
+func listRolesCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &ListRolesCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *ListRolesCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("list roles", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.AccountId, "accountid", "", "list iam roles for a specific account")
+	cmdFlags.BoolVar(&c.External, "external", false, "list only roles that trust principals outside the organization")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	if len(c.AccountId) > 0 {
+		c.accounts = c.AccountId
+	}
+	accounts, err := c.selectAccounts(ctx, org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
+	}
+
+	members, _, err := organizationMembers(org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not list accounts: %s", err))
+		return 1
+	}
+
+	roles, errs := org.GetRoles(ctx, accounts)
+	printAccountErrors(c.Ui, "could not list roles", errs)
+
+	rows := make([]roleRow, 0, len(roles))
+	for _, role := range roles {
+		row := roleRow{
+			AccountId:        role.AccountId,
+			RoleName:         role.RoleName,
+			Arn:              role.Arn,
+			Principals:       make([]string, 0, len(role.Principals)),
+			AttachedPolicies: role.AttachedPolicies,
+			InlinePolicies:   role.InlinePolicies,
+			LastUsed:         role.LastUsed,
+			LastUsedRegion:   role.LastUsedRegion,
+		}
+		for _, principal := range role.Principals {
+			scope := principal.Scope(role.AccountId, members)
+			switch scope {
+			case aws.PrincipalOrganization:
+				row.CrossAccount = true
+			case aws.PrincipalExternal, aws.PrincipalPublic, aws.PrincipalUnknown:
+				row.CrossAccount = true
+				row.External = true
+			}
+			row.Principals = append(row.Principals, fmt.Sprintf("%s (%s)", principal, scope))
+		}
+		if c.External && !row.External {
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print iam roles: %s", err))
+		return 1
+	}
+
+	return 0
+}
+
+// organizationMembers returns the ids and names of every account in the
+// organization, to tell cross account principals from external ones.
+func organizationMembers(org *aws.Organization) (map[string]bool, map[string]string, error) {
+
+	accounts, err := org.GetAccounts()
+	if err != nil {
+		return nil, nil, err
+	}
+	members := make(map[string]bool, len(accounts))
+	names := make(map[string]string, len(accounts))
+	for _, account := range accounts {
+		members[*account.Id] = true
+		names[*account.Id] = stringValue(account.Name)
+	}
+	return members, names, nil
+
+}
+
+func (c *ListRolesCommand) Help() string {
+	helpText := `usage: organizer list roles [<args>]
+
+List all iam roles within accounts for an organization, with the principals
+their trust policy allows, their attached and inline policies and when they
+were last used.
+
+each principal is marked same-account, organization, external, service,
+federated, public or unknown. unknown principals are unique ids left behind by
+deleted users or roles.
+
+Options:
+	    -accountid		list iam roles for a specific account only, same as -accounts <id>
+	    -external		list only roles that trust principals outside the organization
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *ListRolesCommand) Synopsis() string {
+	return "list all iam roles for all accounts in an organization"
+}
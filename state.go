@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/pr8kerl/organizer/aws"
+)
+
+type changeRow struct {
+	Action string `json:"action" yaml:"action"`
+	Target string `json:"target" yaml:"target"`
+	Detail string `json:"detail" yaml:"detail"`
+}
+
+// planOrganization loads a state file and works out the changes needed.
+func planOrganization(ctx context.Context, ui cli.Ui, opts *orgOptions, file string) (*aws.Organization, aws.Plan, bool) {
+
+	state, err := aws.LoadState(file)
+	if err != nil {
+		ui.Error(fmt.Sprintf("error: could not load state: %s", err))
+		return nil, nil, false
+	}
+
+	org, err := opts.newOrganization()
+	if err != nil {
+		ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return nil, nil, false
+	}
+
+	plan, err := org.Plan(ctx, state)
+	if err != nil {
+		ui.Error(fmt.Sprintf("error: could not plan changes: %s", err))
+		return nil, nil, false
+	}
+	return org, plan, true
+
+}
+
+func printPlan(ui cli.Ui, opts *orgOptions, plan aws.Plan) bool {
+
+	if len(plan) == 0 {
+		ui.Warn("no changes, the organization matches the state")
+		return true
+	}
+	rows := make([]changeRow, 0, len(plan))
+	for _, change := range plan {
+		rows = append(rows, changeRow{Action: change.Action, Target: change.Target, Detail: change.Detail})
+	}
+	if err := opts.render(rows); err != nil {
+		ui.Error(fmt.Sprintf("error: could not print plan: %s", err))
+		return false
+	}
+	return true
+
+}
+
+// Plan
+type PlanCommand struct {
+	File string
+	Ui   cli.Ui
+	orgOptions
+}
+
+func planCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &PlanCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *PlanCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("plan", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.File, "f", "", "the organization state file")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.File) == 0 {
+		c.Ui.Error("error: missing plan -f parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	_, plan, ok := planOrganization(ctx, c.Ui, &c.orgOptions, c.File)
+	if !ok {
+		return 1
+	}
+	if !printPlan(c.Ui, &c.orgOptions, plan) {
+		return 1
+	}
+
+	return 0
+}
+
+func (c *PlanCommand) Help() string {
+	helpText := `
+usage: organizer plan -f <state file>
+
+show the changes needed to bring the organization in line with a state file.
+
+the state file is yaml describing organizational units, accounts and service
+control policy attachments:
+
+	organizational_units:
+	  - /Workloads/Prod
+	accounts:
+	  - name: payments-prod
+	    email: aws+payments-prod@example.com
+	    parent: /Workloads/Prod
+	    tags:
+	      env: prod
+	policies:
+	  - name: DenyLeaveOrganization
+	    targets: [/Workloads]
+
+nothing is ever removed from the organization. anything missing from the state is left alone.
+
+options:
+
+	-f=<file>	the organization state file
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *PlanCommand) Synopsis() string {
+	return "show the changes needed to match an organization state file"
+}
+
+// Apply
+type ApplyCommand struct {
+	File        string
+	AutoApprove bool
+	Ui          cli.Ui
+	orgOptions
+}
+
+func applyCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &ApplyCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *ApplyCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("apply", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.File, "f", "", "the organization state file")
+	cmdFlags.BoolVar(&c.AutoApprove, "auto-approve", false, "apply without asking for confirmation")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.File) == 0 {
+		c.Ui.Error("error: missing apply -f parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	org, plan, ok := planOrganization(ctx, c.Ui, &c.orgOptions, c.File)
+	if !ok {
+		return 1
+	}
+	if !printPlan(c.Ui, &c.orgOptions, plan) {
+		return 1
+	}
+	if len(plan) == 0 {
+		return 0
+	}
+
+	if !c.AutoApprove {
+		answer, err := c.Ui.Ask("apply these changes? only 'yes' will be accepted:")
+		if err != nil || answer != "yes" {
+			c.Ui.Error("apply cancelled.")
+			return 1
+		}
+	}
+
+	err := org.Apply(plan, func(change *aws.Change) {
+		c.Ui.Warn(fmt.Sprintf("applying %s %s %s", change.Action, change.Target, change.Detail))
+	})
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: %s", err))
+		return 1
+	}
+
+	c.Ui.Warn(fmt.Sprintf("applied %d changes.", len(plan)))
+
+	return 0
+}
+
+func (c *ApplyCommand) Help() string {
+	helpText := `
+usage: organizer apply -f <state file>
+
+make the changes needed to bring the organization in line with a state file.
+see organizer plan -h for the state file format.
+
+options:
+
+	-f=<file>		the organization state file
+	-auto-approve		apply without asking for confirmation
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *ApplyCommand) Synopsis() string {
+	return "apply an organization state file"
+}
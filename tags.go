@@ -0,0 +1,201 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// tagFlags collects repeated -tag key=value flags.
+type tagFlags map[string]string
+
+func (t tagFlags) String() string {
+	pairs := make([]string, 0, len(t))
+	for key, value := range t {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (t tagFlags) Set(s string) error {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 || len(kv[0]) == 0 {
+		return fmt.Errorf("invalid tag %s, expected key=value", s)
+	}
+	t[kv[0]] = kv[1]
+	return nil
+}
+
+// listFlags collects a repeated string flag.
+type listFlags []string
+
+func (l *listFlags) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlags) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// Tag Account
+type TagAccountCommand struct {
+	AccountId string
+	Tags      tagFlags
+	Ui        cli.Ui
+	orgOptions
+}
+
+func tagAccountCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &TagAccountCommand{
+		Tags: tagFlags{},
+		Ui:   ui,
+	}, nil
+}
+
+func (c *TagAccountCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("tag account", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.AccountId, "accountid", "", "the account to tag")
+	cmdFlags.Var(c.Tags, "tag", "a key=value tag for the account, may be repeated")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.AccountId) == 0 {
+		c.Ui.Error("error: missing tag account --accountid parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+	if len(c.Tags) == 0 {
+		c.Ui.Error("error: missing tag account --tag parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	err = org.TagAccount(c.AccountId, c.Tags)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not tag account: %s", err))
+		return 1
+	}
+
+	fmt.Printf("tagged account %s with %s\n", c.AccountId, c.Tags)
+
+	return 0
+}
+
+func (c *TagAccountCommand) Help() string {
+	helpText := `
+usage: organizer tag account --accountid <account id> --tag <key=value> [--tag <key=value> ...]
+
+add or update tags on an account.
+
+options:
+
+	-accountid=<account id>	the account to tag
+	-tag=<key=value>	the tag to set, may be repeated
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *TagAccountCommand) Synopsis() string {
+	return "add or update tags on an account"
+}
+
+// Untag Account
+type UntagAccountCommand struct {
+	AccountId string
+	Keys      listFlags
+	Ui        cli.Ui
+	orgOptions
+}
+
+func untagAccountCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &UntagAccountCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *UntagAccountCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("untag account", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.AccountId, "accountid", "", "the account to untag")
+	cmdFlags.Var(&c.Keys, "key", "the tag key to remove, may be repeated")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(c.AccountId) == 0 {
+		c.Ui.Error("error: missing untag account --accountid parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+	if len(c.Keys) == 0 {
+		c.Ui.Error("error: missing untag account --key parameter.")
+		cmdFlags.Usage()
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	err = org.UntagAccount(c.AccountId, c.Keys)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not untag account: %s", err))
+		return 1
+	}
+
+	fmt.Printf("removed tags %s from account %s\n", c.Keys.String(), c.AccountId)
+
+	return 0
+}
+
+func (c *UntagAccountCommand) Help() string {
+	helpText := `
+usage: organizer untag account --accountid <account id> --key <tag key> [--key <tag key> ...]
+
+remove tags from an account.
+
+options:
+
+	-accountid=<account id>	the account to untag
+	-key=<tag key>		the tag key to remove, may be repeated
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *UntagAccountCommand) Synopsis() string {
+	return "remove tags from an account"
+}
@@ -1,17 +1,52 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/organizations"
 	"github.com/mitchellh/cli"
 	"github.com/pr8kerl/organizer/aws"
-	"os"
 )
 
+type trailRow struct {
+	AccountId string `json:"account_id" yaml:"account_id"`
+	TrailArn  string `json:"trail_arn" yaml:"trail_arn"`
+	Action    string `json:"action" yaml:"action"`
+}
+
+type trailPurgeRow struct {
+	AccountId string `json:"account_id" yaml:"account_id"`
+	Region    string `json:"region" yaml:"region"`
+	TrailArn  string `json:"trail_arn" yaml:"trail_arn"`
+	Action    string `json:"action" yaml:"action"`
+	Reason    string `json:"reason" yaml:"reason"`
+}
+
+type trailDriftRow struct {
+	AccountId string `json:"account_id" yaml:"account_id"`
+	Region    string `json:"region" yaml:"region"`
+	Trail     string `json:"trail" yaml:"trail"`
+	Issue     string `json:"issue" yaml:"issue"`
+	Action    string `json:"action" yaml:"action"`
+}
+
 type TrailsCommand struct {
-	AccountId string
-	Purge     bool
-	Ui        cli.Ui
+	AccountId   string
+	Purge       bool
+	Ensure      bool
+	Standard    aws.StandardTrail
+	DryRun      bool
+	AutoApprove bool
+	Names       listFlags
+	Exclude     listFlags
+	AuditLog    string
+	Ui          cli.Ui
+	orgOptions
 }
 
 func trailsCmdFactory() (cli.Command, error) {
@@ -33,60 +68,500 @@ func (c *TrailsCommand) Run(args []string) int {
 
 	cmdFlags := flag.NewFlagSet("trails", flag.ContinueOnError)
 	cmdFlags.StringVar(&c.AccountId, "accountid", "", "work on cloudtrails in this account")
-	cmdFlags.BoolVar(&c.Purge, "purge", false, "purge all cloudtrails in this account")
+	cmdFlags.BoolVar(&c.Purge, "purge", false, "purge cloudtrails in the selected accounts")
+	cmdFlags.BoolVar(&c.DryRun, "dry-run", false, "show the trails a purge would delete")
+	cmdFlags.BoolVar(&c.AutoApprove, "auto-approve", false, "purge without asking for confirmation")
+	cmdFlags.Var(&c.Names, "name", "only purge trails with names matching this pattern, may be repeated")
+	cmdFlags.Var(&c.Exclude, "exclude", "never purge trails with names matching this pattern, may be repeated")
+	cmdFlags.StringVar(&c.AuditLog, "audit-log", "organizer-audit.log", "file every trail deletion is appended to")
+	cmdFlags.BoolVar(&c.Ensure, "ensure", false, "create or update the standard trail in the selected accounts")
+	cmdFlags.StringVar(&c.Standard.Name, "trail-name", "", "the standard trail name")
+	cmdFlags.StringVar(&c.Standard.Region, "trail-region", "", "the home region of the standard trail")
+	cmdFlags.StringVar(&c.Standard.Bucket, "bucket", "", "the s3 bucket the standard trail logs to")
+	cmdFlags.StringVar(&c.Standard.KeyPrefix, "key-prefix", "", "the s3 key prefix of the standard trail")
+	cmdFlags.StringVar(&c.Standard.KmsKeyId, "kms-key", "", "the kms key arn or id the standard trail encrypts logs with")
+	cmdFlags.BoolVar(&c.Standard.LogFileValidation, "log-validation", true, "enable log file validation on the standard trail")
+	cmdFlags.StringVar(&c.Standard.CloudWatchLogsGroupArn, "cloudwatch-group", "", "the cloudwatch logs group arn the standard trail delivers to")
+	cmdFlags.StringVar(&c.Standard.CloudWatchLogsRoleArn, "cloudwatch-role", "", "the role cloudtrail uses to deliver to cloudwatch logs")
+	c.addFlags(cmdFlags)
 	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
-	cmdFlags.Parse(args)
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
 
-	org, err := aws.NewOrganization()
+	org, err := c.newOrganization()
 	if err != nil {
-		fmt.Printf("error: could not initialize organization: %s\n", err)
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
 		return 1
 	}
 
-	if len(c.AccountId) == 0 {
-		fmt.Printf("error: trails subcommand requires an accountid options\n")
-		return 1
+	ctx, cancel := interruptContext()
+	defer cancel()
 
+	if len(c.AccountId) > 0 {
+		c.accounts = c.AccountId
+	}
+	if c.Purge && c.Ensure {
+		c.Ui.Error("error: -purge and -ensure cannot be used together")
+		return 1
+	}
+	if c.Purge && len(c.accounts) == 0 {
+		c.Ui.Error("error: purging trails requires the accounts to be named with -accountid or -accounts")
+		return 1
+	}
+	accounts, err := c.selectAccounts(ctx, org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
 	}
 
 	if c.Purge {
+		return c.runPurge(ctx, org, accounts)
+	}
+	if c.Ensure {
+		return c.runEnsure(ctx, org, accounts, cmdFlags)
+	}
 
-		trails, err := org.PurgeTrailsForAccount(c.AccountId)
-		if err != nil {
-			fmt.Printf("error: could purge trails for account %s: %s\n", c.AccountId, err)
-			return 1
+	trails, errs := org.GetTrails(ctx, accounts)
+	printAccountErrors(c.Ui, "could not list trails", errs)
+
+	rows := make([]trailRow, 0, 200)
+	for _, accountid := range trails.Accounts() {
+		for _, trail := range trails[accountid] {
+			rows = append(rows, trailRow{AccountId: accountid, TrailArn: trail, Action: "listed"})
 		}
-		for _, trail := range trails {
-			fmt.Printf("%s,%s purged.\n", c.AccountId, trail)
+	}
+
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print trails: %s", err))
+		return 1
+	}
+
+	return 0
+}
+
+// standardTrail takes the standard trail from the config file, overridden
+// by any flags given.
+func (c *TrailsCommand) standardTrail(org *aws.Organization, cmdFlags *flag.FlagSet) *aws.StandardTrail {
+
+	standard := org.Config().Trail
+	cmdFlags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "trail-name":
+			standard.Name = c.Standard.Name
+		case "trail-region":
+			standard.Region = c.Standard.Region
+		case "bucket":
+			standard.Bucket = c.Standard.Bucket
+		case "key-prefix":
+			standard.KeyPrefix = c.Standard.KeyPrefix
+		case "kms-key":
+			standard.KmsKeyId = c.Standard.KmsKeyId
+		case "log-validation":
+			standard.LogFileValidation = c.Standard.LogFileValidation
+		case "cloudwatch-group":
+			standard.CloudWatchLogsGroupArn = c.Standard.CloudWatchLogsGroupArn
+		case "cloudwatch-role":
+			standard.CloudWatchLogsRoleArn = c.Standard.CloudWatchLogsRoleArn
 		}
+	})
+	if len(standard.Region) == 0 {
+		standard.Region = org.Config().DefaultRegion()
+	}
+	return &standard
 
-	} else {
+}
 
-		trails, err := org.GetTrailArnsForAccount(c.AccountId)
-		if err != nil {
-			fmt.Printf("error: could not list trails for account %s: %s\n", c.AccountId, err)
+// runEnsure reports trail drift and, once confirmed, creates or updates
+// the standard trail.
+func (c *TrailsCommand) runEnsure(ctx context.Context, org *aws.Organization, accounts []*organizations.Account, cmdFlags *flag.FlagSet) int {
+
+	standard := c.standardTrail(org, cmdFlags)
+	if err := standard.Validate(); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: %s", err))
+		return 1
+	}
+
+	drift, errs := org.EnsureTrails(ctx, accounts, standard, false)
+	printAccountErrors(c.Ui, "could not check trails", errs)
+
+	fixes := make(map[string]bool)
+	rows := make([]trailDriftRow, 0, len(drift))
+	for _, d := range drift {
+		row := trailDriftRow{AccountId: *d.Account.Id, Region: d.Region, Trail: d.TrailArn, Issue: d.Issue, Action: "report"}
+		if d.Standard {
+			row.Action = "fix"
+			fixes[*d.Account.Id] = true
+		}
+		rows = append(rows, row)
+	}
+
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print trail drift: %s", err))
+		return 1
+	}
+	if len(fixes) == 0 {
+		c.Ui.Warn("the standard trail is in place in every account.")
+		return 0
+	}
+	if c.DryRun {
+		c.Ui.Warn(fmt.Sprintf("dry run, the standard trail would be fixed in %d accounts.", len(fixes)))
+		return 0
+	}
+
+	if !c.AutoApprove {
+		answer, err := c.Ui.Ask(fmt.Sprintf("fix the standard trail in %d accounts? only 'yes' will be accepted:", len(fixes)))
+		if err != nil || answer != "yes" {
+			c.Ui.Error("ensure cancelled.")
 			return 1
 		}
-		for _, trail := range trails {
-			fmt.Printf("%s,%s\n", c.AccountId, trail)
+	}
+
+	selected := make([]*organizations.Account, 0, len(fixes))
+	for _, account := range accounts {
+		if fixes[*account.Id] {
+			selected = append(selected, account)
+		}
+	}
+	_, errs = org.EnsureTrails(ctx, selected, standard, true)
+	printAccountErrors(c.Ui, "could not fix the standard trail", errs)
+	if len(errs) > 0 {
+		return 1
+	}
+
+	c.Ui.Warn(fmt.Sprintf("fixed the standard trail in %d accounts.", len(selected)))
+
+	return 0
+}
+
+// runPurge shows which trails will be deleted and deletes them once confirmed.
+func (c *TrailsCommand) runPurge(ctx context.Context, org *aws.Organization, accounts []*organizations.Account) int {
+
+	deletions, errs := org.PlanTrailPurge(ctx, accounts, &aws.TrailPurgeOptions{
+		Names:   c.Names,
+		Exclude: c.Exclude,
+	})
+	printAccountErrors(c.Ui, "could not list trails", errs)
+
+	count := 0
+	rows := make([]trailPurgeRow, 0, len(deletions))
+	for _, deletion := range deletions {
+		row := trailPurgeRow{
+			AccountId: stringValue(deletion.Account.Id),
+			Region:    stringValue(deletion.Trail.HomeRegion),
+			TrailArn:  stringValue(deletion.Trail.TrailARN),
+			Action:    "delete",
+			Reason:    deletion.Skip,
+		}
+		if len(deletion.Skip) > 0 {
+			row.Action = "skip"
+		} else {
+			count++
+		}
+		rows = append(rows, row)
+	}
+
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print trails: %s", err))
+		return 1
+	}
+	if count == 0 {
+		c.Ui.Warn("no trails to purge.")
+		return 0
+	}
+	if c.DryRun {
+		c.Ui.Warn(fmt.Sprintf("dry run, %d trails would be deleted.", count))
+		return 0
+	}
+
+	if !c.AutoApprove {
+		answer, err := c.Ui.Ask(fmt.Sprintf("delete %d trails? only 'yes' will be accepted:", count))
+		if err != nil || answer != "yes" {
+			c.Ui.Error("purge cancelled.")
+			return 1
 		}
+	}
+
+	audit, err := os.OpenFile(c.AuditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not open audit log: %s", err))
+		return 1
+	}
+	defer audit.Close()
 
+	errs = org.DeleteTrails(ctx, deletions, audit)
+	printAccountErrors(c.Ui, "could not purge trails", errs)
+	if len(errs) > 0 {
+		c.Ui.Error(fmt.Sprintf("error: purge failed in %d accounts, see %s", len(errs), c.AuditLog))
+		return 1
 	}
 
+	c.Ui.Warn(fmt.Sprintf("deleted %d trails, see %s", count, c.AuditLog))
+
 	return 0
 }
 
 func (c *TrailsCommand) Help() string {
-	return `usage: organizer trails [<args>]
+	helpText := `usage: organizer trails [<args>]
 
-List cloudtrails within an account
+List cloudtrails within the accounts of an organization
+
+a purge shows the trails it will delete and asks for confirmation first.
+organization trails and trails owned by another account are never deleted, and
+shadow copies of multi-region trails are deleted once from their home region.
+every deletion is appended to the audit log as a line of json.
 
 Options:
-	    -accountid		specify the account id to work against
-	    -purge		purge all cloudtrails in this account
+	    -accountid		specify the account id to work against, same as -accounts <id>
+	    -purge		purge cloudtrails in the selected accounts. the accounts must be named with -accountid or -accounts
+	    -dry-run		show the trails a purge would delete without deleting them
+	    -auto-approve	purge without asking for confirmation
+	    -name		only purge trails with names matching this pattern, eg. legacy-*. may be repeated
+	    -exclude		never purge trails with names matching this pattern. may be repeated
+	    -audit-log		file every trail deletion is appended to. default organizer-audit.log
+
+	    -ensure		report trail drift and create or update the standard multi-region trail
+	    			in the selected accounts. use -dry-run to only report drift
+	    -trail-name		the standard trail name. default organizer
+	    -trail-region	the home region of the standard trail. default is AWS_REGION, AWS_DEFAULT_REGION or the partition region
+	    -bucket		the s3 bucket the standard trail logs to
+	    -key-prefix		the s3 key prefix of the standard trail
+	    -kms-key		the kms key arn or id the standard trail encrypts logs with, not an alias
+	    -log-validation	enable log file validation on the standard trail. default true
+	    -cloudwatch-group	the cloudwatch logs group arn the standard trail delivers to
+	    -cloudwatch-role	the role cloudtrail uses to deliver to cloudwatch logs
+
+	the standard trail can also be set in the config file under trail:, eg.
+
+	    trail:
+	      name: organizer
+	      bucket: example-org-cloudtrail
+	      kms_key_id: arn:aws:kms:us-east-1:111111111111:key/...
 	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
 }
 
 func (c *TrailsCommand) Synopsis() string {
-	return "list all trails for an account"
+	return "list all trails for accounts in an organization"
+}
+
+type trailStatusRow struct {
+	AccountId           string     `json:"account_id" yaml:"account_id"`
+	Region              string     `json:"region" yaml:"region"`
+	TrailArn            string     `json:"trail_arn" yaml:"trail_arn"`
+	OrganizationTrail   bool       `json:"organization_trail" yaml:"organization_trail"`
+	IsLogging           bool       `json:"is_logging" yaml:"is_logging"`
+	LatestDelivery      *time.Time `json:"latest_delivery" yaml:"latest_delivery"`
+	LatestDeliveryError string     `json:"latest_delivery_error" yaml:"latest_delivery_error"`
+	ManagementEvents    string     `json:"management_events" yaml:"management_events"`
+	DataResources       int        `json:"data_resources" yaml:"data_resources"`
+	Error               string     `json:"error" yaml:"error"`
+}
+
+// Trails Status
+type TrailsStatusCommand struct {
+	AccountId string
+	Ui        cli.Ui
+	orgOptions
+}
+
+func trailsStatusCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &TrailsStatusCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *TrailsStatusCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("trails status", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.AccountId, "accountid", "", "show trail status for this account")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	if len(c.AccountId) > 0 {
+		c.accounts = c.AccountId
+	}
+	accounts, err := c.selectAccounts(ctx, org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
+	}
+
+	statuses, errs := org.GetTrailStatuses(ctx, accounts)
+	printAccountErrors(c.Ui, "could not get trail status", errs)
+
+	rows := make([]trailStatusRow, 0, len(statuses))
+	for _, status := range statuses {
+		row := trailStatusRow{
+			AccountId:           stringValue(status.Account.Id),
+			Region:              stringValue(status.Trail.HomeRegion),
+			TrailArn:            stringValue(status.Trail.TrailARN),
+			OrganizationTrail:   status.Trail.IsOrganizationTrail != nil && *status.Trail.IsOrganizationTrail,
+			IsLogging:           status.IsLogging,
+			LatestDelivery:      status.LatestDeliveryTime,
+			LatestDeliveryError: status.LatestDeliveryError,
+			ManagementEvents:    status.ManagementEvents,
+			DataResources:       status.DataResources,
+		}
+		if status.Err != nil {
+			row.Error = status.Err.Error()
+		}
+		rows = append(rows, row)
+	}
+
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print trail status: %s", err))
+		return 1
+	}
+
+	return 0
+}
+
+func (c *TrailsStatusCommand) Help() string {
+	helpText := `usage: organizer trails status [<args>]
+
+Show whether every trail in the selected accounts is logging, when it last
+delivered logs, any delivery error, whether it is an organization trail and
+which events it records
+
+Options:
+	    -accountid		specify the account id to work against, same as -accounts <id>
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *TrailsStatusCommand) Synopsis() string {
+	return "show the status of every trail in an organization"
+}
+
+type trailEventRow struct {
+	Time        *time.Time `json:"time" yaml:"time"`
+	AccountId   string     `json:"account_id" yaml:"account_id"`
+	Region      string     `json:"region" yaml:"region"`
+	EventName   string     `json:"event_name" yaml:"event_name"`
+	EventSource string     `json:"event_source" yaml:"event_source"`
+	Username    string     `json:"username" yaml:"username"`
+	AccessKeyId string     `json:"access_key_id" yaml:"access_key_id"`
+	EventId     string     `json:"event_id" yaml:"event_id"`
+}
+
+// Trails Lookup
+type TrailsLookupCommand struct {
+	AccountId string
+	EventName string
+	Since     time.Duration
+	Ui        cli.Ui
+	orgOptions
+}
+
+func trailsLookupCmdFactory() (cli.Command, error) {
+
+	ui := &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+	}
+
+	return &TrailsLookupCommand{
+		Ui: ui,
+	}, nil
+}
+
+func (c *TrailsLookupCommand) Run(args []string) int {
+
+	cmdFlags := flag.NewFlagSet("trails lookup", flag.ContinueOnError)
+	cmdFlags.StringVar(&c.AccountId, "accountid", "", "look up events in this account")
+	cmdFlags.StringVar(&c.EventName, "event-name", "", "only events with this name, eg. ConsoleLogin")
+	cmdFlags.DurationVar(&c.Since, "since", 24*time.Hour, "how far back to look")
+	c.addFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if c.Since <= 0 {
+		c.Ui.Error("error: trails lookup -since must be a positive duration, eg. 24h")
+		return 1
+	}
+
+	org, err := c.newOrganization()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
+		return 1
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	if len(c.AccountId) > 0 {
+		c.accounts = c.AccountId
+	}
+	accounts, err := c.selectAccounts(ctx, org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
+	}
+
+	events, errs := org.LookupEvents(ctx, accounts, c.EventName, time.Now().Add(-c.Since))
+	printAccountErrors(c.Ui, "could not look up events", errs)
+
+	rows := make([]trailEventRow, 0, len(events))
+	for _, e := range events {
+		rows = append(rows, trailEventRow{
+			Time:        e.Event.EventTime,
+			AccountId:   e.AccountId,
+			Region:      e.Region,
+			EventName:   stringValue(e.Event.EventName),
+			EventSource: stringValue(e.Event.EventSource),
+			Username:    stringValue(e.Event.Username),
+			AccessKeyId: stringValue(e.Event.AccessKeyId),
+			EventId:     stringValue(e.Event.EventId),
+		})
+	}
+
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print events: %s", err))
+		return 1
+	}
+
+	return 0
+}
+
+func (c *TrailsLookupCommand) Help() string {
+	helpText := `usage: organizer trails lookup [<args>]
+
+Look up cloudtrail management events in every region of the selected accounts
+and print them as one stream, oldest first, eg.
+
+	organizer trails lookup -event-name ConsoleLogin -since 24h
+
+Options:
+	    -accountid		specify the account id to work against, same as -accounts <id>
+	    -event-name		only events with this name, eg. ConsoleLogin. default all events
+	    -since		how far back to look, eg. 2h. default 24h. cloudtrail keeps 90 days
+	`
+	return strings.TrimSpace(helpText + orgOptionsHelp)
+}
+
+func (c *TrailsLookupCommand) Synopsis() string {
+	return "look up cloudtrail events across an organization"
 }
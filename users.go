@@ -1,21 +1,60 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go/service/organizations"
 	"github.com/mitchellh/cli"
 	"github.com/pr8kerl/organizer/aws"
 )
 
+type userRow struct {
+	AccountId        string     `json:"account_id" yaml:"account_id"`
+	UserName         string     `json:"user_name" yaml:"user_name"`
+	Arn              string     `json:"arn" yaml:"arn"`
+	CreateDate       *time.Time `json:"create_date" yaml:"create_date"`
+	PasswordLastUsed *time.Time `json:"password_last_used" yaml:"password_last_used"`
+}
+
+type credentialReportRow struct {
+	AccountId             string     `json:"account_id" yaml:"account_id"`
+	User                  string     `json:"user" yaml:"user"`
+	Arn                   string     `json:"arn" yaml:"arn"`
+	UserCreationTime      *time.Time `json:"user_creation_time" yaml:"user_creation_time"`
+	PasswordEnabled       bool       `json:"password_enabled" yaml:"password_enabled"`
+	PasswordLastUsed      *time.Time `json:"password_last_used" yaml:"password_last_used"`
+	PasswordLastChanged   *time.Time `json:"password_last_changed" yaml:"password_last_changed"`
+	MFAActive             bool       `json:"mfa_active" yaml:"mfa_active"`
+	AccessKey1Active      bool       `json:"access_key_1_active" yaml:"access_key_1_active"`
+	AccessKey1LastRotated *time.Time `json:"access_key_1_last_rotated" yaml:"access_key_1_last_rotated"`
+	AccessKey1LastUsed    *time.Time `json:"access_key_1_last_used" yaml:"access_key_1_last_used"`
+	AccessKey2Active      bool       `json:"access_key_2_active" yaml:"access_key_2_active"`
+	AccessKey2LastRotated *time.Time `json:"access_key_2_last_rotated" yaml:"access_key_2_last_rotated"`
+	AccessKey2LastUsed    *time.Time `json:"access_key_2_last_used" yaml:"access_key_2_last_used"`
+}
+
+type findingRow struct {
+	AccountId string `json:"account_id" yaml:"account_id"`
+	User      string `json:"user" yaml:"user"`
+	Check     string `json:"check" yaml:"check"`
+	Detail    string `json:"detail" yaml:"detail"`
+}
+
 // List Users
 type ListUsersCommand struct {
 	AccountId string
 	Report    bool
+	Findings  bool
+	MaxKeyAge int
+	MaxUnused int
 	Region    string
 	Ui        cli.Ui
+	orgOptions
 }
 
 func listUsersCmdFactory() (cli.Command, error) {
@@ -38,59 +77,112 @@ func (c *ListUsersCommand) Run(args []string) int {
 
 	cmdFlags := flag.NewFlagSet("list users", flag.ContinueOnError)
 	cmdFlags.StringVar(&c.AccountId, "accountid", "", "list iam users for a specific account")
-	cmdFlags.BoolVar(&c.Report, "report", false, "generate and show iam credential reports distributions")
+	cmdFlags.BoolVar(&c.Report, "report", false, "generate and show iam credential reports")
+	cmdFlags.BoolVar(&c.Findings, "findings", false, "show only the problems found in the credential reports")
+	cmdFlags.IntVar(&c.MaxKeyAge, "max-key-age", 90, "days before an active access key is reported")
+	cmdFlags.IntVar(&c.MaxUnused, "max-unused", 90, "days a password or access key can go unused")
+	c.addFlags(cmdFlags)
 	cmdFlags.Usage = func() { c.Ui.Output(c.Help()); os.Exit(1) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
 
-	org, err := aws.NewOrganization()
+	org, err := c.newOrganization()
 	if err != nil {
-		fmt.Printf("error: could not initialize organization: %s\n", err)
+		c.Ui.Error(fmt.Sprintf("error: could not initialize organization: %s", err))
 		return 1
 	}
 
+	ctx, cancel := interruptContext()
+	defer cancel()
+
 	if len(c.AccountId) > 0 {
+		c.accounts = c.AccountId
+	}
+	accounts, err := c.selectAccounts(ctx, org)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not select accounts: %s", err))
+		return 1
+	}
+
+	if c.Report || c.Findings {
+		return c.runReport(ctx, org, accounts)
+	}
 
-		if c.Report {
-			err = org.GenerateCredentialReportForAccount(c.AccountId)
-			if err != nil {
-				fmt.Printf("error: could generate iam credential report for account: %s\n", err)
-				return 1
-			}
-			err = org.PrintCredentialReportForAccount(c.AccountId)
-			if err != nil {
-				fmt.Printf("error: could get iam credential report for account: %s\n", err)
-				return 1
-			}
-		} else {
-			err = org.PrintUsersForAccount(c.AccountId)
-			if err != nil {
-				fmt.Printf("error: could not list iam users for account: %s\n", err)
-				return 1
-			}
+	users, errs := org.GetUsers(ctx, accounts)
+	printAccountErrors(c.Ui, "could not list users", errs)
+
+	rows := make([]userRow, 0, 200)
+	for _, accountid := range users.Accounts() {
+		for _, user := range users[accountid] {
+			rows = append(rows, userRow{
+				AccountId:        accountid,
+				UserName:         stringValue(user.UserName),
+				Arn:              stringValue(user.Arn),
+				CreateDate:       user.CreateDate,
+				PasswordLastUsed: user.PasswordLastUsed,
+			})
 		}
-	} else {
-		if c.Report {
-
-			err = org.GenerateCredentialReports()
-			if err != nil {
-				fmt.Printf("error: could not generate all iam credential reports: %s\n", err)
-				return 1
-			}
-			err = org.PrintCredentialReports()
-			if err != nil {
-				fmt.Printf("error: could not print all iam credential reports: %s\n", err)
-				return 1
-			}
-
-		} else {
-			err = org.PrintUsers()
-			if err != nil {
-				fmt.Printf("error: could not list all iam users: %s\n", err)
-				return 1
-			}
+	}
+
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print iam users: %s", err))
+		return 1
+	}
+
+	return 0
+}
+
+// runReport prints the iam credential reports of every account as one
+// table, or just the problems found in them.
+func (c *ListUsersCommand) runReport(ctx context.Context, org *aws.Organization, accounts []*organizations.Account) int {
+
+	report, errs := org.GetCredentialReports(ctx, accounts)
+	printAccountErrors(c.Ui, "could not get credentials report", errs)
+
+	if c.Findings {
+		findings := aws.CredentialFindings(report, &aws.FindingOptions{
+			MaxKeyAge: time.Duration(c.MaxKeyAge) * 24 * time.Hour,
+			MaxUnused: time.Duration(c.MaxUnused) * 24 * time.Hour,
+		}, time.Now())
+		rows := make([]findingRow, 0, len(findings))
+		for _, finding := range findings {
+			rows = append(rows, findingRow{
+				AccountId: finding.AccountId,
+				User:      finding.User,
+				Check:     finding.Check,
+				Detail:    finding.Detail,
+			})
+		}
+		if err := c.render(rows); err != nil {
+			c.Ui.Error(fmt.Sprintf("error: could not print findings: %s", err))
+			return 1
 		}
+		return 0
+	}
+
+	rows := make([]credentialReportRow, 0, len(report))
+	for _, r := range report {
+		rows = append(rows, credentialReportRow{
+			AccountId:             r.AccountId,
+			User:                  r.User,
+			Arn:                   r.Arn,
+			UserCreationTime:      r.UserCreationTime,
+			PasswordEnabled:       r.PasswordEnabled,
+			PasswordLastUsed:      r.PasswordLastUsed,
+			PasswordLastChanged:   r.PasswordLastChanged,
+			MFAActive:             r.MFAActive,
+			AccessKey1Active:      r.AccessKey1Active,
+			AccessKey1LastRotated: r.AccessKey1LastRotated,
+			AccessKey1LastUsed:    r.AccessKey1LastUsed,
+			AccessKey2Active:      r.AccessKey2Active,
+			AccessKey2LastRotated: r.AccessKey2LastRotated,
+			AccessKey2LastUsed:    r.AccessKey2LastUsed,
+		})
+	}
+	if err := c.render(rows); err != nil {
+		c.Ui.Error(fmt.Sprintf("error: could not print credential reports: %s", err))
+		return 1
 	}
 
 	return 0
@@ -102,10 +194,14 @@ func (c *ListUsersCommand) Help() string {
 List all iam users within accounts for an organization
 
 Options:
-	    -accountid		list all iam users for a specific account only
-	    -report				run a credential report
+	    -accountid		list all iam users for a specific account only, same as -accounts <id>
+	    -report		generate the iam credential report of each account and print them as one table
+	    -findings		print only credential problems: active root access keys, root
+	    			without mfa, console users without mfa, old access keys and unused credentials
+	    -max-key-age	with -findings, the age in days an active access key is reported at. default 90
+	    -max-unused		with -findings, the days a password or access key can go unused. default 90
 	`
-	return strings.TrimSpace(helpText)
+	return strings.TrimSpace(helpText + orgOptionsHelp)
 }
 
 func (c *ListUsersCommand) Synopsis() string {